@@ -0,0 +1,143 @@
+package sl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Cache stores API responses between calls, e.g. on disk so they survive restarts.
+type Cache interface {
+	// Get returns the entry stored for key or nil if there is none.
+	Get(key string) (*CacheEntry, error)
+
+	// Set stores the entry for key.
+	Set(key string, entry *CacheEntry) error
+}
+
+// CacheEntry represents a cached API response.
+type CacheEntry struct {
+	// Body is the decoded response data encoded as JSON.
+	Body []byte `json:"body"`
+
+	// ETag of the response if any, used to revalidate the entry.
+	ETag string `json:"etag,omitempty"`
+
+	// Header and StatusCode of the http response.
+	Header     http.Header `json:"header"`
+	StatusCode int         `json:"status_code"`
+
+	// Time the response was fetched or last revalidated.
+	Time time.Time `json:"time"`
+}
+
+// CacheOptions specifies optional parameters to CacheMiddleware.
+type CacheOptions struct {
+	// Time to live by call name, e.g. sl.location.search. Calls without a
+	// time to live are not cached. Default is 24 hours for location searches.
+	TTL map[string]time.Duration
+}
+
+// CacheMiddleware returns a middleware that serves responses from the
+// cache while they are fresh. Expired entries with an ETag are revalidated
+// with If-None-Match. The key parameter is not part of the cache key.
+func CacheMiddleware(cache Cache, opt *CacheOptions) Middleware {
+	ttl := map[string]time.Duration{"sl.location.search": 24 * time.Hour}
+	if opt != nil && opt.TTL != nil {
+		ttl = opt.TTL
+	}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			maxAge, ok := ttl[call.Name()]
+			if !ok || maxAge <= 0 {
+				return next(ctx, call)
+			}
+
+			key, err := cacheKey(call)
+			if err != nil {
+				return next(ctx, call)
+			}
+
+			entry, _ := cache.Get(key)
+			if entry != nil && time.Since(entry.Time) < maxAge {
+				if err := entry.decode(call); err == nil {
+					return nil
+				}
+			}
+
+			if entry != nil && len(entry.ETag) > 0 {
+				if call.Header == nil {
+					call.Header = http.Header{}
+				}
+				call.Header.Set("If-None-Match", entry.ETag)
+			}
+
+			if err := next(ctx, call); err != nil {
+				return err
+			}
+
+			if call.Response != nil && call.Response.StatusCode == http.StatusNotModified && entry != nil {
+				entry.Time = time.Now()
+				cache.Set(key, entry)
+				return entry.decode(call)
+			}
+
+			if call.Response == nil || call.Response.StatusCode != http.StatusOK {
+				return nil
+			}
+
+			body, err := json.Marshal(call.Result)
+			if err != nil {
+				return nil
+			}
+
+			cache.Set(key, &CacheEntry{
+				Body:       body,
+				ETag:       call.Response.Header.Get("ETag"),
+				Header:     call.Response.Header,
+				StatusCode: call.Response.StatusCode,
+				Time:       time.Now(),
+			})
+
+			return nil
+		}
+	}
+}
+
+// decode decodes the cached body into the call result.
+func (e *CacheEntry) decode(call *Call) error {
+	if err := json.Unmarshal(e.Body, call.Result); err != nil {
+		return err
+	}
+
+	call.Response = &http.Response{
+		Status:     http.StatusText(e.StatusCode),
+		StatusCode: e.StatusCode,
+		Header:     e.Header,
+		Body:       http.NoBody,
+	}
+
+	return nil
+}
+
+// cacheKey returns the cache key of the call without the key parameter.
+func cacheKey(call *Call) (string, error) {
+	s, err := addOptions(call.Endpoint, call.Options)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Del("key")
+	u.RawQuery = q.Encode()
+
+	return call.Name() + " " + u.String(), nil
+}
@@ -0,0 +1,119 @@
+package sl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryCache is a Cache used for testing.
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]*CacheEntry
+}
+
+func (c *memoryCache) Get(key string) (*CacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key], nil
+}
+
+func (c *memoryCache) Set(key string, entry *CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	return nil
+}
+
+func TestCacheMiddleware(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+
+	var (
+		requests    int
+		notModified int
+	)
+
+	mux.HandleFunc("/typeahead.json", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"StatusCode":0,"Message":null,"ExecutionTime":0,"ResponseData":[{"Name":"Slussen","SiteId":"9192"}]}`)
+	})
+
+	cache := &memoryCache{entries: map[string]*CacheEntry{}}
+	client.Use(CacheMiddleware(cache, nil))
+
+	search := func(key string) {
+		locations, resp, err := client.Location.SearchWithResponse(context.Background(), &LocationSearchOptions{
+			Key:          key,
+			SearchString: "Slussen",
+		})
+		if err != nil {
+			t.Fatalf("Expected nil got error: %v", err)
+		}
+
+		if len(locations) != 1 || locations[0].SiteID != "9192" {
+			t.Fatalf("Expected Slussen got %v", locations)
+		}
+
+		if resp == nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected response with status 200 got %v", resp)
+		}
+	}
+
+	search("XXXX")
+	search("YYYY")
+
+	if requests != 1 {
+		t.Errorf("Expected 1 request got %d", requests)
+	}
+
+	for _, e := range cache.entries {
+		e.Time = time.Now().Add(-48 * time.Hour)
+	}
+
+	search("XXXX")
+
+	if requests != 2 || notModified != 1 {
+		t.Errorf("Expected revalidation with If-None-Match got %d requests and %d not modified", requests, notModified)
+	}
+
+	for _, e := range cache.entries {
+		if time.Since(e.Time) > time.Minute {
+			t.Errorf("Expected revalidated entry to be refreshed got %s", e.Time)
+		}
+	}
+}
+
+func TestCacheMiddlewareTTL(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+
+	var requests int
+	mux.HandleFunc("/realtimedeparturesV4.json", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"StatusCode":0,"Message":null,"ExecutionTime":0,"ResponseData":{}}`)
+	})
+
+	cache := &memoryCache{entries: map[string]*CacheEntry{}}
+	client.Use(CacheMiddleware(cache, nil))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Realtime.Search(context.Background(), &RealtimeSearchOptions{Key: "XXXX", SiteID: "9192"}); err != nil {
+			t.Fatalf("Expected nil got error: %v", err)
+		}
+	}
+
+	if requests != 2 || len(cache.entries) != 0 {
+		t.Errorf("Expected realtime calls not to be cached got %d requests and %d entries", requests, len(cache.entries))
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/frozzare/go-sl"
+)
+
+// memoryCache is a sl.Cache that keeps at most size entries in memory.
+// The oldest stored entry is removed when the cache is full.
+type memoryCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*sl.CacheEntry
+	keys    []string
+}
+
+// newMemoryCache creates a memory cache with room for size entries.
+func newMemoryCache(size int) *memoryCache {
+	return &memoryCache{size: size, entries: map[string]*sl.CacheEntry{}}
+}
+
+// Get returns the entry stored for key or nil if there is none.
+func (c *memoryCache) Get(key string) (*sl.CacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries[key], nil
+}
+
+// Set stores the entry for key.
+func (c *memoryCache) Set(key string, entry *sl.CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		c.keys = append(c.keys, key)
+	}
+	c.entries[key] = entry
+
+	for len(c.keys) > c.size {
+		delete(c.entries, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+
+	return nil
+}
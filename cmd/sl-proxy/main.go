@@ -0,0 +1,188 @@
+// Command sl-proxy serves a small REST API over the SL APIs, so frontends
+// can use SL without holding the API keys.
+//
+// Usage:
+//
+//	sl-proxy [flags]
+//
+// The endpoints are:
+//
+//	GET /locations?q=<text>                   search for locations
+//	GET /sites/{id}/departures?window=<min>    realtime departures from a site
+//	GET /sites/{id}/departures/stream          departure changes as Server-Sent Events or WebSocket
+//	GET /trips?from=<site>&to=<site>           plan a trip, from and to can be site ids or names
+//	GET /journeys/{ref}?date=<YYYY-MM-DD>      stops of a journey
+//	GET, POST /graphql                         GraphQL queries, see package graphql
+//
+// Responses are JSON encoded library models. Keys are read from the SL_KEY,
+// SL_LOCATION_KEY, SL_REALTIME_KEY and SL_TRAVELPLANNER_KEY environment
+// variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/frozzare/go-sl"
+	"github.com/frozzare/go-sl/slcache"
+)
+
+// Keys holds the API keys. Key is used for every API that has no key of its own.
+type Keys struct {
+	Key              string
+	LocationKey      string
+	RealtimeKey      string
+	TravelPlannerKey string
+}
+
+// keysFromEnv returns the keys from the environment variables.
+func keysFromEnv() Keys {
+	return Keys{
+		Key:              os.Getenv("SL_KEY"),
+		LocationKey:      os.Getenv("SL_LOCATION_KEY"),
+		RealtimeKey:      os.Getenv("SL_REALTIME_KEY"),
+		TravelPlannerKey: os.Getenv("SL_TRAVELPLANNER_KEY"),
+	}
+}
+
+// key returns the api specific key if set, otherwise the common key.
+func (k Keys) key(specific string) string {
+	if len(specific) > 0 {
+		return specific
+	}
+
+	return k.Key
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := run(ctx, os.Args[1:], os.Stderr); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "sl-proxy:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run parses the flags and serves until ctx is done.
+func run(ctx context.Context, args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet("sl-proxy", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	addr := fs.String("addr", ":8080", "address to listen on")
+	baseURL := fs.String("base-url", os.Getenv("SL_BASE_URL"), "override the SL API base url")
+	cacheDir := fs.String("cache-dir", "", "directory to cache responses in, default is in memory")
+	origins := fs.String("cors-origins", "", "comma separated origins allowed to call the api from browsers, * allows all")
+	rate := fs.Int("rate", 60, "requests per minute allowed per client")
+	burst := fs.Int("burst", 20, "requests allowed in a burst per client")
+	trustProxy := fs.Bool("trust-proxy", false, "use the last X-Forwarded-For address as the client address")
+	streamSites := fs.Int("stream-sites", 100, "max number of sites streamed at once")
+	streamClients := fs.Int("stream-clients", 1000, "max number of clients streaming a site")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	keys := keysFromEnv()
+	if len(keys.Key) == 0 && (len(keys.LocationKey) == 0 || len(keys.RealtimeKey) == 0 || len(keys.TravelPlannerKey) == 0) {
+		return sl.ErrNoKey
+	}
+
+	logger := slog.New(slog.NewTextHandler(stderr, nil))
+
+	client, err := newClient(*baseURL)
+	if err != nil {
+		return err
+	}
+	client.Logger = logger
+
+	var cache sl.Cache = newMemoryCache(10000)
+	if len(*cacheDir) > 0 {
+		cache, err = slcache.New(*cacheDir, &slcache.Options{MaxSize: 100 << 20, MaxAge: 24 * time.Hour})
+		if err != nil {
+			return err
+		}
+	}
+
+	client.Use(sl.CacheMiddleware(cache, &sl.CacheOptions{TTL: cacheTTL}))
+
+	// The stream polls at its own interval and shares the polls between
+	// clients, so it doesn't use the cache.
+	streamClient, err := newClient(*baseURL)
+	if err != nil {
+		return err
+	}
+	streamClient.Logger = logger
+
+	h := newServer(client, streamClient, keys, &serverOptions{
+		Origins:       strings.Split(*origins, ","),
+		Rate:          *rate,
+		Burst:         *burst,
+		TrustProxy:    *trustProxy,
+		StreamSites:   *streamSites,
+		StreamClients: *streamClients,
+	})
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	logger.Info("sl-proxy listening", slog.String("addr", *addr))
+
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+// cacheTTL is the time to live of cached responses by call name.
+var cacheTTL = map[string]time.Duration{
+	"sl.location.search":       24 * time.Hour,
+	"sl.realtime.search":       30 * time.Second,
+	"sl.travelplanner.trip":    time.Minute,
+	"sl.travelplanner.journey": 5 * time.Minute,
+}
+
+// newClient creates a SL client using the base url if any.
+func newClient(baseURL string) (*sl.Client, error) {
+	client := sl.NewClient(nil)
+	client.UserAgent = "go-sl-proxy"
+
+	if len(baseURL) == 0 {
+		return client, nil
+	}
+
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	client.BaseURL = u
+	return client, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errRateLimited is returned when a client has made too many requests.
+var errRateLimited = errors.New("too many requests")
+
+// maxBuckets is the number of clients tracked before idle buckets are removed.
+const maxBuckets = 10000
+
+// bucket is a token bucket of one client.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter limits the requests per client with a token bucket per client.
+type limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	rate    float64 // tokens per second
+	burst   float64
+	now     func() time.Time
+}
+
+// newLimiter creates a limiter allowing rate requests per minute and burst
+// requests at once. Burst is at least one.
+func newLimiter(rate, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &limiter{
+		buckets: map[string]*bucket{},
+		rate:    float64(rate) / 60,
+		burst:   float64(burst),
+		now:     time.Now,
+	}
+}
+
+// allow reports whether the client may make a request now, otherwise how
+// long until it may.
+func (l *limiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.prune(now)
+		}
+
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// prune removes the buckets that would be full by now.
+func (l *limiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// rateLimit returns a handler that responds with 429 Too Many Requests when
+// a client exceeds the limiter.
+func rateLimit(next http.Handler, l *limiter, trustProxy bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ok, wait := l.allow(clientAddr(r, trustProxy))
+		if !ok {
+			w.Header().Set("Retry-After", retryAfter(wait))
+			writeError(w, http.StatusTooManyRequests, errRateLimited)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientAddr returns the client ip address of the request. If trustProxy
+// is true the last X-Forwarded-For address is used, since it is added by
+// the trusted proxy while earlier addresses are set by the client.
+func clientAddr(r *http.Request, trustProxy bool) string {
+	if values := r.Header.Values("X-Forwarded-For"); trustProxy && len(values) > 0 {
+		addrs := strings.Split(values[len(values)-1], ",")
+		if addr := strings.TrimSpace(addrs[len(addrs)-1]); len(addr) > 0 {
+			return addr
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// allowedOrigins returns the set of origins, where * allows all origins.
+func allowedOrigins(origins []string) map[string]bool {
+	allowed := map[string]bool{}
+	for _, o := range origins {
+		if o = strings.TrimSpace(o); len(o) > 0 {
+			allowed[o] = true
+		}
+	}
+
+	return allowed
+}
+
+// checkOrigin returns a function reporting whether a request is from an
+// allowed origin or not from a browser, i.e. has no Origin header. It is
+// used for WebSocket upgrades, which browsers don't apply CORS to.
+func checkOrigin(origins []string) func(r *http.Request) bool {
+	allowed := allowedOrigins(origins)
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return len(origin) == 0 || allowed["*"] || allowed[origin]
+	}
+}
+
+// cors returns a handler that adds CORS headers for the allowed origins and
+// answers preflight requests. No CORS headers are added if there are no
+// allowed origins.
+func cors(next http.Handler, origins []string) http.Handler {
+	allowed := allowedOrigins(origins)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+
+		if len(origin) > 0 && (allowed["*"] || allowed[origin]) {
+			if allowed["*"] {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			w.Header().Set("Access-Control-Expose-Headers", "Retry-After")
+
+			if r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) > 0 {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+				w.Header().Set("Access-Control-Max-Age", "86400")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frozzare/go-sl"
+	"github.com/frozzare/go-sl/graphql"
+	"github.com/frozzare/go-sl/slstream"
+)
+
+// errNotFound is returned when a location or trip can't be found.
+var errNotFound = errors.New("not found")
+
+// serverOptions specifies optional parameters to newServer.
+type serverOptions struct {
+	// Origins allowed to call the api, * allows all. Default is none.
+	Origins []string
+
+	// Rate is the requests per minute allowed per client and Burst the
+	// requests allowed at once. Rate limiting is disabled if Rate is zero.
+	Rate  int
+	Burst int
+
+	// TrustProxy uses the last X-Forwarded-For address, the one added by
+	// the proxy in front of the server, as the client address.
+	TrustProxy bool
+
+	// StreamSites is the max number of sites streamed at once and
+	// StreamClients the max number of clients streaming a site. Zero
+	// means no limit.
+	StreamSites   int
+	StreamClients int
+}
+
+// server implements the proxy endpoints.
+type server struct {
+	client  *sl.Client
+	keys    Keys
+	graphql http.Handler
+	stream  http.Handler
+}
+
+// newServer returns the proxy handler. Streamed departures are polled with
+// streamClient, which should not cache responses since a cached response
+// would add up to its time to live to the age of the departures.
+func newServer(client, streamClient *sl.Client, keys Keys, opt *serverOptions) http.Handler {
+	if opt == nil {
+		opt = &serverOptions{}
+	}
+
+	var h http.Handler = &server{
+		client: client,
+		keys:   keys,
+		graphql: graphql.NewHandler(graphql.New(client, &graphql.Options{
+			Key:              keys.Key,
+			LocationKey:      keys.LocationKey,
+			RealtimeKey:      keys.RealtimeKey,
+			TravelPlannerKey: keys.TravelPlannerKey,
+		})),
+		stream: slstream.NewHandler(streamClient, &slstream.Options{
+			Key:         keys.key(keys.RealtimeKey),
+			MaxSites:    opt.StreamSites,
+			MaxClients:  opt.StreamClients,
+			CheckOrigin: checkOrigin(opt.Origins),
+		}),
+	}
+	if opt.Rate > 0 {
+		h = rateLimit(h, newLimiter(opt.Rate, opt.Burst), opt.TrustProxy)
+	}
+
+	return cors(h, opt.Origins)
+}
+
+// ServeHTTP routes the request to the endpoint handlers.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.Trim(r.URL.Path, "/") == "graphql" {
+		s.graphql.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "locations":
+		s.locations(w, r)
+	case len(parts) == 3 && parts[0] == "sites" && parts[2] == "departures":
+		s.departures(w, r, parts[1])
+	case len(parts) == 4 && parts[0] == "sites" && parts[2] == "departures" && parts[3] == "stream":
+		s.streamDepartures(w, r, parts[1])
+	case len(parts) == 1 && parts[0] == "trips":
+		s.trips(w, r)
+	case len(parts) == 2 && parts[0] == "journeys" && len(parts[1]) > 0:
+		s.journey(w, r, parts[1])
+	default:
+		writeError(w, http.StatusNotFound, errNotFound)
+	}
+}
+
+// locationsResponse is the response of /locations.
+type locationsResponse struct {
+	Locations []*sl.Location `json:"locations"`
+}
+
+// locations searches for locations matching the q parameter.
+func (s *server) locations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if len(q) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("q can't be empty"))
+		return
+	}
+
+	locations, err := s.client.Location.Search(r.Context(), &sl.LocationSearchOptions{
+		Key:          s.keys.key(s.keys.LocationKey),
+		SearchString: q,
+	})
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	if locations == nil {
+		locations = []*sl.Location{}
+	}
+
+	writeJSON(w, http.StatusOK, &locationsResponse{Locations: locations})
+}
+
+// departuresResponse is the response of /sites/{id}/departures.
+type departuresResponse struct {
+	SiteID       string          `json:"site_id"`
+	LatestUpdate string          `json:"latest_update"`
+	Departures   []*sl.Transport `json:"departures"`
+}
+
+// departures returns the realtime departures from a site. The window
+// parameter is the time window in minutes, max 60.
+func (s *server) departures(w http.ResponseWriter, r *http.Request, id string) {
+	if _, err := strconv.Atoi(id); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("site id must be a number"))
+		return
+	}
+
+	window, err := intParam(r, "window", 0, 60)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	realtime, err := s.client.Realtime.Search(r.Context(), &sl.RealtimeSearchOptions{
+		Key:        s.keys.key(s.keys.RealtimeKey),
+		SiteID:     id,
+		TimeWindow: window,
+	})
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	res := &departuresResponse{SiteID: id, Departures: []*sl.Transport{}}
+	if realtime != nil {
+		res.LatestUpdate = realtime.LatestUpdate
+		res.Departures = append(res.Departures, realtime.Departures()...)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// streamDepartures streams the departures from a site as Server-Sent
+// Events or over WebSocket.
+func (s *server) streamDepartures(w http.ResponseWriter, r *http.Request, id string) {
+	if _, err := strconv.Atoi(id); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("site id must be a number"))
+		return
+	}
+
+	r = r.Clone(r.Context())
+	r.URL.RawQuery = url.Values{"siteId": {id}}.Encode()
+
+	s.stream.ServeHTTP(w, r)
+}
+
+// tripsResponse is the response of /trips.
+type tripsResponse struct {
+	Trips []*sl.Trip `json:"trips"`
+}
+
+// trips plans a trip between the from and to parameters, which can be site
+// ids or names. The date, time and arrival parameters are optional.
+func (s *server) trips(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if len(q.Get("from")) == 0 || len(q.Get("to")) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("from and to can't be empty"))
+		return
+	}
+
+	origin, err := s.resolveSite(r.Context(), q.Get("from"))
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	dest, err := s.resolveSite(r.Context(), q.Get("to"))
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	opt := &sl.TripOptions{
+		Key:      s.keys.key(s.keys.TravelPlannerKey),
+		OriginID: origin,
+		DestID:   dest,
+		Date:     q.Get("date"),
+		Time:     q.Get("time"),
+	}
+
+	if arrival, _ := strconv.ParseBool(q.Get("arrival")); arrival {
+		opt.SearchForArrival = 1
+	}
+
+	trips, err := s.client.TravelPlanner.Trip(r.Context(), opt)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	if trips == nil {
+		trips = []*sl.Trip{}
+	}
+
+	writeJSON(w, http.StatusOK, &tripsResponse{Trips: trips})
+}
+
+// journey returns the stops of a journey by the reference from a trip leg.
+func (s *server) journey(w http.ResponseWriter, r *http.Request, ref string) {
+	journey, err := s.client.TravelPlanner.Journey(r.Context(), &sl.JourneyOptions{
+		Key:  s.keys.key(s.keys.TravelPlannerKey),
+		ID:   ref,
+		Date: r.URL.Query().Get("date"),
+	})
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, journey)
+}
+
+// resolveSite returns s if it is a site id, otherwise the site id of the
+// first location found when searching for s.
+func (s *server) resolveSite(ctx context.Context, name string) (string, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		return name, nil
+	}
+
+	locations, err := s.client.Location.Search(ctx, &sl.LocationSearchOptions{
+		Key:          s.keys.key(s.keys.LocationKey),
+		SearchString: name,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(locations) == 0 {
+		return "", errNotFound
+	}
+
+	return locations[0].SiteID, nil
+}
+
+// intParam returns the integer query parameter name between 0 and max or
+// def if the parameter is missing.
+func intParam(r *http.Request, name string, def, max int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if len(s) == 0 {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > max {
+		return 0, errors.New(name + " must be a number between 0 and " + strconv.Itoa(max))
+	}
+
+	return n, nil
+}
+
+// errorStatus returns the http status code used for err.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, errNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, sl.ErrNoKey):
+		return http.StatusInternalServerError
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// errorResponse is the response of failed requests.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError writes err as a JSON error response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+// writeJSON writes v as JSON with the status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// retryAfter returns the Retry-After header value for d.
+func retryAfter(d time.Duration) string {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+
+	return strconv.Itoa(secs)
+}
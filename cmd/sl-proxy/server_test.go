@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frozzare/go-sl"
+	"github.com/frozzare/go-sl/sltest"
+)
+
+func setupProxy(t *testing.T, opt *serverOptions) (*sltest.Server, http.Handler) {
+	server := sltest.NewServer()
+	t.Cleanup(server.Close)
+
+	client := server.Client()
+	client.Use(sl.CacheMiddleware(newMemoryCache(10), &sl.CacheOptions{TTL: cacheTTL}))
+
+	return server, newServer(client, server.Client(), Keys{Key: "XXXX"}, opt)
+}
+
+func get(h http.Handler, target string, v interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+
+	if v != nil {
+		json.Unmarshal(w.Body.Bytes(), v)
+	}
+
+	return w
+}
+
+func TestLocations(t *testing.T) {
+	server, h := setupProxy(t, nil)
+
+	var res locationsResponse
+	if w := get(h, "/locations?q=slussen", &res); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 got %d: %s", w.Code, w.Body)
+	}
+
+	if len(res.Locations) == 0 || res.Locations[0].SiteID != "9192" {
+		t.Errorf("Expected site 9192 got %v", res.Locations)
+	}
+
+	get(h, "/locations?q=slussen", nil)
+
+	if reqs := server.Requests(); len(reqs) != 1 || reqs[0].Query.Get("searchstring") != "slussen" {
+		t.Errorf("Expected one cached location search got %v", reqs)
+	}
+
+	if w := get(h, "/locations", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 got %d", w.Code)
+	}
+}
+
+func TestDepartures(t *testing.T) {
+	server, h := setupProxy(t, nil)
+
+	var res departuresResponse
+	if w := get(h, "/sites/1002/departures?window=30", &res); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 got %d: %s", w.Code, w.Body)
+	}
+
+	if res.SiteID != "1002" || res.LatestUpdate != "2017-12-18T20:10:19" || len(res.Departures) == 0 {
+		t.Errorf("Expected departures from 1002 got %+v", res)
+	}
+
+	if reqs := server.Requests(); len(reqs) != 1 || reqs[0].Query.Get("timeWindow") != "30" {
+		t.Errorf("Expected realtime search within 30 minutes got %v", reqs)
+	}
+
+	for _, target := range []string{"/sites/abc/departures", "/sites/1002/departures?window=90"} {
+		if w := get(h, target, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s got %d", target, w.Code)
+		}
+	}
+
+	server.SetMessage(sltest.EndpointRealtime, "Invalid site")
+
+	var e errorResponse
+	if w := get(h, "/sites/1003/departures", &e); w.Code != http.StatusBadGateway || e.Error != "Invalid site" {
+		t.Errorf("Expected 502 with 'Invalid site' got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestTrips(t *testing.T) {
+	server, h := setupProxy(t, nil)
+
+	var res tripsResponse
+	if w := get(h, "/trips?from=slussen&to=1002&arrival=true&time=08:00", &res); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 got %d: %s", w.Code, w.Body)
+	}
+
+	if len(res.Trips) == 0 || len(res.Trips[0].LegList.Leg) == 0 {
+		t.Errorf("Expected trips got %v", res.Trips)
+	}
+
+	reqs := server.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("Expected 2 requests got %d", len(reqs))
+	}
+
+	q := reqs[1].Query
+	if q.Get("originId") != "9192" || q.Get("destId") != "1002" || q.Get("searchForArrival") != "1" || q.Get("time") != "08:00" {
+		t.Errorf("Expected trip from 9192 to 1002 got %v", q)
+	}
+
+	if w := get(h, "/trips?from=slussen", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 got %d", w.Code)
+	}
+
+	server.SetFixture(sltest.EndpointTypeahead, `{"StatusCode":0,"ResponseData":[]}`)
+
+	if w := get(h, "/trips?from=nowhere&to=1002", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 got %d", w.Code)
+	}
+}
+
+func TestJourney(t *testing.T) {
+	server, h := setupProxy(t, nil)
+
+	var res sl.Journey
+	if w := get(h, "/journeys/1%7C4455%7C1%7C74%7C18122017?date=2017-12-18", &res); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 got %d: %s", w.Code, w.Body)
+	}
+
+	if len(res.Stops.Stop) == 0 || res.Stops.Stop[0].Name != "Slussen" {
+		t.Errorf("Expected stops from Slussen got %v", res.Stops.Stop)
+	}
+
+	if reqs := server.Requests(); len(reqs) != 1 || reqs[0].Query.Get("id") != "1|4455|1|74|18122017" {
+		t.Errorf("Expected journey 1|4455|1|74|18122017 got %v", reqs)
+	}
+
+	if w := get(h, "/unknown", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 got %d", w.Code)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	_, h := setupProxy(t, &serverOptions{Origins: []string{"https://example.com"}})
+
+	r := httptest.NewRequest("OPTIONS", "/locations", nil)
+	r.Header.Set("Origin", "https://example.com")
+	r.Header.Set("Access-Control-Request-Method", "GET")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 got %d", w.Code)
+	}
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://example.com" {
+		t.Errorf("Expected 'https://example.com' got %s", got)
+	}
+
+	r = httptest.NewRequest("GET", "/locations?q=slussen", nil)
+	r.Header.Set("Origin", "https://evil.com")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected empty got %s", got)
+	}
+}
+
+func TestCORSDefault(t *testing.T) {
+	_, h := setupProxy(t, nil)
+
+	r := httptest.NewRequest("GET", "/locations?q=slussen", nil)
+	r.Header.Set("Origin", "https://example.com")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected empty got %s", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	_, h := setupProxy(t, &serverOptions{Rate: 60, Burst: 2})
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := get(h, "/locations?q=slussen", nil)
+		if w.Code != want {
+			t.Errorf("Expected %d for request %d got %d", want, i, w.Code)
+		}
+
+		if want == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "1" {
+			t.Errorf("Expected '1' got %s", w.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	_, h := setupProxy(t, &serverOptions{Rate: 60, Burst: 2, TrustProxy: true})
+
+	// The client sets the first address, the proxy appends the last.
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		r := httptest.NewRequest("GET", "/locations?q=slussen", nil)
+		r.Header.Set("X-Forwarded-For", fmt.Sprintf("192.0.2.%d, 198.51.100.7", i))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != want {
+			t.Errorf("Expected %d for request %d got %d", want, i, w.Code)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2017, 12, 18, 20, 0, 0, 0, time.UTC)
+
+	l := newLimiter(6, 1)
+	l.now = func() time.Time { return now }
+
+	if ok, _ := l.allow("a"); !ok {
+		t.Errorf("Expected true got false")
+	}
+
+	if ok, wait := l.allow("a"); ok || wait != 10*time.Second {
+		t.Errorf("Expected false and 10s got %v and %v", ok, wait)
+	}
+
+	if ok, _ := l.allow("b"); !ok {
+		t.Errorf("Expected true for another client got false")
+	}
+
+	now = now.Add(10 * time.Second)
+
+	if ok, _ := l.allow("a"); !ok {
+		t.Errorf("Expected true after 10s got false")
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	server, h := setupProxy(t, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ site(id: 9192) { name departures { lineNumber } } }"}`)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 got %d: %s", w.Code, w.Body)
+	}
+
+	want := `{"data":{"site":{"name":"T-Centralen","departures":[{"lineNumber":"11"}]}}}`
+	if got := strings.TrimSpace(w.Body.String()); got != want {
+		t.Errorf("Expected %s got %s", want, got)
+	}
+
+	if reqs := server.Requests(); len(reqs) != 1 || reqs[0].Key() != "XXXX" {
+		t.Errorf("Expected one realtime request with key 'XXXX' got %v", reqs)
+	}
+}
+
+func TestStreamDepartures(t *testing.T) {
+	server, h := setupProxy(t, nil)
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	// Cache the departures, the stream must not be served from the cache.
+	if w := get(h, "/sites/1051/departures", nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 got %d", w.Code)
+	}
+
+	server.SetFixture(sltest.EndpointRealtime, strings.Replace(sltest.RealtimeFixture, "2017-12-18T20:11:03", "2017-12-18T20:12:30", 1))
+
+	resp, err := http.Get(ts.URL + "/sites/1051/departures/stream")
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	line, err := r.ReadString('\n')
+	if err != nil || line != "event: snapshot\n" {
+		t.Errorf("Expected snapshot event got %q, %v", line, err)
+	}
+
+	line, err = r.ReadString('\n')
+	if err != nil || !strings.Contains(line, "2017-12-18T20:12:30") {
+		t.Errorf("Expected snapshot with updated departure got %q, %v", line, err)
+	}
+
+	if w := get(h, "/sites/abc/departures/stream", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 got %d", w.Code)
+	}
+}
+
+func TestStreamDeparturesOrigin(t *testing.T) {
+	_, h := setupProxy(t, &serverOptions{Origins: []string{"https://example.com"}})
+
+	for origin, want := range map[string]int{
+		"https://evil.com":    http.StatusForbidden,
+		"https://example.com": http.StatusBadRequest,
+	} {
+		// Without a Sec-WebSocket-Key the upgrade fails after the origin check.
+		r := httptest.NewRequest("GET", "/sites/1051/departures/stream", nil)
+		r.Header.Set("Connection", "Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Sec-WebSocket-Version", "13")
+		r.Header.Set("Origin", origin)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != want {
+			t.Errorf("Expected %d for %s got %d", want, origin, w.Code)
+		}
+	}
+}
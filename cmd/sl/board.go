@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+// ANSI escape sequences used by the departure board.
+const (
+	ansiEnterScreen = "\x1b[?1049h\x1b[?25l"
+	ansiLeaveScreen = "\x1b[?25h\x1b[?1049l"
+	ansiClear       = "\x1b[H\x1b[2J"
+	ansiReset       = "\x1b[0m"
+	ansiBold        = "\x1b[1m"
+	ansiDim         = "\x1b[2m"
+	ansiRed         = "\x1b[31m"
+	ansiYellow      = "\x1b[33m"
+	ansiCyan        = "\x1b[36m"
+)
+
+// board holds the state of a departure board.
+type board struct {
+	site        string
+	data        *sl.RealtimeResponse
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	err         error
+}
+
+// boardCommand renders a full screen departure board that is refreshed
+// until the command is interrupted.
+func boardCommand(e *env, args []string) (*result, error) {
+	fs := flag.NewFlagSet("board", flag.ContinueOnError)
+	refresh := fs.Duration("refresh", 30*time.Second, "how often departures are fetched")
+	window := fs.Int("window", 0, "time window in minutes, max 60")
+	args, err := parseArgs(e, fs, args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if *refresh < time.Second {
+		*refresh = time.Second
+	}
+
+	b := &board{site: args[0]}
+
+	fetch := func() {
+		data, err := e.client.Realtime.Search(e.ctx, &sl.RealtimeSearchOptions{
+			Key:        e.config.key(e.config.RealtimeKey),
+			SiteID:     b.site,
+			TimeWindow: *window,
+		})
+
+		// Keep showing the last departures if the refresh fails and wait
+		// a full refresh interval before trying again.
+		b.attemptedAt = time.Now()
+		b.err = err
+		if err == nil {
+			b.data = data
+			b.fetchedAt = time.Now()
+		}
+	}
+
+	fmt.Fprint(e.stdout, ansiEnterScreen)
+	defer fmt.Fprint(e.stdout, ansiLeaveScreen)
+
+	fetch()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		if time.Since(b.attemptedAt) >= *refresh {
+			fetch()
+		}
+
+		fmt.Fprint(e.stdout, ansiClear)
+		b.render(e.stdout, time.Now())
+
+		select {
+		case <-e.ctx.Done():
+			return nil, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// boardGroup represents departures with the same transport mode and platform.
+type boardGroup struct {
+	mode       string
+	platform   string
+	departures []*sl.Transport
+}
+
+// render writes the departure board to w with minutes to departure computed from now.
+func (b *board) render(w io.Writer, now time.Time) {
+	var buf bytes.Buffer
+
+	name := b.site
+	if b.data != nil {
+		if d := b.data.Departures(); len(d) > 0 {
+			name = d[0].StopAreaName
+		}
+	}
+
+	fmt.Fprintf(&buf, "%s%s%s  %s\n\n", ansiBold, name, ansiReset, now.In(sl.Stockholm()).Format("15:04:05"))
+
+	if b.data != nil {
+		for _, g := range groupDepartures(b.data.Departures()) {
+			fmt.Fprintf(&buf, "%s%s", ansiBold, g.mode)
+			if len(g.platform) > 0 {
+				fmt.Fprintf(&buf, " · platform %s", g.platform)
+			}
+			fmt.Fprintf(&buf, "%s\n", ansiReset)
+
+			for _, t := range g.departures {
+				when, ok := minutesToDeparture(t, now)
+				if !ok {
+					continue
+				}
+
+				fmt.Fprintf(&buf, "  %-5s %-30s %8s\n", t.LineNumber, t.Destination, when)
+
+				for _, d := range t.Deviations {
+					fmt.Fprintf(&buf, "        %s%s%s\n", importanceColor(d.ImportanceLevel), d.Text, ansiReset)
+				}
+			}
+
+			fmt.Fprintln(&buf)
+		}
+
+		for _, d := range b.data.StopPointDeviations {
+			fmt.Fprintf(&buf, "%s! %s%s\n", importanceColor(d.Deviation.ImportanceLevel), d.Deviation.Text, ansiReset)
+		}
+
+		fmt.Fprintf(&buf, "\n%supdated %s ago%s\n", ansiDim, now.Sub(b.fetchedAt).Truncate(time.Second), ansiReset)
+	}
+
+	if b.err != nil {
+		fmt.Fprintf(&buf, "%s%s%s\n", ansiRed, b.err, ansiReset)
+	}
+
+	w.Write(buf.Bytes())
+}
+
+// groupDepartures groups departures by transport mode and platform.
+func groupDepartures(departures []*sl.Transport) []*boardGroup {
+	var groups []*boardGroup
+	index := map[string]*boardGroup{}
+
+	for _, t := range departures {
+		key := t.TransportMode + "\x00" + t.StopPointDesignation
+		g, ok := index[key]
+		if !ok {
+			g = &boardGroup{mode: t.TransportMode, platform: t.StopPointDesignation}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.departures = append(g.departures, t)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].mode != groups[j].mode {
+			return groups[i].mode < groups[j].mode
+		}
+		return groups[i].platform < groups[j].platform
+	})
+
+	for _, g := range groups {
+		sort.SliceStable(g.departures, func(i, j int) bool {
+			return g.departures[i].ExpectedDateTime < g.departures[j].ExpectedDateTime
+		})
+	}
+
+	return groups
+}
+
+// minutesToDeparture returns the time left to departure as shown on the
+// board. False is returned if the departure has left.
+func minutesToDeparture(t *sl.Transport, now time.Time) (string, bool) {
+	s := t.ExpectedDateTime
+	if len(s) == 0 {
+		s = t.TimeTabledDateTime
+	}
+
+	expected, err := sl.ParseRealtimeTime(s)
+	if err != nil {
+		return strings.TrimSpace(t.DisplayTime), true
+	}
+
+	left := expected.Sub(now)
+	if left < -30*time.Second {
+		return "", false
+	}
+
+	if left < time.Minute {
+		return "Nu", true
+	}
+
+	return fmt.Sprintf("%d min", int(left/time.Minute)), true
+}
+
+// importanceColor returns the color used for a deviation with the given importance level.
+func importanceColor(level int) string {
+	switch {
+	case level >= 7:
+		return ansiRed
+	case level >= 4:
+		return ansiYellow
+	default:
+		return ansiCyan
+	}
+}
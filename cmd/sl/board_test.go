@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+const testBoardResponse = `{"Metros":[{"TransportMode":"METRO","LineNumber":"11","Destination":"Akalla","StopAreaName":"T-Centralen","StopPointDesignation":"5","ExpectedDateTime":"2017-12-18T20:15:03","DisplayTime":"5 min"},{"TransportMode":"METRO","LineNumber":"10","Destination":"Hjulsta","StopAreaName":"T-Centralen","StopPointDesignation":"5","ExpectedDateTime":"2017-12-18T20:10:20","DisplayTime":"Nu"},{"TransportMode":"METRO","LineNumber":"13","Destination":"Ropsten","StopAreaName":"T-Centralen","StopPointDesignation":"3","ExpectedDateTime":"2017-12-18T20:05:00","DisplayTime":"Nu"}],"Buses":[{"TransportMode":"BUS","LineNumber":"53","Destination":"Karolinska","StopAreaName":"Centralen","StopPointDesignation":"B","ExpectedDateTime":"2017-12-18T20:20:00","DisplayTime":"20:20","Deviations":[{"Text":"Inställd","ImportanceLevel":7}]}],"StopPointDeviations":[{"Deviation":{"Text":"Hissen är ur funktion","ImportanceLevel":2}}]}`
+
+func TestBoardRender(t *testing.T) {
+	var data *sl.RealtimeResponse
+	if err := json.Unmarshal([]byte(testBoardResponse), &data); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	now := time.Date(2017, 12, 18, 20, 10, 0, 0, sl.Stockholm())
+	b := &board{site: "1002", data: data, fetchedAt: now.Add(-5 * time.Second)}
+
+	var buf bytes.Buffer
+	b.render(&buf, now)
+	out := buf.String()
+
+	for _, want := range []string{
+		"T-Centralen",
+		"METRO · platform 5",
+		"Nu",
+		"5 min",
+		ansiRed + "Inställd",
+		ansiCyan + "! Hissen är ur funktion",
+		"updated 5s ago",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected board to contain %q got %s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "Ropsten") {
+		t.Errorf("Expected departed metro to be hidden got %s", out)
+	}
+
+	if strings.Index(out, "Hjulsta") > strings.Index(out, "Akalla") {
+		t.Errorf("Expected Hjulsta before Akalla got %s", out)
+	}
+
+	if strings.Index(out, "BUS") > strings.Index(out, "METRO") {
+		t.Errorf("Expected buses before metros got %s", out)
+	}
+}
+
+func TestBoardCommandFailure(t *testing.T) {
+	mux, baseURL, teardown := setupServer(t)
+	defer teardown()
+
+	var requests int32
+	mux.HandleFunc("/api2/realtimedeparturesV4.json", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	})
+
+	config := &Config{Key: "XXXX", BaseURL: baseURL}
+	client, err := newClient(config)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	// The board ticks every second, a failed fetch must not be retried
+	// before the refresh interval has passed.
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	e := &env{ctx: ctx, client: client, config: config, stdout: &stdout, stderr: &stdout}
+
+	if _, err := boardCommand(e, []string{"-refresh", "1m", "1002"}); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected 1 request got %d", got)
+	}
+
+	if !strings.Contains(stdout.String(), ansiRed) {
+		t.Errorf("Expected board to show the error got %s", stdout.String())
+	}
+}
+
+func TestMinutesToDeparture(t *testing.T) {
+	now := time.Date(2017, 12, 18, 20, 10, 0, 0, sl.Stockholm())
+
+	tests := []struct {
+		transport *sl.Transport
+		want      string
+		ok        bool
+	}{
+		{&sl.Transport{ExpectedDateTime: "2017-12-18T20:10:40"}, "Nu", true},
+		{&sl.Transport{ExpectedDateTime: "2017-12-18T20:22:10"}, "12 min", true},
+		{&sl.Transport{TimeTabledDateTime: "2017-12-18T20:13:00"}, "3 min", true},
+		{&sl.Transport{ExpectedDateTime: "2017-12-18T20:09:00"}, "", false},
+		{&sl.Transport{DisplayTime: "20:30"}, "20:30", true},
+	}
+
+	for _, test := range tests {
+		got, ok := minutesToDeparture(test.transport, now)
+		if got != test.want || ok != test.ok {
+			t.Errorf("Expected %q, %v got %q, %v", test.want, test.ok, got, ok)
+		}
+	}
+}
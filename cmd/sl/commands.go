@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/frozzare/go-sl"
+)
+
+// searchCommand searches for locations.
+func searchCommand(e *env, args []string) (*result, error) {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	args, err := parseArgs(e, fs, args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := e.client.Location.Search(e.ctx, &sl.LocationSearchOptions{
+		Key:          e.config.key(e.config.LocationKey),
+		SearchString: args[0],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{Value: locations, Header: []string{"SITE", "NAME", "TYPE"}}
+	for _, l := range locations {
+		res.Rows = append(res.Rows, []string{l.SiteID, l.Name, l.Type})
+	}
+
+	return res, nil
+}
+
+// departuresCommand shows realtime departures from a site.
+func departuresCommand(e *env, args []string) (*result, error) {
+	fs := flag.NewFlagSet("departures", flag.ContinueOnError)
+	window := fs.Int("window", 0, "time window in minutes, max 60")
+	args, err := parseArgs(e, fs, args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	realtime, err := e.client.Realtime.Search(e.ctx, &sl.RealtimeSearchOptions{
+		Key:        e.config.key(e.config.RealtimeKey),
+		SiteID:     args[0],
+		TimeWindow: *window,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{Value: realtime, Header: []string{"MODE", "LINE", "DESTINATION", "STOP", "PLATFORM", "TIME", "EXPECTED"}}
+	for _, t := range realtime.Departures() {
+		res.Rows = append(res.Rows, []string{
+			t.TransportMode,
+			t.LineNumber,
+			t.Destination,
+			t.StopAreaName,
+			t.StopPointDesignation,
+			t.DisplayTime,
+			t.ExpectedDateTime,
+		})
+	}
+
+	return res, nil
+}
+
+// tripCommand plans a trip between two sites.
+func tripCommand(e *env, args []string) (*result, error) {
+	fs := flag.NewFlagSet("trip", flag.ContinueOnError)
+	date := fs.String("date", "", "trip date, default today")
+	time := fs.String("time", "", "trip time, default now")
+	arrival := fs.Bool("arrival", false, "search for arrival time instead of departure time")
+	args, err := parseArgs(e, fs, args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	origin, err := resolveSite(e, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err := resolveSite(e, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	opt := &sl.TripOptions{
+		Key:      e.config.key(e.config.TravelPlannerKey),
+		OriginID: origin,
+		DestID:   dest,
+		Date:     *date,
+		Time:     *time,
+	}
+
+	if *arrival {
+		opt.SearchForArrival = 1
+	}
+
+	trips, err := e.client.TravelPlanner.Trip(e.ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{Value: trips, Header: legHeader}
+	for _, trip := range trips {
+		res.Rows = append(res.Rows, legRows(trip)...)
+	}
+
+	return res, nil
+}
+
+// journeyCommand shows the stops of a journey.
+func journeyCommand(e *env, args []string) (*result, error) {
+	fs := flag.NewFlagSet("journey", flag.ContinueOnError)
+	date := fs.String("date", "", "journey date, default today")
+	args, err := parseArgs(e, fs, args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	journey, err := e.client.TravelPlanner.Journey(e.ctx, &sl.JourneyOptions{
+		Key:  e.config.key(e.config.TravelPlannerKey),
+		ID:   args[0],
+		Date: *date,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{Value: journey, Header: []string{"IDX", "STOP", "ARRIVAL", "DEPARTURE", "TRACK"}}
+	for _, stop := range journey.Stops.Stop {
+		track := stop.DepTrack
+		if len(track) == 0 {
+			track = stop.ArrTrack
+		}
+
+		res.Rows = append(res.Rows, []string{
+			strconv.Itoa(stop.RouteIdx),
+			stop.Name,
+			stop.ArrTime,
+			stop.DepTime,
+			track,
+		})
+	}
+
+	return res, nil
+}
+
+// reconstructCommand reconstructs a trip from its ctxRecon value.
+func reconstructCommand(e *env, args []string) (*result, error) {
+	fs := flag.NewFlagSet("reconstruct", flag.ContinueOnError)
+	args, err := parseArgs(e, fs, args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	trip, err := e.client.TravelPlanner.Reconstruction(e.ctx, &sl.ReconstructionOptions{
+		Key: e.config.key(e.config.TravelPlannerKey),
+		Ctx: args[0],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result{Value: trip, Header: legHeader, Rows: legRows(trip)}, nil
+}
+
+// legHeader is the table header used for trip legs.
+var legHeader = []string{"TRIP", "LINE", "FROM", "DEPARTURE", "TO", "ARRIVAL", "DIRECTION"}
+
+// legRows returns one row per leg in the trip.
+func legRows(trip *sl.Trip) [][]string {
+	var rows [][]string
+
+	for _, leg := range trip.LegList.Leg {
+		name := leg.DisplayName()
+		if len(name) == 0 {
+			name = strings.ToLower(leg.Type)
+		}
+
+		rows = append(rows, []string{
+			strconv.Itoa(trip.Idx),
+			name,
+			leg.Origin.Name,
+			leg.Origin.Time,
+			leg.Destination.Name,
+			leg.Destination.Time,
+			leg.Direction,
+		})
+	}
+
+	return rows
+}
+
+// resolveSite returns s if it is a site id, otherwise the site id of the
+// first location found when searching for s.
+func resolveSite(e *env, s string) (string, error) {
+	if _, err := strconv.Atoi(s); err == nil {
+		return s, nil
+	}
+
+	locations, err := e.client.Location.Search(e.ctx, &sl.LocationSearchOptions{
+		Key:          e.config.key(e.config.LocationKey),
+		SearchString: s,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(locations) == 0 {
+		return "", errors.New("no location found for " + strconv.Quote(s))
+	}
+
+	fmt.Fprintf(e.stderr, "%s: %s (%s)\n", s, locations[0].Name, locations[0].SiteID)
+
+	return locations[0].SiteID, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Config represents the sl command configuration.
+//
+// Trafiklab issues one key per API, so a key can be given for each API. Key
+// is used for every API that has no key of its own.
+type Config struct {
+	BaseURL          string `json:"base_url"`
+	Key              string `json:"key"`
+	LocationKey      string `json:"location_key"`
+	RealtimeKey      string `json:"realtime_key"`
+	TravelPlannerKey string `json:"travelplanner_key"`
+}
+
+// defaultConfigPath returns the default config file path.
+func defaultConfigPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); len(dir) > 0 {
+		return filepath.Join(dir, "sl", "config.json")
+	}
+
+	return filepath.Join(os.Getenv("HOME"), ".config", "sl", "config.json")
+}
+
+// loadConfig reads the config file at path if it exists and then applies
+// environment variables on top of it.
+func loadConfig(path string) (*Config, error) {
+	c := &Config{}
+
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		defer f.Close()
+
+		if err := json.NewDecoder(f).Decode(c); err != nil {
+			return nil, err
+		}
+	}
+
+	for env, v := range map[string]*string{
+		"SL_BASE_URL":          &c.BaseURL,
+		"SL_KEY":               &c.Key,
+		"SL_LOCATION_KEY":      &c.LocationKey,
+		"SL_REALTIME_KEY":      &c.RealtimeKey,
+		"SL_TRAVELPLANNER_KEY": &c.TravelPlannerKey,
+	} {
+		if s := os.Getenv(env); len(s) > 0 {
+			*v = s
+		}
+	}
+
+	return c, nil
+}
+
+// key returns the api specific key if set, otherwise the common key.
+func (c *Config) key(specific string) string {
+	if len(specific) > 0 {
+		return specific
+	}
+
+	return c.Key
+}
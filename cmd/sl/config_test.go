@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"key":"common","realtime_key":"realtime","base_url":"http://localhost/"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("SL_LOCATION_KEY", "location")
+	defer os.Unsetenv("SL_LOCATION_KEY")
+
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if got := c.key(c.RealtimeKey); got != "realtime" {
+		t.Errorf("Expected 'realtime' got %s", got)
+	}
+
+	if got := c.key(c.LocationKey); got != "location" {
+		t.Errorf("Expected 'location' got %s", got)
+	}
+
+	if got := c.key(c.TravelPlannerKey); got != "common" {
+		t.Errorf("Expected 'common' got %s", got)
+	}
+
+	if c.BaseURL != "http://localhost/" {
+		t.Errorf("Expected 'http://localhost/' got %s", c.BaseURL)
+	}
+
+	if _, err := loadConfig(filepath.Join(dir, "missing.json")); err != nil {
+		t.Errorf("Expected nil got error: %v", err)
+	}
+}
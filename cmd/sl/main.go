@@ -0,0 +1,193 @@
+// Command sl queries the SL APIs from a terminal.
+//
+// Usage:
+//
+//	sl [flags] <command> [command flags] [arguments]
+//
+// The commands are:
+//
+//	search <text>          search for locations
+//	departures <site>      show realtime departures from a site
+//	trip <from> <to>       plan a trip, from and to can be site ids or names
+//	journey <ref>          show the stops of a journey
+//	reconstruct <ctx>      reconstruct a trip from its ctxRecon value
+//	board <site>           show a full screen departure board
+//
+// Keys are read from the config file (default ~/.config/sl/config.json) and
+// the SL_KEY, SL_LOCATION_KEY, SL_REALTIME_KEY and SL_TRAVELPLANNER_KEY
+// environment variables.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/frozzare/go-sl"
+)
+
+// env holds what a command needs to run.
+type env struct {
+	ctx    context.Context
+	client *sl.Client
+	config *Config
+	stdout io.Writer
+	stderr io.Writer
+	usage  string
+}
+
+// command represents a sl sub command.
+type command struct {
+	usage string
+	run   func(e *env, args []string) (*result, error)
+}
+
+// commands contains all sub commands by name.
+var commands = map[string]*command{
+	"search":      {usage: "search <text>", run: searchCommand},
+	"departures":  {usage: "departures [-window minutes] <site>", run: departuresCommand},
+	"trip":        {usage: "trip [-date YYYY-MM-DD] [-time HH:MM] [-arrival] <from> <to>", run: tripCommand},
+	"journey":     {usage: "journey [-date YYYY-MM-DD] <ref>", run: journeyCommand},
+	"reconstruct": {usage: "reconstruct <ctx>", run: reconstructCommand},
+	"board":       {usage: "board [-refresh 30s] [-window minutes] <site>", run: boardCommand},
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		cancel()
+	}()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	cancel()
+
+	if err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "sl:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run parses the global flags and runs the sub command.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("sl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	configPath := fs.String("config", defaultConfigPath(), "path to config file")
+	baseURL := fs.String("base-url", "", "override the SL API base url")
+	format := fs.String("format", formatTable, "output format: table, json or csv")
+
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: sl [flags] <command> [command flags] [arguments]")
+		fmt.Fprintln(stderr, "\ncommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(stderr, "  "+commands[name].usage)
+		}
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch *format {
+	case formatTable, formatJSON, formatCSV:
+	default:
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if len(*baseURL) > 0 {
+		config.BaseURL = *baseURL
+	}
+
+	client, err := newClient(config)
+	if err != nil {
+		return err
+	}
+
+	e := &env{ctx: ctx, client: client, config: config, stdout: stdout, stderr: stderr, usage: cmd.usage}
+	res, err := cmd.run(e, fs.Args()[1:])
+	if err != nil || res == nil {
+		return err
+	}
+
+	return write(stdout, *format, res)
+}
+
+// newClient creates a SL client using the config base url if any.
+func newClient(c *Config) (*sl.Client, error) {
+	client := sl.NewClient(nil)
+	client.UserAgent = "go-sl-cli"
+
+	if len(c.BaseURL) == 0 {
+		return client, nil
+	}
+
+	baseURL := c.BaseURL
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	client.BaseURL = u
+	return client, nil
+}
+
+// parseArgs parses the command flags and checks the number of arguments.
+func parseArgs(e *env, fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: sl "+e.usage)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() != n {
+		fs.Usage()
+		return nil, errors.New("wrong number of arguments")
+	}
+
+	return fs.Args(), nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func setupServer(t *testing.T) (*http.ServeMux, string, func()) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	return mux, server.URL + "/api2/", server.Close
+}
+
+func testRun(t *testing.T, baseURL string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	args = append([]string{"-config", "testdata/missing.json", "-base-url", baseURL}, args...)
+	err := run(context.Background(), args, &stdout, &stderr)
+
+	return stdout.String(), err
+}
+
+func TestRunDepartures(t *testing.T) {
+	mux, baseURL, teardown := setupServer(t)
+	defer teardown()
+
+	os.Setenv("SL_KEY", "XXXX")
+	defer os.Unsetenv("SL_KEY")
+
+	mux.HandleFunc("/api2/realtimedeparturesV4.json", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("key"); got != "XXXX" {
+			t.Errorf("Expected 'XXXX' got %s", got)
+		}
+
+		if got := r.URL.Query().Get("timeWindow"); got != "30" {
+			t.Errorf("Expected '30' got %s", got)
+		}
+
+		fmt.Fprint(w, `{"StatusCode":0,"Message":null,"ExecutionTime":621,"ResponseData":{"Metros":[{"GroupOfLine":"tunnelbanans blå linje","DisplayTime":"Nu","TransportMode":"METRO","LineNumber":"11","Destination":"Akalla","StopAreaName":"T-Centralen","StopPointDesignation":"5","ExpectedDateTime":"2017-12-18T20:11:03"}]}}`)
+	})
+
+	out, err := testRun(t, baseURL, "-format", "csv", "departures", "-window", "30", "1002")
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	want := "MODE,LINE,DESTINATION,STOP,PLATFORM,TIME,EXPECTED\nMETRO,11,Akalla,T-Centralen,5,Nu,2017-12-18T20:11:03\n"
+	if out != want {
+		t.Errorf("Expected %q got %q", want, out)
+	}
+}
+
+func TestRunTrip(t *testing.T) {
+	mux, baseURL, teardown := setupServer(t)
+	defer teardown()
+
+	os.Setenv("SL_KEY", "XXXX")
+	defer os.Unsetenv("SL_KEY")
+
+	mux.HandleFunc("/api2/typeahead.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"StatusCode":0,"Message":null,"ResponseData":[{"Name":"Slussen (Stockholm)","SiteId":"9192","Type":"Station","X":"18071860","Y":"59320284"}]}`)
+	})
+
+	mux.HandleFunc("/api2/TravelplannerV3/trip.json", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("originId"); got != "9192" {
+			t.Errorf("Expected '9192' got %s", got)
+		}
+
+		if got := r.URL.Query().Get("destId"); got != "1002" {
+			t.Errorf("Expected '1002' got %s", got)
+		}
+
+		fmt.Fprint(w, `{"Trip":[{"LegList":{"Leg":[{"Origin":{"name":"Slussen","time":"22:58:00"},"Destination":{"name":"T-Centralen","time":"23:02:00"},"name":"TUNNELBANA  13","direction":"Ropsten"}]},"idx":0}]}`)
+	})
+
+	out, err := testRun(t, baseURL, "-format", "json", "trip", "Slussen", "1002")
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if !strings.Contains(out, `"name": "TUNNELBANA  13"`) {
+		t.Errorf("Expected json output with the leg name got %s", out)
+	}
+
+	out, err = testRun(t, baseURL, "trip", "9192", "1002")
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if !strings.Contains(out, "TUNNELBANA 13") {
+		t.Errorf("Expected table output with the leg name got %s", out)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	if _, err := testRun(t, "http://localhost/", "unknown"); err == nil {
+		t.Errorf("Expected error got nil")
+	}
+
+	if _, err := testRun(t, "http://localhost/", "departures"); err == nil {
+		t.Errorf("Expected error got nil")
+	}
+}
+
+func TestRunFormat(t *testing.T) {
+	mux, baseURL, teardown := setupServer(t)
+	defer teardown()
+
+	os.Setenv("SL_KEY", "XXXX")
+	defer os.Unsetenv("SL_KEY")
+
+	mux.HandleFunc("/api2/realtimedeparturesV4.json", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected no request for an unknown format")
+	})
+
+	if _, err := testRun(t, baseURL, "-format", "xml", "departures", "1002"); err == nil || !strings.Contains(err.Error(), "xml") {
+		t.Errorf("Expected unknown format error got %v", err)
+	}
+}
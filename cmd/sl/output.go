@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// result represents the result of a command. Value is written as is for
+// json output while Header and Rows are used for table and csv output.
+type result struct {
+	Value  interface{}
+	Header []string
+	Rows   [][]string
+}
+
+// write writes the result to w in the given format.
+func write(w io.Writer, format string, r *result) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.Value)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(r.Header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(r.Rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(r.Header, "\t"))
+		for _, row := range r.Rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
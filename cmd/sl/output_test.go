@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r := &result{
+		Value:  map[string]string{"name": "Slussen"},
+		Header: []string{"SITE", "NAME"},
+		Rows:   [][]string{{"9192", "Slussen"}},
+	}
+
+	tests := map[string]string{
+		formatTable: "SITE  NAME\n9192  Slussen\n",
+		formatCSV:   "SITE,NAME\n9192,Slussen\n",
+		formatJSON:  "{\n  \"name\": \"Slussen\"\n}\n",
+	}
+
+	for format, want := range tests {
+		var buf bytes.Buffer
+		if err := write(&buf, format, r); err != nil {
+			t.Fatalf("Expected nil got error: %v", err)
+		}
+
+		if got := buf.String(); got != want {
+			t.Errorf("Expected %q got %q", want, got)
+		}
+	}
+
+	if err := write(&bytes.Buffer{}, "xml", r); err == nil {
+		t.Errorf("Expected error got nil")
+	}
+}
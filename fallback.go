@@ -0,0 +1,312 @@
+package sl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCircuitOpen = errors.New("SL API circuit breaker is open")
+)
+
+// FallbackOptions specifies optional parameters to StaleFallback.
+type FallbackOptions struct {
+	// Services the fallback is used for. Default is location and realtime.
+	Services []string
+
+	// Max age of a response that can be served as stale. Default is 15 minutes.
+	MaxAge time.Duration
+
+	// Interval between background retries after a failed call. It is also
+	// the timeout of each retry. Default is 10 seconds.
+	RetryInterval time.Duration
+
+	// Number of consecutive failures before the circuit breaker opens. Default is 5.
+	FailureThreshold int
+
+	// Time the circuit breaker stays open before a request is let through again. Default is 30 seconds.
+	Cooldown time.Duration
+}
+
+// fallbackEntry represents the last good response of a call.
+type fallbackEntry struct {
+	result   interface{}
+	response *http.Response
+	duration time.Duration
+	time     time.Time
+}
+
+// fallback holds the state of the stale fallback middleware.
+type fallback struct {
+	opt      FallbackOptions
+	services map[string]bool
+	now      func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*fallbackEntry
+	retrying  map[string]bool
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// StaleFallback returns a middleware that serves the last good response
+// when a call fails, while the call is retried in the background. Served
+// responses are marked with Call.Stale and Response.Stale and carry their age.
+//
+// A circuit breaker stops sending requests after FailureThreshold consecutive
+// failures until Cooldown has passed. Only transport errors and 5xx responses
+// count as failures, API messages and canceled calls do not. After Cooldown a
+// single request is let through and the breaker opens again if it fails.
+// While it is open, stale responses are served or ErrCircuitOpen is returned.
+//
+// Stale responses share data with the responses returned earlier, so
+// returned data should not be modified.
+func StaleFallback(opt *FallbackOptions) Middleware {
+	f := newFallback(opt)
+	return f.middleware
+}
+
+// newFallback returns a new fallback with defaults applied to the options.
+func newFallback(opt *FallbackOptions) *fallback {
+	f := &fallback{
+		now:      time.Now,
+		services: map[string]bool{},
+		entries:  map[string]*fallbackEntry{},
+		retrying: map[string]bool{},
+	}
+
+	if opt != nil {
+		f.opt = *opt
+	}
+
+	if len(f.opt.Services) == 0 {
+		f.opt.Services = []string{"location", "realtime"}
+	}
+
+	if f.opt.MaxAge <= 0 {
+		f.opt.MaxAge = 15 * time.Minute
+	}
+
+	if f.opt.RetryInterval <= 0 {
+		f.opt.RetryInterval = 10 * time.Second
+	}
+
+	if f.opt.FailureThreshold <= 0 {
+		f.opt.FailureThreshold = 5
+	}
+
+	if f.opt.Cooldown <= 0 {
+		f.opt.Cooldown = 30 * time.Second
+	}
+
+	for _, s := range f.opt.Services {
+		f.services[s] = true
+	}
+
+	return f
+}
+
+// middleware implements Middleware.
+func (f *fallback) middleware(next Handler) Handler {
+	return func(ctx context.Context, call *Call) error {
+		if !f.services[call.Service] {
+			return next(ctx, call)
+		}
+
+		key, err := addOptions(call.Endpoint, call.Options)
+		if err != nil {
+			return next(ctx, call)
+		}
+
+		ok, probe := f.allow()
+		if !ok {
+			if f.serveStale(key, call) {
+				return nil
+			}
+			return ErrCircuitOpen
+		}
+
+		err = next(ctx, call)
+		f.record(key, call, err, breakerFailure(ctx, call, err), probe)
+		if err == nil {
+			return nil
+		}
+
+		if f.serveStale(key, call) {
+			f.retry(key, next, call)
+			return nil
+		}
+
+		return err
+	}
+}
+
+// allow reports whether the circuit breaker lets a request through and
+// whether the request is the single probe let through when it is half-open.
+func (f *fallback) allow() (ok, probe bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failures < f.opt.FailureThreshold {
+		return true, false
+	}
+
+	if f.probing || f.now().Before(f.openUntil) {
+		return false, false
+	}
+
+	f.probing = true
+	return true, true
+}
+
+// breakerFailure reports whether err means the API could not be reached and
+// should count as a circuit breaker failure. API messages, missing keys and
+// errors caused by ctx being done are not counted.
+func breakerFailure(ctx context.Context, call *Call, err error) bool {
+	if err == nil || errors.Is(err, ErrNoKey) || ctx.Err() != nil {
+		return false
+	}
+
+	if call.Response != nil {
+		return call.Response.StatusCode >= http.StatusInternalServerError
+	}
+
+	var uerr *url.Error
+	return errors.As(err, &uerr)
+}
+
+// record updates the circuit breaker and stores the result of a successful
+// call. Errors that are not failures leave the circuit breaker as it is.
+func (f *fallback) record(key string, call *Call, err error, failure, probe bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if probe {
+		f.probing = false
+	}
+
+	if err != nil {
+		if failure {
+			f.failures++
+			if f.failures >= f.opt.FailureThreshold {
+				f.openUntil = f.now().Add(f.opt.Cooldown)
+			}
+		}
+		return
+	}
+
+	f.failures = 0
+	f.openUntil = time.Time{}
+	f.entries[key] = &fallbackEntry{
+		result:   call.Result,
+		response: call.Response,
+		duration: call.Duration,
+		time:     f.now(),
+	}
+}
+
+// serveStale copies the last good result into the call. False is returned
+// if there is no result or it is too old.
+func (f *fallback) serveStale(key string, call *Call) bool {
+	f.mu.Lock()
+	e, ok := f.entries[key]
+	now := f.now()
+	f.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	age := now.Sub(e.time)
+	if age > f.opt.MaxAge {
+		return false
+	}
+
+	dst := reflect.ValueOf(call.Result)
+	src := reflect.ValueOf(e.result)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() || dst.Type() != src.Type() {
+		return false
+	}
+
+	dst.Elem().Set(src.Elem())
+	call.Response = e.response
+	call.Duration = e.duration
+	call.Stale = true
+	call.Age = age
+
+	return true
+}
+
+// retry retries the call in the background until it succeeds or the last
+// good result has become too old to be served.
+func (f *fallback) retry(key string, next Handler, call *Call) {
+	f.mu.Lock()
+	if f.retrying[key] {
+		f.mu.Unlock()
+		return
+	}
+	f.retrying[key] = true
+	f.mu.Unlock()
+
+	// Copy the options since the caller may reuse them.
+	options := call.Options
+	if v := reflect.ValueOf(options); v.Kind() == reflect.Ptr && !v.IsNil() {
+		c := reflect.New(v.Elem().Type())
+		c.Elem().Set(v.Elem())
+		options = c.Interface()
+	}
+
+	resultType := reflect.TypeOf(call.Result).Elem()
+
+	go func() {
+		defer func() {
+			f.mu.Lock()
+			delete(f.retrying, key)
+			f.mu.Unlock()
+		}()
+
+		for {
+			time.Sleep(f.opt.RetryInterval)
+
+			f.mu.Lock()
+			e := f.entries[key]
+			now := f.now()
+			f.mu.Unlock()
+
+			if e == nil || now.Sub(e.time) > f.opt.MaxAge {
+				return
+			}
+
+			ok, probe := f.allow()
+			if !ok {
+				continue
+			}
+
+			c := &Call{
+				Service:  call.Service,
+				Method:   call.Method,
+				Endpoint: call.Endpoint,
+				Options:  options,
+				Header:   call.Header,
+				Result:   reflect.New(resultType).Interface(),
+			}
+
+			// A retry timing out is a failure since the timeout is ours.
+			ctx, cancel := context.WithTimeout(context.Background(), f.opt.RetryInterval)
+			err := next(ctx, c)
+			failure := breakerFailure(ctx, c, err) || (err != nil && ctx.Err() == context.DeadlineExceeded)
+			cancel()
+
+			f.record(key, c, err, failure, probe)
+			if err == nil {
+				return
+			}
+		}
+	}()
+}
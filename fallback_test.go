@@ -0,0 +1,341 @@
+package sl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStaleFallback(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+
+	var (
+		mu       sync.Mutex
+		down     bool
+		requests int
+		line     = "11"
+	)
+
+	mux.HandleFunc("/realtimedeparturesV4.json", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		if down {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprintf(w, `{"StatusCode":0,"Message":null,"ExecutionTime":621,"ResponseData":{"Metros":[{"LineNumber":"%s"}]}}`, line)
+	})
+
+	client.Use(StaleFallback(&FallbackOptions{
+		RetryInterval:    10 * time.Millisecond,
+		FailureThreshold: 2,
+		Cooldown:         time.Hour,
+	}))
+
+	search := func() (*RealtimeResponse, *Response, error) {
+		return client.Realtime.SearchWithResponse(context.Background(), &RealtimeSearchOptions{
+			Key:    "XXXX",
+			SiteID: "1002",
+		})
+	}
+
+	if _, resp, err := search(); err != nil || resp.Stale {
+		t.Fatalf("Expected fresh response got %v", err)
+	}
+
+	mu.Lock()
+	down = true
+	mu.Unlock()
+
+	realtime, resp, err := search()
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if !resp.Stale || resp.Age <= 0 {
+		t.Errorf("Expected stale response with age got %v and %s", resp.Stale, resp.Age)
+	}
+
+	if realtime.Metros[0].LineNumber != "11" {
+		t.Errorf("Expected '11' got %s", realtime.Metros[0].LineNumber)
+	}
+
+	// The background retry is the second failure and opens the circuit breaker.
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	before := requests
+	mu.Unlock()
+
+	if _, resp, err := search(); err != nil || !resp.Stale {
+		t.Errorf("Expected stale response got %v", err)
+	}
+
+	mu.Lock()
+	after := requests
+	mu.Unlock()
+
+	if after != before {
+		t.Errorf("Expected no requests while the circuit breaker is open got %d", after-before)
+	}
+
+	if _, err := client.Realtime.Search(context.Background(), &RealtimeSearchOptions{
+		Key:    "XXXX",
+		SiteID: "9192",
+	}); err != ErrCircuitOpen {
+		t.Errorf("Expected %v got %v", ErrCircuitOpen, err)
+	}
+}
+
+func TestStaleFallbackRetry(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+
+	var (
+		mu   sync.Mutex
+		down bool
+		line = "11"
+	)
+
+	mux.HandleFunc("/typeahead.json", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if down {
+			http.Error(w, "Bad Gateway", http.StatusBadGateway)
+			return
+		}
+
+		fmt.Fprintf(w, `{"StatusCode":0,"Message":null,"ResponseData":[{"Name":"Slussen","SiteId":"%s"}]}`, line)
+	})
+
+	f := newFallback(&FallbackOptions{RetryInterval: 10 * time.Millisecond})
+	client.Use(f.middleware)
+
+	search := func() ([]*Location, *Response, error) {
+		return client.Location.SearchWithResponse(context.Background(), &LocationSearchOptions{
+			Key:          "XXXX",
+			SearchString: "Slussen",
+		})
+	}
+
+	if _, _, err := search(); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	mu.Lock()
+	down = true
+	mu.Unlock()
+
+	if _, resp, err := search(); err != nil || !resp.Stale {
+		t.Fatalf("Expected stale response got %v", err)
+	}
+
+	mu.Lock()
+	down = false
+	line = "9192"
+	mu.Unlock()
+
+	// Wait for the background retry to refresh the response.
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	down = true
+	mu.Unlock()
+
+	locations, resp, err := search()
+	if err != nil || !resp.Stale {
+		t.Fatalf("Expected stale response got %v", err)
+	}
+
+	if locations[0].SiteID != "9192" {
+		t.Errorf("Expected '9192' got %s", locations[0].SiteID)
+	}
+
+	f.mu.Lock()
+	f.now = func() time.Time { return time.Now().Add(time.Hour) }
+	f.mu.Unlock()
+
+	if _, _, err := search(); err == nil {
+		t.Errorf("Expected error for too old response got nil")
+	}
+}
+
+func TestStaleFallbackBreakerFailures(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+
+	mux.HandleFunc("/realtimedeparturesV4.json", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+
+		fmt.Fprint(w, `{"StatusCode":1002,"Message":"Key is invalid"}`)
+	})
+
+	f := newFallback(&FallbackOptions{FailureThreshold: 1, Cooldown: time.Hour})
+	client.Use(f.middleware)
+
+	opt := &RealtimeSearchOptions{Key: "XXXX", SiteID: "1002"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Realtime.Search(context.Background(), opt); err == nil || err == ErrCircuitOpen {
+			t.Fatalf("Expected API error got %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.Realtime.Search(ctx, opt); err != context.Canceled {
+		t.Fatalf("Expected %v got %v", context.Canceled, err)
+	}
+
+	if _, err := client.Realtime.Search(context.Background(), opt); err == ErrCircuitOpen {
+		t.Errorf("Expected API errors and canceled calls to not open the circuit breaker")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if requests != 4 {
+		t.Errorf("Expected 4 requests got %d", requests)
+	}
+}
+
+func TestStaleFallbackHalfOpen(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+
+	var (
+		mu       sync.Mutex
+		down     = true
+		requests int
+		block    chan struct{}
+	)
+
+	mux.HandleFunc("/realtimedeparturesV4.json", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		wait := block
+		mu.Unlock()
+
+		if wait != nil {
+			<-wait
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if down {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprint(w, `{"StatusCode":0,"Message":null,"ResponseData":{"Metros":[{"LineNumber":"11"}]}}`)
+	})
+
+	f := newFallback(&FallbackOptions{FailureThreshold: 2, Cooldown: time.Minute})
+	client.Use(f.middleware)
+
+	now := time.Now()
+	advance := func(d time.Duration) {
+		f.mu.Lock()
+		now = now.Add(d)
+		f.mu.Unlock()
+	}
+
+	f.mu.Lock()
+	f.now = func() time.Time { return now }
+	f.mu.Unlock()
+
+	search := func() error {
+		_, err := client.Realtime.Search(context.Background(), &RealtimeSearchOptions{
+			Key:    "XXXX",
+			SiteID: "1002",
+		})
+		return err
+	}
+
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := search(); err == nil || err == ErrCircuitOpen {
+			t.Fatalf("Expected http error got %v", err)
+		}
+	}
+
+	if err := search(); err != ErrCircuitOpen {
+		t.Fatalf("Expected %v got %v", ErrCircuitOpen, err)
+	}
+
+	// The probe fails and the circuit breaker opens again.
+	advance(time.Minute)
+
+	if err := search(); err == nil || err == ErrCircuitOpen {
+		t.Fatalf("Expected http error got %v", err)
+	}
+
+	if err := search(); err != ErrCircuitOpen {
+		t.Fatalf("Expected %v got %v", ErrCircuitOpen, err)
+	}
+
+	if got := count(); got != 3 {
+		t.Errorf("Expected 3 requests got %d", got)
+	}
+
+	// Only a single probe is let through while it is in flight.
+	advance(time.Minute)
+
+	mu.Lock()
+	down = false
+	block = make(chan struct{})
+	mu.Unlock()
+
+	done := make(chan error)
+	go func() {
+		done <- search()
+	}()
+
+	for count() != 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := search(); err != ErrCircuitOpen {
+		t.Errorf("Expected %v got %v", ErrCircuitOpen, err)
+	}
+
+	mu.Lock()
+	close(block)
+	block = nil
+	mu.Unlock()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	// The probe succeeded and closed the circuit breaker.
+	if err := search(); err != nil {
+		t.Errorf("Expected nil got error: %v", err)
+	}
+
+	if got := count(); got != 5 {
+		t.Errorf("Expected 5 requests got %d", got)
+	}
+}
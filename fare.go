@@ -0,0 +1,158 @@
+package sl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Money represents an amount of money in the minor unit of the currency,
+// e.g. öre for SEK.
+type Money struct {
+	Amount   int
+	Currency string
+}
+
+// String returns the money formatted with two decimals and the currency, e.g. "30.00 SEK".
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, m.Currency))
+}
+
+// FareChannel represents the channel where a ticket is bought.
+type FareChannel int
+
+const (
+	// FareChannelUnknown is used when the channel name is not known.
+	FareChannelUnknown FareChannel = iota
+
+	// FareChannelTravelFunds is travel funds (reskassa) on an SL Access card.
+	FareChannelTravelFunds
+
+	// FareChannelOtherVendors is other points of sale (övriga försäljningsställen).
+	FareChannelOtherVendors
+
+	// FareChannelConductor is a conductor on Djurgårdsbanan or Roslagsbanan.
+	FareChannelConductor
+)
+
+// fareChannelNames maps the SL API fare item names to fare channels.
+var fareChannelNames = map[string]FareChannel{
+	"reskassa":                   FareChannelTravelFunds,
+	"övriga försäljningsställen": FareChannelOtherVendors,
+	"konduktör på djurgårds- och roslagsbanan": FareChannelConductor,
+}
+
+// String returns the English name of the fare channel.
+func (c FareChannel) String() string {
+	switch c {
+	case FareChannelTravelFunds:
+		return "travel funds"
+	case FareChannelOtherVendors:
+		return "other vendors"
+	case FareChannelConductor:
+		return "conductor"
+	default:
+		return "unknown"
+	}
+}
+
+// PriceClass represents the price class of a fare.
+type PriceClass int
+
+const (
+	// PriceClassUnknown is used when the price class description is not known.
+	PriceClassUnknown PriceClass = iota
+
+	// PriceClassFull is full price (helt pris).
+	PriceClassFull
+
+	// PriceClassReduced is reduced price (reducerat pris).
+	PriceClassReduced
+)
+
+// priceClassNames maps the SL API fare item descriptions to price classes.
+var priceClassNames = map[string]PriceClass{
+	"helt pris":      PriceClassFull,
+	"reducerat pris": PriceClassReduced,
+}
+
+// String returns the English name of the price class.
+func (c PriceClass) String() string {
+	switch c {
+	case PriceClassFull:
+		return "full"
+	case PriceClassReduced:
+		return "reduced"
+	default:
+		return "unknown"
+	}
+}
+
+// Fare represents a typed fare item from a trip tariff result.
+type Fare struct {
+	// Fare set name, e.g. ONEWAY.
+	Set string
+
+	// Channel where the ticket is bought.
+	Channel FareChannel
+
+	// Price class of the ticket.
+	Class PriceClass
+
+	// Price of the ticket.
+	Price Money
+
+	// Name and Desc are the raw values from the SL API.
+	Name string
+	Desc string
+}
+
+// Fares returns all fares in the trip tariff result.
+func (t *Trip) Fares() []*Fare {
+	var fares []*Fare
+
+	for _, set := range t.TariffResult.FareSetItem {
+		for _, item := range set.FareItem {
+			fares = append(fares, &Fare{
+				Set:     set.Name,
+				Channel: fareChannelNames[strings.ToLower(strings.TrimSpace(item.Name))],
+				Class:   priceClassNames[strings.ToLower(strings.TrimSpace(item.Desc))],
+				Price:   Money{Amount: item.Price, Currency: item.Cur},
+				Name:    item.Name,
+				Desc:    item.Desc,
+			})
+		}
+	}
+
+	return fares
+}
+
+// CheapestFare returns the cheapest fare with the given price class or nil
+// if the trip has no such fare. Prices in different currencies can't be
+// compared, so only fares in the currency of the first fare with the price
+// class are considered.
+func (t *Trip) CheapestFare(class PriceClass) *Fare {
+	var cheapest *Fare
+
+	for _, fare := range t.Fares() {
+		if fare.Class != class {
+			continue
+		}
+
+		if cheapest != nil && fare.Price.Currency != cheapest.Price.Currency {
+			continue
+		}
+
+		if cheapest == nil || fare.Price.Amount < cheapest.Price.Amount {
+			cheapest = fare
+		}
+	}
+
+	return cheapest
+}
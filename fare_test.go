@@ -0,0 +1,73 @@
+package sl
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testTariffResult = `{"TariffResult":{"fareSetItem":[{"fareItem":[{"name":"Reskassa","desc":"Helt pris","price":3000,"cur":"SEK"},{"name":"Övriga försäljningsställen","desc":"Helt pris","price":4300,"cur":"SEK"},{"name":"Konduktör på Djurgårds- och Roslagsbanan","desc":"Helt pris","price":6000,"cur":"SEK"},{"name":"Reskassa","desc":"Reducerat pris","price":2000,"cur":"SEK"},{"name":"Övriga försäljningsställen","desc":"Reducerat pris","price":2900,"cur":"SEK"},{"name":"Konduktör på Djurgårds- och Roslagsbanan","desc":"Reducerat pris","price":4000,"cur":"SEK"}],"name":"ONEWAY","desc":"SL"}]}}`
+
+func TestTripFares(t *testing.T) {
+	var trip *Trip
+	if err := json.Unmarshal([]byte(testTariffResult), &trip); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	fares := trip.Fares()
+
+	if len(fares) != 6 {
+		t.Fatalf("Expected 6 fares got %d", len(fares))
+	}
+
+	if fares[1].Channel != FareChannelOtherVendors {
+		t.Errorf("Expected '%s' got %s", FareChannelOtherVendors, fares[1].Channel)
+	}
+
+	if fares[2].Channel != FareChannelConductor {
+		t.Errorf("Expected '%s' got %s", FareChannelConductor, fares[2].Channel)
+	}
+
+	if fares[3].Class != PriceClassReduced {
+		t.Errorf("Expected '%s' got %s", PriceClassReduced, fares[3].Class)
+	}
+
+	if fares[0].Set != "ONEWAY" {
+		t.Errorf("Expected 'ONEWAY' got %s", fares[0].Set)
+	}
+}
+
+func TestTripCheapestFare(t *testing.T) {
+	var trip *Trip
+	if err := json.Unmarshal([]byte(testTariffResult), &trip); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	fare := trip.CheapestFare(PriceClassFull)
+
+	if fare.Channel != FareChannelTravelFunds {
+		t.Errorf("Expected '%s' got %s", FareChannelTravelFunds, fare.Channel)
+	}
+
+	if fare.Price.String() != "30.00 SEK" {
+		t.Errorf("Expected '30.00 SEK' got %s", fare.Price)
+	}
+
+	if fare := trip.CheapestFare(PriceClassReduced); fare.Price.Amount != 2000 {
+		t.Errorf("Expected 2000 got %d", fare.Price.Amount)
+	}
+
+	if fare := trip.CheapestFare(PriceClassUnknown); fare != nil {
+		t.Errorf("Expected nil got %v", fare)
+	}
+}
+
+func TestTripCheapestFareCurrency(t *testing.T) {
+	var trip *Trip
+	if err := json.Unmarshal([]byte(`{"TariffResult":{"fareSetItem":[{"fareItem":[{"name":"Reskassa","desc":"Helt pris","price":3000,"cur":"SEK"},{"name":"Reskassa","desc":"Helt pris","price":300,"cur":"EUR"}],"name":"ONEWAY","desc":"SL"}]}}`), &trip); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if fare := trip.CheapestFare(PriceClassFull); fare.Price.String() != "30.00 SEK" {
+		t.Errorf("Expected '30.00 SEK' got %s", fare.Price)
+	}
+}
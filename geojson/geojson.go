@@ -0,0 +1,172 @@
+// Package geojson converts SL API models into RFC 7946 GeoJSON feature collections.
+package geojson
+
+import (
+	"strings"
+
+	"github.com/frozzare/go-sl"
+)
+
+// Geometry types.
+const (
+	TypePoint      = "Point"
+	TypeLineString = "LineString"
+)
+
+// FeatureCollection represents a GeoJSON feature collection.
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// Feature represents a GeoJSON feature.
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry represents a GeoJSON point or line string geometry.
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// NewFeatureCollection returns a new empty feature collection.
+func NewFeatureCollection() *FeatureCollection {
+	return &FeatureCollection{Type: "FeatureCollection", Features: []*Feature{}}
+}
+
+// NewFeature returns a new feature with the given geometry and properties.
+func NewFeature(g *Geometry, props map[string]interface{}) *Feature {
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+
+	return &Feature{Type: "Feature", Geometry: g, Properties: props}
+}
+
+// Point returns a point geometry. GeoJSON positions are longitude first.
+func Point(p sl.LatLon) *Geometry {
+	return &Geometry{Type: TypePoint, Coordinates: position(p)}
+}
+
+// LineString returns a line string geometry.
+func LineString(points []sl.LatLon) *Geometry {
+	coords := make([][]float64, len(points))
+	for i, p := range points {
+		coords[i] = position(p)
+	}
+
+	return &Geometry{Type: TypeLineString, Coordinates: coords}
+}
+
+// position returns the GeoJSON position of a coordinate.
+func position(p sl.LatLon) []float64 {
+	return []float64{p.Lon, p.Lat}
+}
+
+// Trip converts a trip into a feature collection with one line string feature per leg.
+// The polyline geometry is used when available, otherwise a line through the leg stops
+// when the trip was searched with a passlist or else a straight line from the leg
+// origin to the leg destination.
+func Trip(t *sl.Trip) *FeatureCollection {
+	fc := NewFeatureCollection()
+
+	for _, leg := range t.LegList.Leg {
+		points := leg.Path()
+		if len(points) == 0 {
+			points = stopPoints(leg.Stops.Stop)
+		}
+		if len(points) == 0 {
+			points = []sl.LatLon{
+				{Lat: leg.Origin.Lat, Lon: leg.Origin.Lon},
+				{Lat: leg.Destination.Lat, Lon: leg.Destination.Lon},
+			}
+		}
+
+		fc.Features = append(fc.Features, NewFeature(LineString(points), map[string]interface{}{
+			"name":        leg.Name,
+			"line":        leg.Product.Line,
+			"mode":        strings.TrimSpace(leg.Product.CatOutL),
+			"category":    leg.Category,
+			"type":        leg.Type,
+			"direction":   leg.Direction,
+			"origin":      leg.Origin.Name,
+			"destination": leg.Destination.Name,
+			"departure":   dateTime(leg.Origin.Date, leg.Origin.Time),
+			"arrival":     dateTime(leg.Destination.Date, leg.Destination.Time),
+		}))
+	}
+
+	return fc
+}
+
+// stopPoints returns the coordinates of the stops or nil if there are less
+// than two stops.
+func stopPoints(stops []*sl.Stop) []sl.LatLon {
+	if len(stops) < 2 {
+		return nil
+	}
+
+	points := make([]sl.LatLon, len(stops))
+	for i, stop := range stops {
+		points[i] = sl.LatLon{Lat: stop.Lat, Lon: stop.Lon}
+	}
+
+	return points
+}
+
+// Journey converts a journey into a feature collection with one point feature per stop.
+// If the journey contains polyline geometry it is added as a line string feature first.
+func Journey(j *sl.Journey) *FeatureCollection {
+	fc := NewFeatureCollection()
+
+	if points := j.Path(); len(points) > 0 {
+		fc.Features = append(fc.Features, NewFeature(LineString(points), map[string]interface{}{
+			"ref": j.Ref,
+		}))
+	}
+
+	for _, stop := range j.Stops.Stop {
+		fc.Features = append(fc.Features, NewFeature(Point(sl.LatLon{Lat: stop.Lat, Lon: stop.Lon}), map[string]interface{}{
+			"name":      stop.Name,
+			"id":        stop.ExtID,
+			"routeIdx":  stop.RouteIdx,
+			"departure": dateTime(stop.DepDate, stop.DepTime),
+			"track":     stop.DepTrack,
+		}))
+	}
+
+	return fc
+}
+
+// Locations converts locations into a feature collection with one point feature per location.
+// Locations without a valid coordinate are skipped.
+func Locations(locations []*sl.Location) *FeatureCollection {
+	fc := NewFeatureCollection()
+
+	for _, l := range locations {
+		p, err := l.LatLon()
+		if err != nil {
+			continue
+		}
+
+		fc.Features = append(fc.Features, NewFeature(Point(p), map[string]interface{}{
+			"name":   l.Name,
+			"siteId": l.SiteID,
+			"type":   l.Type,
+		}))
+	}
+
+	return fc
+}
+
+// dateTime joins a date and a time from the travel planner api.
+func dateTime(date, time string) string {
+	if len(date) == 0 || len(time) == 0 {
+		return date + time
+	}
+
+	return date + "T" + time
+}
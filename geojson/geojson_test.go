@@ -0,0 +1,100 @@
+package geojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/frozzare/go-sl"
+)
+
+func TestTrip(t *testing.T) {
+	var trip *sl.Trip
+	if err := json.Unmarshal([]byte(`{"LegList":{"Leg":[{"Origin":{"name":"Slussen","lon":18.071491,"lat":59.319511,"time":"22:58:00","date":"2017-12-18"},"Destination":{"name":"T-Centralen","lon":18.061477,"lat":59.331358,"time":"23:02:00","date":"2017-12-18"},"Product":{"line":"13","catOutL":"TUNNELBANA "},"name":"TUNNELBANA  13"},{"Origin":{"name":"T-Centralen","lon":18.061477,"lat":59.331358},"Destination":{"name":"Hötorget","lon":18.06296,"lat":59.33561},"Polyline":{"crd":[18.061477,59.331358,0.0005,0.002,0.001,0.002252],"delta":true,"dim":2},"type":"WALK"}]}}`), &trip); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	fc := Trip(trip)
+
+	if len(fc.Features) != 2 {
+		t.Fatalf("Expected 2 features got %d", len(fc.Features))
+	}
+
+	if coords := fc.Features[0].Geometry.Coordinates.([][]float64); len(coords) != 2 || coords[0][0] != 18.071491 {
+		t.Errorf("Expected straight line from Slussen got %v", coords)
+	}
+
+	if coords := fc.Features[1].Geometry.Coordinates.([][]float64); len(coords) != 3 {
+		t.Errorf("Expected 3 coordinates got %d", len(coords))
+	}
+
+	if mode := fc.Features[0].Properties["mode"]; mode != "TUNNELBANA" {
+		t.Errorf("Expected 'TUNNELBANA' got %v", mode)
+	}
+
+	if departure := fc.Features[0].Properties["departure"]; departure != "2017-12-18T22:58:00" {
+		t.Errorf("Expected '2017-12-18T22:58:00' got %v", departure)
+	}
+
+	b, err := json.Marshal(fc)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if out["type"] != "FeatureCollection" {
+		t.Errorf("Expected 'FeatureCollection' got %v", out["type"])
+	}
+}
+
+func TestTripPasslist(t *testing.T) {
+	var trip *sl.Trip
+	if err := json.Unmarshal([]byte(`{"LegList":{"Leg":[{"Origin":{"name":"Slussen","lon":18.071491,"lat":59.319511},"Destination":{"name":"T-Centralen","lon":18.061477,"lat":59.331358},"Stops":{"Stop":[{"name":"Slussen","lon":18.071491,"lat":59.319511},{"name":"Gamla stan","lon":18.067034,"lat":59.323304},{"name":"T-Centralen","lon":18.061477,"lat":59.331358}]},"name":"TUNNELBANA  13"}]}}`), &trip); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	fc := Trip(trip)
+
+	if coords := fc.Features[0].Geometry.Coordinates.([][]float64); len(coords) != 3 || coords[1][0] != 18.067034 {
+		t.Errorf("Expected line through Gamla stan got %v", coords)
+	}
+}
+
+func TestJourney(t *testing.T) {
+	var journey *sl.Journey
+	if err := json.Unmarshal([]byte(`{"Stops":{"Stop":[{"name":"Fruängen","extId":"400102851","routeIdx":0,"lon":17.964852,"lat":59.286754,"depTime":"08:04:00","depDate":"2017-12-19"}]},"ref":"1|5258|0|74|19122017"}`), &journey); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	fc := Journey(journey)
+
+	if len(fc.Features) != 1 {
+		t.Fatalf("Expected 1 feature got %d", len(fc.Features))
+	}
+
+	if fc.Features[0].Geometry.Type != TypePoint {
+		t.Errorf("Expected '%s' got %s", TypePoint, fc.Features[0].Geometry.Type)
+	}
+
+	if name := fc.Features[0].Properties["name"]; name != "Fruängen" {
+		t.Errorf("Expected 'Fruängen' got %v", name)
+	}
+}
+
+func TestLocations(t *testing.T) {
+	fc := Locations([]*sl.Location{
+		{Name: "Södra station (Stockholm)", SiteID: "9530", Type: "Station", X: "18061405", Y: "59313389"},
+		{Name: "Invalid"},
+	})
+
+	if len(fc.Features) != 1 {
+		t.Fatalf("Expected 1 feature got %d", len(fc.Features))
+	}
+
+	if coords := fc.Features[0].Geometry.Coordinates.([]float64); coords[0] != 18.061405 || coords[1] != 59.313389 {
+		t.Errorf("Expected '[18.061405 59.313389]' got %v", coords)
+	}
+}
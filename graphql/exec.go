@@ -0,0 +1,489 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// executor executes an operation.
+type executor struct {
+	schema *Schema
+	doc    *document
+	vars   map[string]interface{}
+	batch  *batch
+	errors []*Error
+}
+
+// object is an object value whose fields are resolved in the same level.
+type object struct {
+	typ    *objectType
+	source interface{}
+	sel    []selection
+	out    *orderedMap
+	path   []interface{}
+}
+
+// fieldValue is a resolved field of an object.
+type fieldValue struct {
+	obj   *object
+	key   string
+	def   *fieldDefinition
+	sel   []selection
+	value interface{}
+	err   error
+}
+
+// execute resolves the selection set of the root type and returns the data.
+func (e *executor) execute(ctx context.Context, root *objectType, sel []selection) *orderedMap {
+	data := &orderedMap{}
+	level := []*object{{typ: root, sel: sel, out: data}}
+
+	for len(level) > 0 {
+		var fields []*fieldValue
+
+		for _, obj := range level {
+			fields = append(fields, e.resolveFields(ctx, obj)...)
+		}
+
+		// Load the keys of all thunks at once, again if a thunk returns
+		// another thunk.
+		for pending := true; pending; {
+			pending = false
+			e.batch.dispatch(ctx)
+
+			for _, f := range fields {
+				if t, ok := f.value.(Thunk); ok && f.err == nil {
+					f.value, f.err = t()
+					_, again := f.value.(Thunk)
+					pending = pending || again
+				}
+			}
+		}
+
+		level = nil
+		for _, f := range fields {
+			path := appendPath(f.obj.path, f.key)
+
+			if f.err != nil {
+				e.errorf(path, "%v", f.err)
+				f.obj.out.set(f.key, nil)
+				continue
+			}
+
+			f.obj.out.set(f.key, e.complete(f.def.typ, f.value, f.sel, path, &level))
+		}
+	}
+
+	return data
+}
+
+// resolveFields calls the resolvers of the fields selected on obj.
+func (e *executor) resolveFields(ctx context.Context, obj *object) []*fieldValue {
+	var fields []*fieldValue
+
+	var keys []string
+	groups := map[string][]*field{}
+	e.collect(obj.typ, obj.sel, &keys, groups, map[string]bool{})
+
+	for _, key := range keys {
+		group := groups[key]
+		name := group[0].name
+		path := appendPath(obj.path, key)
+
+		if name == "__typename" {
+			obj.out.set(key, obj.typ.name)
+			continue
+		}
+
+		def := obj.typ.fields[name]
+		if def == nil {
+			e.errorf(path, "unknown field %s on type %s", name, obj.typ.name)
+			obj.out.set(key, nil)
+			continue
+		}
+
+		var sel []selection
+		for _, f := range group {
+			sel = append(sel, f.sel...)
+		}
+
+		if scalar := isScalar(def.typ.named()); scalar && len(sel) > 0 {
+			e.errorf(path, "field %s of type %s must not have a selection", name, def.typ)
+			obj.out.set(key, nil)
+			continue
+		} else if !scalar && len(sel) == 0 {
+			e.errorf(path, "field %s of type %s must have a selection", name, def.typ)
+			obj.out.set(key, nil)
+			continue
+		}
+
+		// Reserve the position of the key in the output.
+		obj.out.set(key, nil)
+
+		f := &fieldValue{obj: obj, key: key, def: def, sel: sel}
+		args, err := e.arguments(def, group[0])
+		if err != nil {
+			f.err = err
+		} else if def.resolve != nil {
+			f.value, f.err = def.resolve(ctx, obj.source, args)
+		} else {
+			f.value, f.err = resolveStructField(obj.source, name)
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+// collect adds the fields of sel grouped by response key to keys and
+// groups, with fragments expanded and skipped fields removed.
+func (e *executor) collect(typ *objectType, sel []selection, keys *[]string, groups map[string][]*field, visited map[string]bool) {
+	for _, s := range sel {
+		switch s := s.(type) {
+		case *field:
+			if !e.include(s.directives) {
+				continue
+			}
+
+			key := s.key()
+			if _, ok := groups[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			groups[key] = append(groups[key], s)
+		case *fragmentSpread:
+			if !e.include(s.directives) || visited[s.name] {
+				continue
+			}
+			visited[s.name] = true
+
+			f := e.doc.fragments[s.name]
+			if f == nil {
+				e.errorf(nil, "unknown fragment %s", s.name)
+				continue
+			}
+
+			if f.typeCondition == typ.name {
+				e.collect(typ, f.sel, keys, groups, visited)
+			}
+		case *inlineFragment:
+			if !e.include(s.directives) {
+				continue
+			}
+
+			if len(s.typeCondition) == 0 || s.typeCondition == typ.name {
+				e.collect(typ, s.sel, keys, groups, visited)
+			}
+		}
+	}
+}
+
+// include reports whether the skip and include directives include a selection.
+func (e *executor) include(dirs []*directive) bool {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+
+		var cond bool
+		for _, a := range d.args {
+			if a.name == "if" {
+				cond, _ = e.value(a.value).(bool)
+			}
+		}
+
+		if (d.name == "skip") == cond {
+			return false
+		}
+	}
+
+	return true
+}
+
+// arguments returns the coerced arguments of a field.
+func (e *executor) arguments(def *fieldDefinition, f *field) (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+
+	for _, a := range f.args {
+		found := false
+		for _, ad := range def.args {
+			found = found || ad.name == a.name
+		}
+
+		if !found {
+			return nil, fmt.Errorf("unknown argument %s on field %s", a.name, def.name)
+		}
+	}
+
+	for _, ad := range def.args {
+		value := ad.def
+
+		for _, a := range f.args {
+			if a.name != ad.name {
+				continue
+			}
+
+			if v, ok := a.value.(variable); ok {
+				if vv, ok := e.vars[string(v)]; ok && vv != nil {
+					value = vv
+				}
+				continue
+			}
+
+			value = e.value(a.value)
+		}
+
+		value, err := coerceInput(ad.typ, value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %v", ad.name, err)
+		}
+
+		args[ad.name] = value
+	}
+
+	return args, nil
+}
+
+// value returns the Go value of a literal with the variables substituted.
+func (e *executor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case variable:
+		return e.vars[string(v)]
+	case enumValue:
+		return string(v)
+	case listValue:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = e.value(item)
+		}
+		return list
+	case objectValue:
+		obj := map[string]interface{}{}
+		for k, item := range v {
+			obj[k] = e.value(item)
+		}
+		return obj
+	}
+
+	return v
+}
+
+// complete returns the output value of a resolved field value. Objects are
+// added to next to have their fields resolved in the next level.
+func (e *executor) complete(t *typeRef, v interface{}, sel []selection, path []interface{}, next *[]*object) interface{} {
+	// A nil slice is an empty list.
+	emptyList := t.list != nil && reflect.ValueOf(v).Kind() == reflect.Slice
+	if isNil(v) && !emptyList {
+		if t.nonNull {
+			e.errorf(path, "null value for non-null type %s", t)
+		}
+		return nil
+	}
+
+	if t.list != nil {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.errorf(path, "expected list got %T", v)
+			return nil
+		}
+
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = e.complete(t.list, rv.Index(i).Interface(), sel, appendPath(path, i), next)
+		}
+		return list
+	}
+
+	if isScalar(t.name) {
+		out, err := coerceOutput(t.name, v)
+		if err != nil {
+			e.errorf(path, "%v", err)
+			return nil
+		}
+		return out
+	}
+
+	out := &orderedMap{}
+	*next = append(*next, &object{typ: e.schema.types[t.name], source: v, sel: sel, out: out, path: path})
+	return out
+}
+
+// errorf adds a field error.
+func (e *executor) errorf(path []interface{}, format string, args ...interface{}) {
+	e.errors = append(e.errors, &Error{Message: fmt.Sprintf(format, args...), Path: path})
+}
+
+// appendPath returns a copy of path with elem appended.
+func appendPath(path []interface{}, elem interface{}) []interface{} {
+	p := make([]interface{}, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, elem)
+}
+
+// resolveStructField returns the struct field of source named name ignoring case.
+func resolveStructField(source interface{}, name string) (interface{}, error) {
+	v := reflect.ValueOf(source)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("no resolver for field %s", name)
+	}
+
+	f := v.FieldByNameFunc(func(s string) bool {
+		return strings.EqualFold(s, name)
+	})
+	if !f.IsValid() || !f.CanInterface() {
+		return nil, fmt.Errorf("no resolver for field %s", name)
+	}
+
+	if f.Kind() == reflect.Struct && f.CanAddr() {
+		return f.Addr().Interface(), nil
+	}
+
+	return f.Interface(), nil
+}
+
+// isNil reports whether v is nil or a nil pointer, slice or map.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface, reflect.Func:
+		return rv.IsNil()
+	}
+
+	return false
+}
+
+// isScalar reports whether name is a built-in scalar type.
+func isScalar(name string) bool {
+	switch name {
+	case "String", "ID", "Int", "Float", "Boolean":
+		return true
+	}
+
+	return false
+}
+
+// coerceOutput converts a resolved value to the scalar type.
+func coerceOutput(typ string, v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+
+	switch typ {
+	case "String":
+		if rv.Kind() == reflect.String {
+			return rv.String(), nil
+		}
+	case "ID":
+		switch rv.Kind() {
+		case reflect.String:
+			return rv.String(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return fmt.Sprint(rv.Int()), nil
+		}
+	case "Int":
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if n := rv.Int(); n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int(n), nil
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n := rv.Uint(); n <= math.MaxInt32 {
+				return int(n), nil
+			}
+		}
+	case "Float":
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return rv.Float(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(rv.Int()), nil
+		}
+	case "Boolean":
+		if rv.Kind() == reflect.Bool {
+			return rv.Bool(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("can't represent %T as %s", v, typ)
+}
+
+// coerceInput converts an argument or variable value to the type. Ints are
+// accepted as floats and IDs, and a single value as a list.
+func coerceInput(t *typeRef, v interface{}) (interface{}, error) {
+	if v == nil {
+		if t.nonNull {
+			return nil, fmt.Errorf("expected %s got null", t)
+		}
+		return nil, nil
+	}
+
+	if t.list != nil {
+		items, ok := v.([]interface{})
+		if !ok {
+			items = []interface{}{v}
+		}
+
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			value, err := coerceInput(t.list, item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	}
+
+	switch t.name {
+	case "String":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case "ID":
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case int:
+			return fmt.Sprint(v), nil
+		case float64:
+			if v == math.Trunc(v) {
+				return fmt.Sprint(int64(v)), nil
+			}
+		}
+	case "Int":
+		switch v := v.(type) {
+		case int:
+			if v >= math.MinInt32 && v <= math.MaxInt32 {
+				return v, nil
+			}
+		case float64:
+			if v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int(v), nil
+			}
+		}
+	case "Float":
+		switch v := v.(type) {
+		case int:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+	case "Boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expected %s got %v", t, v)
+}
@@ -0,0 +1,222 @@
+// Package graphql implements a small GraphQL server for the SL APIs.
+//
+// New returns a schema with locations, departures, deviations, trips and
+// journeys resolved by the LocationService, RealtimeService and
+// TravelPlannerService of a sl.Client. NewHandler serves a schema over http.
+//
+// The executor resolves a query one level at a time, so resolvers that load
+// data with a Loader are batched: all keys requested by the fields of a level
+// are fetched together and each key is fetched once per request. Fragments,
+// variables, aliases and the skip and include directives are supported,
+// introspection other than __typename is not.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Resolver resolves a field value from the source object, i.e. the value of
+// the parent field, and the field arguments. A resolver may return a Thunk
+// from Loader.Load to have the value loaded together with other fields.
+type Resolver func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// Schema represents an executable GraphQL schema.
+type Schema struct {
+	sdl   string
+	types map[string]*objectType
+}
+
+// NewSchema creates a schema from the object types in sdl and resolvers by
+// type and field name, e.g. Query.locations. Fields without a resolver are
+// resolved from the struct field of the source with the same name ignoring
+// case, i.e. lineNumber is resolved from LineNumber. The root type is Query.
+func NewSchema(sdl string, resolvers map[string]Resolver) (*Schema, error) {
+	types, err := parseSchema(sdl)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := types["Query"]; !ok {
+		return nil, fmt.Errorf("graphql: schema has no Query type")
+	}
+
+	for _, t := range types {
+		for _, f := range t.fields {
+			if !isScalar(f.typ.named()) && types[f.typ.named()] == nil {
+				return nil, fmt.Errorf("graphql: unknown type %s of %s.%s", f.typ.named(), t.name, f.name)
+			}
+
+			for _, a := range f.args {
+				if !isScalar(a.typ.named()) {
+					return nil, fmt.Errorf("graphql: argument %s of %s.%s must be a scalar", a.name, t.name, f.name)
+				}
+			}
+		}
+	}
+
+	for name, r := range resolvers {
+		typ, fieldName, _ := strings.Cut(name, ".")
+
+		t := types[typ]
+		if t == nil || t.fields[fieldName] == nil {
+			return nil, fmt.Errorf("graphql: resolver for unknown field %s", name)
+		}
+
+		t.fields[fieldName].resolve = r
+	}
+
+	return &Schema{sdl: sdl, types: types}, nil
+}
+
+// String returns the schema definition.
+func (s *Schema) String() string {
+	return s.sdl
+}
+
+// Params specifies the query to execute.
+type Params struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response represents the result of executing a query.
+type Response struct {
+	Data   interface{} `json:"data"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error represents a GraphQL error. Path is the response path of the field
+// that failed, if any.
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Execute executes a query against the schema. Request errors, like syntax
+// errors, are returned in a response without data. Field errors are
+// returned together with the data and the failed fields are null.
+func (s *Schema) Execute(ctx context.Context, params *Params) *Response {
+	doc, err := parseQuery(params.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	op, err := doc.operation(params.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	if op.typ != "query" {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("graphql: %s operations are not supported", op.typ)}}}
+	}
+
+	vars, err := variables(op, params.Variables)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	e := &executor{schema: s, doc: doc, vars: vars, batch: &batch{}}
+	data := e.execute(withBatch(ctx, e.batch), s.types["Query"], op.sel)
+
+	return &Response{Data: data, Errors: e.errors}
+}
+
+// operation returns the operation to execute by name. The name can be empty
+// if the document has a single operation.
+func (d *document) operation(name string) (*operation, error) {
+	if len(name) == 0 {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("graphql: operation name is required with multiple operations")
+		}
+
+		return d.operations[0], nil
+	}
+
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+
+	return nil, fmt.Errorf("graphql: unknown operation %q", name)
+}
+
+// variables returns the coerced variable values of the operation.
+func variables(op *operation, values map[string]interface{}) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+
+	for _, v := range op.vars {
+		if !isScalar(v.typ.named()) {
+			return nil, fmt.Errorf("graphql: variable $%s must be a scalar", v.name)
+		}
+
+		value, ok := values[v.name]
+		if !ok {
+			value = v.def
+		}
+
+		value, err := coerceInput(v.typ, value)
+		if err != nil {
+			return nil, fmt.Errorf("graphql: variable $%s: %v", v.name, err)
+		}
+
+		vars[v.name] = value
+	}
+
+	return vars, nil
+}
+
+// orderedMap is a JSON object that keeps the order of its keys.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// set sets the value of key, keeping the position of an existing key.
+func (m *orderedMap) set(key string, value interface{}) {
+	if m.values == nil {
+		m.values = map[string]interface{}{}
+	}
+
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+
+	m.values[key] = value
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	b := []byte{'{'}
+
+	for i, k := range m.keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+
+		b = append(b, key...)
+		b = append(b, ':')
+		b = append(b, value...)
+	}
+
+	return append(b, '}'), nil
+}
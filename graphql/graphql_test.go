@@ -0,0 +1,214 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSDL = `
+# Test schema.
+type Query {
+  "A line by number."
+  line(number: Int!): Line
+  lines(numbers: [Int!]!, mode: String = "METRO"): [Line!]!
+  fail: String
+}
+
+type Line {
+  number: Int!
+  mode: String!
+  name: String
+  stops: [Stop!]!
+}
+
+type Stop {
+  name: String!
+  position: Float
+}
+`
+
+type testLine struct {
+	Number int
+	Mode   string
+	Name   string
+	Stops  []*testStop
+}
+
+type testStop struct {
+	Name     string
+	Position float64
+}
+
+func testSchema(t *testing.T) *Schema {
+	line := func(n int, mode string) *testLine {
+		return &testLine{Number: n, Mode: mode, Name: "line " + strings.Repeat("x", n%3), Stops: []*testStop{{Name: "Slussen", Position: 1.5}}}
+	}
+
+	schema, err := NewSchema(testSDL, map[string]Resolver{
+		"Query.line": func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return line(args["number"].(int), "METRO"), nil
+		},
+		"Query.lines": func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			var lines []*testLine
+			for _, n := range args["numbers"].([]interface{}) {
+				lines = append(lines, line(n.(int), args["mode"].(string)))
+			}
+			return lines, nil
+		},
+		"Query.fail": func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return nil, errors.New("failed")
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	return schema
+}
+
+func execute(t *testing.T, schema *Schema, params *Params) (string, []*Error) {
+	resp := schema.Execute(context.Background(), params)
+
+	b, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	return string(b), resp.Errors
+}
+
+func TestExecute(t *testing.T) {
+	schema := testSchema(t)
+
+	tests := []struct {
+		params *Params
+		want   string
+	}{
+		{
+			&Params{Query: `{ line(number: 13) { number mode __typename } }`},
+			`{"line":{"number":13,"mode":"METRO","__typename":"Line"}}`,
+		},
+		{
+			&Params{Query: `query Lines($n: [Int!]!, $mode: String) { lines(numbers: $n, mode: $mode) { n: number mode } }`, Variables: map[string]interface{}{"n": []interface{}{13.0, 14.0}, "mode": "BUS"}},
+			`{"lines":[{"n":13,"mode":"BUS"},{"n":14,"mode":"BUS"}]}`,
+		},
+		{
+			&Params{Query: `{ lines(numbers: 10) { ...line stops { name } } } fragment line on Line { number name }`},
+			`{"lines":[{"number":10,"name":"line x","stops":[{"name":"Slussen"}]}]}`,
+		},
+		{
+			&Params{Query: `query ($skip: Boolean = true) { line(number: 11) { number mode @skip(if: $skip) ... on Line @include(if: true) { stops { position } } } }`},
+			`{"line":{"number":11,"stops":[{"position":1.5}]}}`,
+		},
+		{
+			&Params{Query: `query A { line(number: 1) { number } } query B { line(number: 2) { number } }`, OperationName: "B"},
+			`{"line":{"number":2}}`,
+		},
+	}
+
+	for _, test := range tests {
+		got, errs := execute(t, schema, test.params)
+		if len(errs) > 0 {
+			t.Errorf("Expected no errors got %v", errs[0])
+		}
+
+		if got != test.want {
+			t.Errorf("Expected %s got %s", test.want, got)
+		}
+	}
+}
+
+func TestExecuteErrors(t *testing.T) {
+	schema := testSchema(t)
+
+	got, errs := execute(t, schema, &Params{Query: `{ fail line(number: 1) { number } }`})
+	if want := `{"fail":null,"line":{"number":1}}`; got != want {
+		t.Errorf("Expected %s got %s", want, got)
+	}
+
+	if len(errs) != 1 || errs[0].Message != "failed" || !reflect.DeepEqual(errs[0].Path, []interface{}{"fail"}) {
+		t.Errorf("Expected error 'failed' at fail got %v", errs)
+	}
+
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`{ line(number: 1) { number }`, "graphql: syntax error at 1:29: unexpected end of document, expected name"},
+		{`{ line(number: "1") { number } }`, `argument number: expected Int! got 1`},
+		{`{ line { number } }`, `argument number: expected Int! got null`},
+		{`{ line(number: 1) { speed } }`, "unknown field speed on type Line"},
+		{`{ line(number: 1) }`, "field line of type Line must have a selection"},
+		{`{ fail { name } }`, "field fail of type String must not have a selection"},
+		{`mutation { fail }`, "graphql: mutation operations are not supported"},
+		{`query A { fail } query B { fail }`, "graphql: operation name is required with multiple operations"},
+		{`{ fail @`, "graphql: syntax error at 1:9: unexpected end of document, expected name"},
+	}
+
+	for _, test := range tests {
+		_, errs := execute(t, schema, &Params{Query: test.query})
+		if len(errs) == 0 || errs[0].Message != test.err {
+			t.Errorf("Expected error %q for %s got %v", test.err, test.query, errs)
+		}
+	}
+}
+
+func TestNewSchemaErrors(t *testing.T) {
+	tests := []struct {
+		sdl       string
+		resolvers map[string]Resolver
+	}{
+		{`type Line { number: Int }`, nil},
+		{`type Query { line: Line }`, nil},
+		{`type Query { line: String }`, map[string]Resolver{"Query.lines": nil}},
+		{`type Query { line: String line: Int }`, nil},
+	}
+
+	for _, test := range tests {
+		if _, err := NewSchema(test.sdl, test.resolvers); err == nil {
+			t.Errorf("Expected error for %s got nil", test.sdl)
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	doc, err := parseQuery(`
+		query Departures($site: ID! = "9192") @cached {
+			# The departures.
+			d: departures(siteId: $site, modes: [METRO, BUS], filter: {line: "13", min: -1.5e2}) {
+				... on Departure { line }
+				...rest
+			}
+		}
+		fragment rest on Departure { text(s: "a\"å\n") }`)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	op := doc.operations[0]
+	if op.name != "Departures" || len(op.vars) != 1 || op.vars[0].typ.String() != "ID!" || op.vars[0].def != "9192" {
+		t.Errorf("Expected operation Departures with $site: ID! got %+v", op)
+	}
+
+	f := op.sel[0].(*field)
+	if f.alias != "d" || f.name != "departures" || len(f.args) != 3 || len(f.sel) != 2 {
+		t.Fatalf("Expected field d: departures got %+v", f)
+	}
+
+	if got := f.args[1].value; !reflect.DeepEqual(got, listValue{enumValue("METRO"), enumValue("BUS")}) {
+		t.Errorf("Expected [METRO BUS] got %v", got)
+	}
+
+	if got := f.args[2].value; !reflect.DeepEqual(got, objectValue{"line": "13", "min": -150.0}) {
+		t.Errorf("Expected {line: 13, min: -150} got %v", got)
+	}
+
+	arg := doc.fragments["rest"].sel[0].(*field).args[0].value
+	if arg != "a\"å\n" {
+		t.Errorf("Expected %q got %q", "a\"å\n", arg)
+	}
+}
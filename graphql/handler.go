@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// maxBodySize is the max size of a request body.
+const maxBodySize = 1 << 20
+
+// NewHandler returns a handler that executes queries against the schema.
+//
+// GET requests take the query, operationName and variables query
+// parameters, variables encoded as JSON. POST requests take a JSON body
+// with the same fields or, with the application/graphql content type, the
+// query as the body. Responses are JSON with status 400 if the query
+// couldn't be executed at all.
+func NewHandler(schema *Schema) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := &Params{}
+
+		switch r.Method {
+		case http.MethodGet:
+			q := r.URL.Query()
+			params.Query = q.Get("query")
+			params.OperationName = q.Get("operationName")
+
+			if v := q.Get("variables"); len(v) > 0 {
+				if err := json.Unmarshal([]byte(v), &params.Variables); err != nil {
+					writeResponse(w, http.StatusBadRequest, errorResponse("invalid variables"))
+					return
+				}
+			}
+		case http.MethodPost:
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize))
+			if err != nil {
+				writeResponse(w, http.StatusBadRequest, errorResponse("invalid request body"))
+				return
+			}
+
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "application/graphql") {
+				params.Query = string(body)
+			} else if err := json.Unmarshal(body, params); err != nil {
+				writeResponse(w, http.StatusBadRequest, errorResponse("invalid request body"))
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeResponse(w, http.StatusMethodNotAllowed, errorResponse(http.StatusText(http.StatusMethodNotAllowed)))
+			return
+		}
+
+		if len(params.Query) == 0 {
+			writeResponse(w, http.StatusBadRequest, errorResponse("query can't be empty"))
+			return
+		}
+
+		resp := schema.Execute(r.Context(), params)
+
+		status := http.StatusOK
+		if resp.Data == nil {
+			status = http.StatusBadRequest
+		}
+
+		writeResponse(w, status, resp)
+	})
+}
+
+// errorResponse returns a response with a single error.
+func errorResponse(message string) *Response {
+	return &Response{Errors: []*Error{{Message: message}}}
+}
+
+// writeResponse writes the response as JSON with the status code.
+func writeResponse(w http.ResponseWriter, status int, resp *Response) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,66 @@
+package graphql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	h := NewHandler(testSchema(t))
+
+	graphqlReq := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{ line(number: 1) { mode } }`))
+	graphqlReq.Header.Set("Content-Type", "application/graphql")
+
+	tests := []struct {
+		req  *http.Request
+		code int
+		body string
+	}{
+		{
+			httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`query ($n: Int!) { line(number: $n) { number } }`)+"&variables="+url.QueryEscape(`{"n": 13}`), nil),
+			http.StatusOK,
+			`{"data":{"line":{"number":13}}}`,
+		},
+		{
+			httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ fail }"}`)),
+			http.StatusOK,
+			`{"data":{"fail":null},"errors":[{"message":"failed","path":["fail"]}]}`,
+		},
+		{
+			httptest.NewRequest("POST", "/graphql", strings.NewReader(`{ line(number: 1) { mode } }`)),
+			http.StatusBadRequest,
+			`{"data":null,"errors":[{"message":"invalid request body"}]}`,
+		},
+		{
+			httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape("{ line"), nil),
+			http.StatusBadRequest,
+			`{"data":null,"errors":[{"message":"graphql: syntax error at 1:7: unexpected end of document, expected name"}]}`,
+		},
+		{
+			graphqlReq,
+			http.StatusOK,
+			`{"data":{"line":{"mode":"METRO"}}}`,
+		},
+		{
+			httptest.NewRequest("PUT", "/graphql", nil),
+			http.StatusMethodNotAllowed,
+			`{"data":null,"errors":[{"message":"Method Not Allowed"}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, test.req)
+
+		if w.Code != test.code {
+			t.Errorf("Expected %d got %d", test.code, w.Code)
+		}
+
+		if got := strings.TrimSpace(w.Body.String()); got != test.body {
+			t.Errorf("Expected %s got %s", test.body, got)
+		}
+	}
+}
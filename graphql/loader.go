@@ -0,0 +1,141 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultMaxConcurrency is the default number of keys fetched at once by a Loader.
+const defaultMaxConcurrency = 8
+
+// Thunk returns a value loaded by a Loader.
+type Thunk func() (interface{}, error)
+
+// Loader batches and caches loads of values by key. Keys requested while a
+// level of a query is resolved are fetched together when the level is done
+// and each key is fetched once per query execution.
+//
+// The SL APIs have no batch endpoints, so a batch fetches its keys
+// concurrently with at most MaxConcurrency fetches at once.
+type Loader struct {
+	// Fetch fetches the value of a key.
+	Fetch func(ctx context.Context, key string) (interface{}, error)
+
+	// MaxConcurrency is the max number of concurrent fetches. Default is 8.
+	MaxConcurrency int
+}
+
+// Load returns a thunk for the value of key. The value is fetched when the
+// batch is dispatched or when the thunk is called. Outside of
+// Schema.Execute the value is fetched at once.
+func (l *Loader) Load(ctx context.Context, key string) Thunk {
+	b, _ := ctx.Value(batchKey{}).(*batch)
+	if b == nil {
+		v, err := l.Fetch(ctx, key)
+		return func() (interface{}, error) {
+			return v, err
+		}
+	}
+
+	r := b.load(l, key)
+	return func() (interface{}, error) {
+		b.dispatch(ctx)
+		return r.value, r.err
+	}
+}
+
+// batchKey is the context key of the batch of a query execution.
+type batchKey struct{}
+
+// withBatch returns a context with the batch used by loaders.
+func withBatch(ctx context.Context, b *batch) context.Context {
+	return context.WithValue(ctx, batchKey{}, b)
+}
+
+// result is the loaded value of a key.
+type result struct {
+	value interface{}
+	err   error
+}
+
+// loaderState is the cached and pending keys of a loader.
+type loaderState struct {
+	results map[string]*result
+	pending []string
+}
+
+// batch holds the state of all loaders during a query execution.
+type batch struct {
+	mu      sync.Mutex
+	loaders map[*Loader]*loaderState
+}
+
+// load returns the result of key, adding the key to the pending keys if it
+// isn't loaded or pending already.
+func (b *batch) load(l *Loader, key string) *result {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.loaders == nil {
+		b.loaders = map[*Loader]*loaderState{}
+	}
+
+	s := b.loaders[l]
+	if s == nil {
+		s = &loaderState{results: map[string]*result{}}
+		b.loaders[l] = s
+	}
+
+	r, ok := s.results[key]
+	if !ok {
+		r = &result{}
+		s.results[key] = r
+		s.pending = append(s.pending, key)
+	}
+
+	return r
+}
+
+// dispatch fetches the pending keys of all loaders.
+func (b *batch) dispatch(ctx context.Context) {
+	type fetch struct {
+		loader *Loader
+		key    string
+		result *result
+	}
+
+	b.mu.Lock()
+	var fetches []*fetch
+	for l, s := range b.loaders {
+		for _, key := range s.pending {
+			fetches = append(fetches, &fetch{loader: l, key: key, result: s.results[key]})
+		}
+		s.pending = nil
+	}
+	b.mu.Unlock()
+
+	var wg sync.WaitGroup
+	sem := map[*Loader]chan struct{}{}
+
+	for _, f := range fetches {
+		if sem[f.loader] == nil {
+			n := f.loader.MaxConcurrency
+			if n <= 0 {
+				n = defaultMaxConcurrency
+			}
+			sem[f.loader] = make(chan struct{}, n)
+		}
+
+		wg.Add(1)
+		go func(f *fetch, sem chan struct{}) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			f.result.value, f.result.err = f.loader.Fetch(ctx, f.key)
+		}(f, sem[f.loader])
+	}
+
+	wg.Wait()
+}
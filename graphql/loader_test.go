@@ -0,0 +1,66 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestLoader(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+
+	l := &Loader{
+		Fetch: func(ctx context.Context, key string) (interface{}, error) {
+			mu.Lock()
+			keys = append(keys, key)
+			mu.Unlock()
+
+			if key == "bad" {
+				return nil, errors.New("bad key")
+			}
+
+			return "value " + key, nil
+		},
+		MaxConcurrency: 2,
+	}
+
+	b := &batch{}
+	ctx := withBatch(context.Background(), b)
+
+	thunks := []Thunk{l.Load(ctx, "a"), l.Load(ctx, "b"), l.Load(ctx, "a"), l.Load(ctx, "c"), l.Load(ctx, "bad")}
+	if len(keys) != 0 {
+		t.Fatalf("Expected no fetches before dispatch got %v", keys)
+	}
+
+	b.dispatch(ctx)
+
+	if len(keys) != 4 {
+		t.Errorf("Expected 4 fetches got %v", keys)
+	}
+
+	for i, want := range []string{"value a", "value b", "value a", "value c"} {
+		if v, err := thunks[i](); err != nil || v != want {
+			t.Errorf("Expected %q got %v, %v", want, v, err)
+		}
+	}
+
+	if _, err := thunks[4](); err == nil || err.Error() != "bad key" {
+		t.Errorf("Expected 'bad key' got %v", err)
+	}
+
+	// Loaded keys are cached and thunks dispatch pending keys when called.
+	if v, _ := l.Load(ctx, "b")(); v != "value b" || len(keys) != 4 {
+		t.Errorf("Expected cached 'value b' got %v after %v", v, keys)
+	}
+
+	if v, _ := l.Load(ctx, "d")(); v != "value d" || len(keys) != 5 {
+		t.Errorf("Expected 'value d' got %v after %v", v, keys)
+	}
+
+	// Without a batch values are fetched at once.
+	if v, _ := l.Load(context.Background(), "e")(); v != "value e" || len(keys) != 6 {
+		t.Errorf("Expected 'value e' got %v after %v", v, keys)
+	}
+}
@@ -0,0 +1,724 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Token kinds.
+const (
+	tokenEOF = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+// token is a lexical token.
+type token struct {
+	kind  int
+	value string
+	pos   int
+}
+
+// document is a parsed query document.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation is a query, mutation or subscription.
+type operation struct {
+	typ  string
+	name string
+	vars []*variableDefinition
+	sel  []selection
+}
+
+// variableDefinition is a variable of an operation.
+type variableDefinition struct {
+	name string
+	typ  *typeRef
+	def  interface{}
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment.
+type selection interface{}
+
+// field is a field selection.
+type field struct {
+	alias      string
+	name       string
+	args       []*argument
+	directives []*directive
+	sel        []selection
+	pos        int
+}
+
+// key returns the response key of the field.
+func (f *field) key() string {
+	if len(f.alias) > 0 {
+		return f.alias
+	}
+
+	return f.name
+}
+
+// argument is an argument of a field or directive.
+type argument struct {
+	name  string
+	value interface{}
+}
+
+// directive is a directive like @skip(if: true).
+type directive struct {
+	name string
+	args []*argument
+}
+
+// fragmentSpread is a named fragment spread like ...name.
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+}
+
+// inlineFragment is an inline fragment like ... on Type { }.
+type inlineFragment struct {
+	typeCondition string
+	directives    []*directive
+	sel           []selection
+}
+
+// fragment is a named fragment definition.
+type fragment struct {
+	name          string
+	typeCondition string
+	sel           []selection
+}
+
+// Value literals that can't be represented by Go values.
+type (
+	variable    string
+	enumValue   string
+	listValue   []interface{}
+	objectValue map[string]interface{}
+)
+
+// typeRef is a type reference like [String!]!.
+type typeRef struct {
+	name    string
+	list    *typeRef
+	nonNull bool
+}
+
+// String returns the type in GraphQL notation.
+func (t *typeRef) String() string {
+	s := t.name
+	if t.list != nil {
+		s = "[" + t.list.String() + "]"
+	}
+
+	if t.nonNull {
+		s += "!"
+	}
+
+	return s
+}
+
+// named returns the named type of a possibly wrapped type.
+func (t *typeRef) named() string {
+	for t.list != nil {
+		t = t.list
+	}
+
+	return t.name
+}
+
+// parser parses GraphQL documents. The first error is kept and stops
+// the parsing.
+type parser struct {
+	src string
+	pos int
+	tok token
+	err error
+}
+
+// newParser creates a parser positioned at the first token of src.
+func newParser(src string) *parser {
+	p := &parser{src: src}
+	p.next()
+	return p
+}
+
+// errorf sets the parser error unless one is already set.
+func (p *parser) errorf(pos int, format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+
+	line, col := 1, 1
+	for _, r := range p.src[:pos] {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+
+	p.err = fmt.Errorf("graphql: syntax error at %d:%d: %s", line, col, fmt.Sprintf(format, args...))
+	p.tok = token{kind: tokenEOF, pos: len(p.src)}
+}
+
+// next reads the next token.
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else if strings.HasPrefix(p.src[p.pos:], "\ufeff") {
+			p.pos += 3
+		} else {
+			break
+		}
+	}
+
+	start := p.pos
+	if start >= len(p.src) {
+		p.tok = token{kind: tokenEOF, pos: start}
+		return
+	}
+
+	c := p.src[start]
+	switch {
+	case c == '.':
+		if !strings.HasPrefix(p.src[start:], "...") {
+			p.errorf(start, "unexpected %q", c)
+			return
+		}
+		p.pos += 3
+		p.tok = token{kind: tokenPunct, value: "...", pos: start}
+	case strings.IndexByte("!$&()[]{}:=@|", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenPunct, value: string(c), pos: start}
+	case isNameStart(c):
+		for p.pos < len(p.src) && (isNameStart(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenName, value: p.src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		p.number()
+	case c == '"':
+		p.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[start:])
+		p.errorf(start, "unexpected %q", r)
+	}
+}
+
+// number reads an int or float token.
+func (p *parser) number() {
+	start := p.pos
+	kind := tokenInt
+
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+
+	digits := func() {
+		n := p.pos
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+		}
+		if n == p.pos {
+			p.errorf(p.pos, "invalid number")
+		}
+	}
+
+	digits()
+
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		kind = tokenFloat
+		p.pos++
+		digits()
+	}
+
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		kind = tokenFloat
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+
+	if p.err == nil {
+		p.tok = token{kind: kind, value: p.src[start:p.pos], pos: start}
+	}
+}
+
+// string reads a string or block string token.
+func (p *parser) string() {
+	start := p.pos
+
+	if strings.HasPrefix(p.src[start:], `"""`) {
+		end := strings.Index(p.src[start+3:], `"""`)
+		if end < 0 {
+			p.errorf(start, "unterminated string")
+			return
+		}
+		p.pos = start + 3 + end + 3
+		p.tok = token{kind: tokenString, value: strings.TrimSpace(p.src[start+3 : start+3+end]), pos: start}
+		return
+	}
+
+	var b strings.Builder
+	p.pos++
+
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			p.errorf(start, "unterminated string")
+			return
+		}
+
+		c := p.src[p.pos]
+		if c == '"' {
+			p.pos++
+			break
+		}
+
+		if c != '\\' {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		if p.pos+1 >= len(p.src) {
+			p.errorf(p.pos, "unterminated string")
+			return
+		}
+
+		switch e := p.src[p.pos+1]; e {
+		case '"', '\\', '/':
+			b.WriteByte(e)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if p.pos+6 > len(p.src) {
+				p.errorf(p.pos, "invalid unicode escape")
+				return
+			}
+			r, err := strconv.ParseUint(p.src[p.pos+2:p.pos+6], 16, 32)
+			if err != nil {
+				p.errorf(p.pos, "invalid unicode escape")
+				return
+			}
+			b.WriteRune(rune(r))
+			p.pos += 4
+		default:
+			p.errorf(p.pos, "invalid escape \\%c", e)
+			return
+		}
+
+		p.pos += 2
+	}
+
+	p.tok = token{kind: tokenString, value: b.String(), pos: start}
+}
+
+// peek reports whether the current token is the punctuator or name s.
+func (p *parser) peek(s string) bool {
+	return (p.tok.kind == tokenPunct || p.tok.kind == tokenName) && p.tok.value == s
+}
+
+// skip reads the current token if it is the punctuator or name s.
+func (p *parser) skip(s string) bool {
+	if p.peek(s) {
+		p.next()
+		return true
+	}
+
+	return false
+}
+
+// expect reads the punctuator or name s or sets an error.
+func (p *parser) expect(s string) {
+	if !p.skip(s) {
+		p.unexpected("expected " + strconv.Quote(s))
+	}
+}
+
+// unexpected sets an error for the current token.
+func (p *parser) unexpected(msg string) {
+	if p.tok.kind == tokenEOF {
+		p.errorf(p.tok.pos, "unexpected end of document, %s", msg)
+	} else {
+		p.errorf(p.tok.pos, "unexpected %q, %s", p.tok.value, msg)
+	}
+}
+
+// name reads a name.
+func (p *parser) name() string {
+	if p.tok.kind != tokenName {
+		p.unexpected("expected name")
+		return ""
+	}
+
+	s := p.tok.value
+	p.next()
+	return s
+}
+
+// parseQuery parses an executable document.
+func parseQuery(src string) (*document, error) {
+	p := newParser(src)
+	doc := &document{fragments: map[string]*fragment{}}
+
+	for p.err == nil && p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			doc.operations = append(doc.operations, &operation{typ: "query", sel: p.selectionSet()})
+		case p.peek("query"), p.peek("mutation"), p.peek("subscription"):
+			doc.operations = append(doc.operations, p.operation())
+		case p.peek("fragment"):
+			pos := p.tok.pos
+			f := p.fragment()
+			if _, ok := doc.fragments[f.name]; ok {
+				p.errorf(pos, "duplicate fragment %q", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			p.unexpected("expected operation or fragment")
+		}
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("graphql: document has no operations")
+	}
+
+	return doc, nil
+}
+
+// operation reads an operation definition.
+func (p *parser) operation() *operation {
+	op := &operation{typ: p.name()}
+
+	if p.tok.kind == tokenName {
+		op.name = p.name()
+	}
+
+	if p.skip("(") {
+		for p.err == nil && !p.skip(")") {
+			v := &variableDefinition{}
+			p.expect("$")
+			v.name = p.name()
+			p.expect(":")
+			v.typ = p.typeRef()
+			if p.skip("=") {
+				v.def = p.value(true)
+			}
+			p.directives()
+			op.vars = append(op.vars, v)
+		}
+	}
+
+	p.directives()
+	op.sel = p.selectionSet()
+
+	return op
+}
+
+// fragment reads a fragment definition.
+func (p *parser) fragment() *fragment {
+	p.expect("fragment")
+
+	f := &fragment{}
+	if p.peek("on") {
+		p.unexpected("expected fragment name")
+	}
+	f.name = p.name()
+	p.expect("on")
+	f.typeCondition = p.name()
+	p.directives()
+	f.sel = p.selectionSet()
+
+	return f
+}
+
+// selectionSet reads a non-empty selection set.
+func (p *parser) selectionSet() []selection {
+	var sel []selection
+
+	p.expect("{")
+	for p.err == nil && !p.skip("}") {
+		sel = append(sel, p.selection())
+	}
+
+	if p.err == nil && len(sel) == 0 {
+		p.errorf(p.tok.pos, "empty selection set")
+	}
+
+	return sel
+}
+
+// selection reads a field, fragment spread or inline fragment.
+func (p *parser) selection() selection {
+	if !p.skip("...") {
+		return p.field()
+	}
+
+	if p.tok.kind == tokenName && !p.peek("on") {
+		return &fragmentSpread{name: p.name(), directives: p.directives()}
+	}
+
+	f := &inlineFragment{}
+	if p.skip("on") {
+		f.typeCondition = p.name()
+	}
+	f.directives = p.directives()
+	f.sel = p.selectionSet()
+
+	return f
+}
+
+// field reads a field selection.
+func (p *parser) field() *field {
+	f := &field{pos: p.tok.pos, name: p.name()}
+
+	if p.skip(":") {
+		f.alias = f.name
+		f.name = p.name()
+	}
+
+	f.args = p.arguments(false)
+	f.directives = p.directives()
+
+	if p.peek("{") {
+		f.sel = p.selectionSet()
+	}
+
+	return f
+}
+
+// arguments reads optional arguments.
+func (p *parser) arguments(constant bool) []*argument {
+	var args []*argument
+
+	if !p.skip("(") {
+		return nil
+	}
+
+	for p.err == nil && !p.skip(")") {
+		a := &argument{name: p.name()}
+		p.expect(":")
+		a.value = p.value(constant)
+		args = append(args, a)
+	}
+
+	return args
+}
+
+// directives reads optional directives.
+func (p *parser) directives() []*directive {
+	var dirs []*directive
+
+	for p.err == nil && p.skip("@") {
+		dirs = append(dirs, &directive{name: p.name(), args: p.arguments(false)})
+	}
+
+	return dirs
+}
+
+// typeRef reads a type reference.
+func (p *parser) typeRef() *typeRef {
+	t := &typeRef{}
+
+	if p.skip("[") {
+		t.list = p.typeRef()
+		p.expect("]")
+	} else {
+		t.name = p.name()
+	}
+
+	t.nonNull = p.skip("!")
+
+	return t
+}
+
+// value reads a value. Variables are not allowed in constant values.
+func (p *parser) value(constant bool) interface{} {
+	tok := p.tok
+
+	switch tok.kind {
+	case tokenInt:
+		p.next()
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			p.errorf(tok.pos, "invalid int %s", tok.value)
+		}
+		return n
+	case tokenFloat:
+		p.next()
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			p.errorf(tok.pos, "invalid float %s", tok.value)
+		}
+		return f
+	case tokenString:
+		p.next()
+		return tok.value
+	case tokenName:
+		p.next()
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return enumValue(tok.value)
+	}
+
+	switch {
+	case p.skip("$"):
+		if constant {
+			p.errorf(tok.pos, "unexpected variable in constant value")
+		}
+		return variable(p.name())
+	case p.skip("["):
+		list := listValue{}
+		for p.err == nil && !p.skip("]") {
+			list = append(list, p.value(constant))
+		}
+		return list
+	case p.skip("{"):
+		obj := objectValue{}
+		for p.err == nil && !p.skip("}") {
+			name := p.name()
+			p.expect(":")
+			obj[name] = p.value(constant)
+		}
+		return obj
+	}
+
+	p.unexpected("expected value")
+	return nil
+}
+
+// objectType is an object type of a schema.
+type objectType struct {
+	name   string
+	fields map[string]*fieldDefinition
+}
+
+// fieldDefinition is a field of an object type.
+type fieldDefinition struct {
+	name    string
+	args    []*argumentDefinition
+	typ     *typeRef
+	resolve Resolver
+}
+
+// argumentDefinition is an argument of a field definition.
+type argumentDefinition struct {
+	name string
+	typ  *typeRef
+	def  interface{}
+}
+
+// parseSchema parses the object type definitions of a schema in the
+// schema definition language. Descriptions and directives are ignored.
+func parseSchema(src string) (map[string]*objectType, error) {
+	p := newParser(src)
+	types := map[string]*objectType{}
+
+	for p.err == nil && p.tok.kind != tokenEOF {
+		p.description()
+		p.expect("type")
+
+		pos := p.tok.pos
+		t := &objectType{name: p.name(), fields: map[string]*fieldDefinition{}}
+		if _, ok := types[t.name]; ok {
+			p.errorf(pos, "duplicate type %q", t.name)
+		}
+		types[t.name] = t
+
+		p.directives()
+		p.expect("{")
+		for p.err == nil && !p.skip("}") {
+			p.description()
+
+			pos := p.tok.pos
+			f := &fieldDefinition{name: p.name()}
+			if _, ok := t.fields[f.name]; ok {
+				p.errorf(pos, "duplicate field %s.%s", t.name, f.name)
+			}
+			t.fields[f.name] = f
+
+			if p.skip("(") {
+				for p.err == nil && !p.skip(")") {
+					p.description()
+					a := &argumentDefinition{name: p.name()}
+					p.expect(":")
+					a.typ = p.typeRef()
+					if p.skip("=") {
+						a.def = p.value(true)
+					}
+					p.directives()
+					f.args = append(f.args, a)
+				}
+			}
+
+			p.expect(":")
+			f.typ = p.typeRef()
+			p.directives()
+		}
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return types, nil
+}
+
+// description skips an optional description string.
+func (p *parser) description() {
+	if p.tok.kind == tokenString {
+		p.next()
+	}
+}
+
+// isNameStart reports whether c can start a name.
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDigit reports whether c is a decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
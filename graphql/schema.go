@@ -0,0 +1,492 @@
+package graphql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/frozzare/go-sl"
+)
+
+// SDL is the schema definition of the SL schema returned by New.
+const SDL = `type Query {
+  "Search for locations by name."
+  locations(query: String!, stationsOnly: Boolean = false): [Location!]!
+
+  "A site by id, the name is taken from its departures."
+  site(id: ID!): Location
+
+  "Realtime departures from a site within timeWindow minutes, max 60."
+  departures(siteId: ID!, timeWindow: Int = 0): [Departure!]!
+
+  "Trips between two sites, planned for arrival if arrival is true."
+  trips(from: ID!, to: ID!, date: String, time: String, arrival: Boolean = false): [Trip!]!
+
+  "A journey by the journeyRef of a leg."
+  journey(ref: String!, date: String): Journey
+}
+
+type Location {
+  siteId: ID!
+  name: String
+  type: String
+  lat: Float
+  lon: Float
+  departures(timeWindow: Int = 0): [Departure!]!
+  deviations: [Deviation!]!
+}
+
+"""
+A realtime departure. Realtime departures have no journey reference,
+journey details are available from the legs of trips.
+"""
+type Departure {
+  transportMode: String!
+  lineNumber: String!
+  destination: String!
+  groupOfLine: String
+  displayTime: String
+  timeTabledDateTime: String
+  expectedDateTime: String
+  journeyDirection: Int
+  journeyNumber: Int
+  stopAreaName: String
+  stopAreaNumber: Int
+  stopPointDesignation: String
+  stopPointNumber: Int
+  scheduledOnly: Boolean
+  deviations: [Deviation!]!
+}
+
+type Deviation {
+  text: String!
+  consequence: String
+  importanceLevel: Int
+  transportMode: String
+  groupOfLine: String
+  stopAreaName: String
+}
+
+type Trip {
+  idx: Int
+  tripId: String
+  duration: String
+  ctxRecon: String
+  legs: [Leg!]!
+}
+
+type Leg {
+  idx: String
+  name: String
+  type: String
+  category: String
+  number: String
+  direction: String
+  cancelled: Boolean
+  origin: LegStop!
+  destination: LegStop!
+  product: Product
+  stops: [Stop!]!
+  deviations: [Deviation!]!
+  journeyRef: String
+  journey: Journey
+}
+
+type LegStop {
+  name: String
+  extId: String
+  type: String
+  date: String
+  time: String
+  track: String
+  rtDate: String
+  rtTime: String
+  rtTrack: String
+  lat: Float
+  lon: Float
+  cancelled: Boolean
+}
+
+type Product {
+  name: String
+  num: String
+  line: String
+  catOut: String
+  catOutL: String
+  operator: String
+}
+
+type Journey {
+  ref: String
+  journeyStatus: String
+  direction: String
+  stops: [Stop!]!
+}
+
+type Stop {
+  name: String
+  extId: String
+  routeIdx: Int
+  arrDate: String
+  arrTime: String
+  arrTrack: String
+  depDate: String
+  depTime: String
+  depTrack: String
+  rtArrTime: String
+  rtDepTime: String
+  lat: Float
+  lon: Float
+  cancelled: Boolean
+}
+`
+
+// Options specifies optional parameters to New.
+type Options struct {
+	// API Key used for the APIs that have no key of their own.
+	Key string
+
+	// API Keys by API.
+	LocationKey      string
+	RealtimeKey      string
+	TravelPlannerKey string
+}
+
+// key returns the api specific key if set, otherwise the common key.
+func (o *Options) key(specific string) string {
+	if len(specific) > 0 {
+		return specific
+	}
+
+	return o.Key
+}
+
+// deviation is a realtime deviation or a trip leg message.
+type deviation struct {
+	Text            string
+	Consequence     string
+	ImportanceLevel int
+	TransportMode   string
+	GroupOfLine     string
+	StopAreaName    string
+}
+
+// slResolvers resolves the SL schema using a sl.Client.
+type slResolvers struct {
+	client   *sl.Client
+	opt      Options
+	realtime *Loader
+	journeys *Loader
+}
+
+// New returns the SL schema, see SDL, resolved using the client.
+//
+// Departures and deviations of locations are loaded by site id and journeys
+// of legs by reference, so a query for many locations or legs fetches each
+// site or journey once and all of them concurrently.
+func New(client *sl.Client, opt *Options) *Schema {
+	r := &slResolvers{client: client}
+
+	if opt != nil {
+		r.opt = *opt
+	}
+
+	r.realtime = &Loader{Fetch: r.fetchRealtime}
+	r.journeys = &Loader{Fetch: r.fetchJourney}
+
+	schema, err := NewSchema(SDL, map[string]Resolver{
+		"Query.locations":      r.locations,
+		"Query.site":           r.site,
+		"Query.departures":     r.departures,
+		"Query.trips":          r.trips,
+		"Query.journey":        r.journey,
+		"Location.name":        r.locationName,
+		"Location.lat":         r.locationLat,
+		"Location.lon":         r.locationLon,
+		"Location.departures":  r.locationDepartures,
+		"Location.deviations":  r.locationDeviations,
+		"Departure.deviations": r.departureDeviations,
+		"Trip.legs":            r.legs,
+		"Leg.stops":            r.legStops,
+		"Leg.deviations":       r.legDeviations,
+		"Leg.journeyRef":       r.journeyRef,
+		"Leg.journey":          r.legJourney,
+		"Journey.direction":    r.journeyDirection,
+		"Journey.stops":        r.journeyStops,
+	})
+	if err != nil {
+		// The schema is static, so this is a bug.
+		panic(err)
+	}
+
+	return schema
+}
+
+// fetchRealtime fetches the realtime response of a key from realtimeKey.
+func (r *slResolvers) fetchRealtime(ctx context.Context, key string) (interface{}, error) {
+	siteID, window := splitKey(key)
+	n, _ := strconv.Atoi(window)
+
+	return r.client.Realtime.Search(ctx, &sl.RealtimeSearchOptions{
+		Key:        r.opt.key(r.opt.RealtimeKey),
+		SiteID:     siteID,
+		TimeWindow: n,
+	})
+}
+
+// fetchJourney fetches the journey of a key from journeyKey.
+func (r *slResolvers) fetchJourney(ctx context.Context, key string) (interface{}, error) {
+	ref, date := splitKey(key)
+
+	return r.client.TravelPlanner.Journey(ctx, &sl.JourneyOptions{
+		Key:  r.opt.key(r.opt.TravelPlannerKey),
+		ID:   ref,
+		Date: date,
+	})
+}
+
+// realtimeKey returns the realtime loader key of a site and time window.
+func realtimeKey(siteID string, window int) string {
+	return siteID + "\n" + strconv.Itoa(window)
+}
+
+// journeyKey returns the journey loader key of a reference and date.
+func journeyKey(ref, date string) string {
+	return ref + "\n" + date
+}
+
+// splitKey splits a loader key in its two parts.
+func splitKey(key string) (string, string) {
+	i := strings.LastIndexByte(key, '\n')
+	if i < 0 {
+		return key, ""
+	}
+
+	return key[:i], key[i+1:]
+}
+
+// loadRealtime returns a thunk for the realtime response of a site and
+// applies fn to it.
+func (r *slResolvers) loadRealtime(ctx context.Context, siteID string, window int, fn func(*sl.RealtimeResponse) interface{}) Thunk {
+	thunk := r.realtime.Load(ctx, realtimeKey(siteID, window))
+
+	return func() (interface{}, error) {
+		v, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, _ := v.(*sl.RealtimeResponse)
+		if resp == nil {
+			resp = &sl.RealtimeResponse{}
+		}
+
+		return fn(resp), nil
+	}
+}
+
+// locations resolves Query.locations.
+func (r *slResolvers) locations(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	return r.client.Location.Search(ctx, &sl.LocationSearchOptions{
+		Key:          r.opt.key(r.opt.LocationKey),
+		SearchString: args["query"].(string),
+		StationsOnly: args["stationsOnly"] == true,
+	})
+}
+
+// site resolves Query.site.
+func (r *slResolvers) site(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	return &sl.Location{SiteID: args["id"].(string)}, nil
+}
+
+// departures resolves Query.departures.
+func (r *slResolvers) departures(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	return r.loadRealtime(ctx, args["siteId"].(string), intArg(args, "timeWindow"), departures), nil
+}
+
+// trips resolves Query.trips.
+func (r *slResolvers) trips(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	opt := &sl.TripOptions{
+		Key:      r.opt.key(r.opt.TravelPlannerKey),
+		OriginID: args["from"].(string),
+		DestID:   args["to"].(string),
+		Date:     stringArg(args, "date"),
+		Time:     stringArg(args, "time"),
+	}
+
+	if args["arrival"] == true {
+		opt.SearchForArrival = 1
+	}
+
+	return r.client.TravelPlanner.Trip(ctx, opt)
+}
+
+// journey resolves Query.journey.
+func (r *slResolvers) journey(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	return r.journeys.Load(ctx, journeyKey(args["ref"].(string), stringArg(args, "date"))), nil
+}
+
+// locationName resolves Location.name, using the stop area name of the
+// site departures for locations from Query.site.
+func (r *slResolvers) locationName(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	l := source.(*sl.Location)
+	if len(l.Name) > 0 {
+		return l.Name, nil
+	}
+
+	return r.loadRealtime(ctx, l.SiteID, 0, func(resp *sl.RealtimeResponse) interface{} {
+		for _, t := range resp.Departures() {
+			if len(t.StopAreaName) > 0 {
+				return t.StopAreaName
+			}
+		}
+
+		for _, d := range resp.StopPointDeviations {
+			if len(d.StopInfo.StopAreaName) > 0 {
+				return d.StopInfo.StopAreaName
+			}
+		}
+
+		return nil
+	}), nil
+}
+
+// locationLat resolves Location.lat.
+func (r *slResolvers) locationLat(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	ll, err := source.(*sl.Location).LatLon()
+	if err != nil {
+		return nil, nil
+	}
+
+	return ll.Lat, nil
+}
+
+// locationLon resolves Location.lon.
+func (r *slResolvers) locationLon(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	ll, err := source.(*sl.Location).LatLon()
+	if err != nil {
+		return nil, nil
+	}
+
+	return ll.Lon, nil
+}
+
+// locationDepartures resolves Location.departures.
+func (r *slResolvers) locationDepartures(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	return r.loadRealtime(ctx, source.(*sl.Location).SiteID, intArg(args, "timeWindow"), departures), nil
+}
+
+// locationDeviations resolves Location.deviations from the stop point deviations of the site.
+func (r *slResolvers) locationDeviations(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	return r.loadRealtime(ctx, source.(*sl.Location).SiteID, 0, func(resp *sl.RealtimeResponse) interface{} {
+		devs := []*deviation{}
+
+		for _, d := range resp.StopPointDeviations {
+			consequence, _ := d.Deviation.Consequence.(string)
+			devs = append(devs, &deviation{
+				Text:            d.Deviation.Text,
+				Consequence:     consequence,
+				ImportanceLevel: d.Deviation.ImportanceLevel,
+				TransportMode:   d.StopInfo.TransportMode,
+				GroupOfLine:     d.StopInfo.GroupOfLine,
+				StopAreaName:    d.StopInfo.StopAreaName,
+			})
+		}
+
+		return devs
+	}), nil
+}
+
+// departureDeviations resolves Departure.deviations.
+func (r *slResolvers) departureDeviations(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	t := source.(*sl.Transport)
+	devs := []*deviation{}
+
+	for _, d := range t.Deviations {
+		devs = append(devs, &deviation{
+			Text:            d.Text,
+			Consequence:     d.Consequence,
+			ImportanceLevel: d.ImportanceLevel,
+			TransportMode:   t.TransportMode,
+			GroupOfLine:     t.GroupOfLine,
+			StopAreaName:    t.StopAreaName,
+		})
+	}
+
+	return devs, nil
+}
+
+// legs resolves Trip.legs.
+func (r *slResolvers) legs(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	return source.(*sl.Trip).LegList.Leg, nil
+}
+
+// legStops resolves Leg.stops, only included by the API when Passlist is 1.
+func (r *slResolvers) legStops(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	return source.(*sl.Leg).Stops.Stop, nil
+}
+
+// legDeviations resolves Leg.deviations from the leg messages.
+func (r *slResolvers) legDeviations(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	devs := []*deviation{}
+
+	for _, m := range source.(*sl.Leg).Messages.Message {
+		devs = append(devs, &deviation{
+			Text:            m.Text,
+			Consequence:     m.Head,
+			ImportanceLevel: m.Priority,
+		})
+	}
+
+	return devs, nil
+}
+
+// journeyRef resolves Leg.journeyRef.
+func (r *slResolvers) journeyRef(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	if ref := source.(*sl.Leg).JourneyDetailRef.Ref; len(ref) > 0 {
+		return ref, nil
+	}
+
+	return nil, nil
+}
+
+// legJourney resolves Leg.journey on the date of the leg origin.
+func (r *slResolvers) legJourney(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	l := source.(*sl.Leg)
+	if len(l.JourneyDetailRef.Ref) == 0 {
+		return nil, nil
+	}
+
+	return r.journeys.Load(ctx, journeyKey(l.JourneyDetailRef.Ref, l.Origin.Date)), nil
+}
+
+// journeyDirection resolves Journey.direction.
+func (r *slResolvers) journeyDirection(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	for _, d := range source.(*sl.Journey).Directions.Direction {
+		return d.Value, nil
+	}
+
+	return nil, nil
+}
+
+// journeyStops resolves Journey.stops.
+func (r *slResolvers) journeyStops(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+	return source.(*sl.Journey).Stops.Stop, nil
+}
+
+// departures returns the departures of a realtime response.
+func departures(resp *sl.RealtimeResponse) interface{} {
+	return resp.Departures()
+}
+
+// intArg returns the int argument name or zero.
+func intArg(args map[string]interface{}, name string) int {
+	n, _ := args[name].(int)
+	return n
+}
+
+// stringArg returns the string argument name or an empty string.
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
@@ -0,0 +1,167 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/frozzare/go-sl/sltest"
+)
+
+func requests(server *sltest.Server) map[string]int {
+	n := map[string]int{}
+	for _, r := range server.Requests() {
+		n[r.Endpoint]++
+	}
+	return n
+}
+
+func TestSchemaLocations(t *testing.T) {
+	server := sltest.NewServer()
+	defer server.Close()
+
+	schema := New(server.Client(), &Options{Key: "XXXX"})
+
+	resp := schema.Execute(context.Background(), &Params{Query: `{
+		locations(query: "s") {
+			siteId
+			name
+			lat
+			departures { lineNumber destination deviations { text } }
+			deviations { text }
+		}
+	}`})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("Expected no errors got %v", resp.Errors[0])
+	}
+
+	var data struct {
+		Locations []struct {
+			SiteID     string  `json:"siteId"`
+			Name       string  `json:"name"`
+			Lat        float64 `json:"lat"`
+			Departures []struct {
+				LineNumber string `json:"lineNumber"`
+			} `json:"departures"`
+		} `json:"locations"`
+	}
+
+	b, _ := json.Marshal(resp.Data)
+	if err := json.Unmarshal(b, &data); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if len(data.Locations) != 2 || data.Locations[0].SiteID != "9192" || data.Locations[0].Lat != 59.320284 {
+		t.Fatalf("Expected Slussen and T-Centralen got %s", b)
+	}
+
+	if len(data.Locations[1].Departures) == 0 || data.Locations[1].Departures[0].LineNumber != "11" {
+		t.Errorf("Expected line 11 got %s", b)
+	}
+
+	// Departures and deviations of a site share one realtime request.
+	n := requests(server)
+	if n[sltest.EndpointTypeahead] != 1 || n[sltest.EndpointRealtime] != 2 {
+		t.Errorf("Expected 1 typeahead and 2 realtime requests got %v", n)
+	}
+}
+
+func TestSchemaSite(t *testing.T) {
+	server := sltest.NewServer()
+	defer server.Close()
+
+	schema := New(server.Client(), &Options{RealtimeKey: "XXXX"})
+
+	got, errs := execute(t, schema, &Params{
+		Query:     `query ($id: ID!) { site(id: $id) { siteId name lat } a: departures(siteId: $id) { lineNumber } b: departures(siteId: $id) { destination } }`,
+		Variables: map[string]interface{}{"id": 9192},
+	})
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors got %v", errs[0])
+	}
+
+	want := `{"site":{"siteId":"9192","name":"T-Centralen","lat":null},"a":[{"lineNumber":"11"}],"b":[{"destination":"Akalla"}]}`
+	if got != want {
+		t.Errorf("Expected %s got %s", want, got)
+	}
+
+	if reqs := server.Requests(); len(reqs) != 1 || reqs[0].Query.Get("siteId") != "9192" {
+		t.Errorf("Expected one realtime request for 9192 got %v", reqs)
+	}
+
+	server.SetMessage(sltest.EndpointRealtime, "Invalid key")
+
+	got, errs = execute(t, schema, &Params{Query: `{ departures(siteId: 1002) { lineNumber } }`})
+	if len(errs) != 1 || errs[0].Message != "Invalid key" || got != `{"departures":null}` {
+		t.Errorf("Expected 'Invalid key' error got %s and %v", got, errs)
+	}
+}
+
+func TestSchemaTrips(t *testing.T) {
+	server := sltest.NewServer()
+	defer server.Close()
+
+	leg := `{"Origin":{"name":"Slussen","date":"2017-12-18"},"Destination":{"name":"T-Centralen"},"JourneyDetailRef":{"ref":"1|4455|1|74|18122017"},"type":"JNY"}`
+	server.SetFixture(sltest.EndpointTrip, `{"Trip":[{"idx":0,"LegList":{"Leg":[`+leg+`]}},{"idx":1,"LegList":{"Leg":[`+leg+`]}}]}`)
+
+	schema := New(server.Client(), &Options{Key: "XXXX"})
+
+	got, errs := execute(t, schema, &Params{Query: `{
+		trips(from: 9192, to: 9001, arrival: true) {
+			legs {
+				origin { name }
+				journeyRef
+				journey { stops { name } }
+			}
+		}
+	}`})
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors got %v", errs[0])
+	}
+
+	var data struct {
+		Trips []struct {
+			Legs []struct {
+				JourneyRef string `json:"journeyRef"`
+				Journey    struct {
+					Stops []struct {
+						Name string `json:"name"`
+					} `json:"stops"`
+				} `json:"journey"`
+			} `json:"legs"`
+		} `json:"trips"`
+	}
+
+	if err := json.Unmarshal([]byte(got), &data); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	legs := 0
+	for _, trip := range data.Trips {
+		for _, leg := range trip.Legs {
+			legs++
+			if leg.JourneyRef != "1|4455|1|74|18122017" || len(leg.Journey.Stops) == 0 || leg.Journey.Stops[0].Name != "Slussen" {
+				t.Errorf("Expected journey from Slussen got %+v", leg)
+			}
+		}
+	}
+
+	if legs != 2 {
+		t.Errorf("Expected legs got %s", got)
+	}
+
+	// Legs with the same journey reference share one journey request.
+	n := requests(server)
+	if n[sltest.EndpointTrip] != 1 || n[sltest.EndpointJourney] != 1 {
+		t.Errorf("Expected 1 trip and 1 journey request got %v", n)
+	}
+
+	reqs := server.Requests()
+	if q := reqs[0].Query; q.Get("originId") != "9192" || q.Get("destId") != "9001" || q.Get("searchForArrival") != "1" {
+		t.Errorf("Expected trip from 9192 to 9001 got %v", q)
+	}
+
+	if q := reqs[1].Query; q.Get("date") != "2017-12-18" {
+		t.Errorf("Expected journey on '2017-12-18' got %v", q)
+	}
+}
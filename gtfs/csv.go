@@ -0,0 +1,147 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayout is the layout of GTFS dates.
+const dateLayout = "20060102"
+
+// record represents a row in a GTFS table. Parse errors are kept in err so
+// a row can be read field by field and checked once.
+type record struct {
+	file   string
+	line   int
+	header map[string]int
+	fields []string
+	err    error
+}
+
+// readTable reads the rows of a GTFS table and calls parse for each row.
+func readTable(f *zip.File, parse func(*record) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+
+	// Skip the UTF-8 byte order mark some feeds start with.
+	if b, err := br.Peek(3); err == nil && string(b) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	names, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("gtfs: %s: %v", f.Name, err)
+	}
+
+	r := &record{file: f.Name, line: 1, header: map[string]int{}}
+	for i, name := range names {
+		r.header[strings.TrimSpace(name)] = i
+	}
+
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("gtfs: %s: %v", f.Name, err)
+		}
+
+		r.line++
+		r.fields = fields
+		r.err = nil
+
+		if err := parse(r); err != nil {
+			return err
+		}
+	}
+}
+
+// errorf returns an error with the file and line of the record.
+func (r *record) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("gtfs: %s:%d: %s", r.file, r.line, fmt.Sprintf(format, args...))
+}
+
+// get returns the value of the field or an empty string if the column is missing.
+func (r *record) get(name string) string {
+	i, ok := r.header[name]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[i])
+}
+
+// int returns the field as an int, zero if it is empty.
+func (r *record) int(name string) int {
+	s := r.get(name)
+	if len(s) == 0 {
+		return 0
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil && r.err == nil {
+		r.err = r.errorf("invalid %s %q", name, s)
+	}
+
+	return n
+}
+
+// float returns the field as a float64, zero if it is empty.
+func (r *record) float(name string) float64 {
+	s := r.get(name)
+	if len(s) == 0 {
+		return 0
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil && r.err == nil {
+		r.err = r.errorf("invalid %s %q", name, s)
+	}
+
+	return n
+}
+
+// time returns the field as a Time, -1 if it is empty.
+func (r *record) time(name string) Time {
+	s := r.get(name)
+	if len(s) == 0 {
+		return -1
+	}
+
+	t, err := ParseTime(s)
+	if err != nil && r.err == nil {
+		r.err = r.errorf("invalid %s %q", name, s)
+	}
+
+	return t
+}
+
+// date returns the field as a date in UTC.
+func (r *record) date(name string) time.Time {
+	s := r.get(name)
+
+	t, err := time.Parse(dateLayout, s)
+	if err != nil && r.err == nil {
+		r.err = r.errorf("invalid %s %q", name, s)
+	}
+
+	return t
+}
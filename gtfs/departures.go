@@ -0,0 +1,208 @@
+package gtfs
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+var (
+	ErrUnknownSite = errors.New("gtfs: unknown site")
+)
+
+// Location returns the time zone of the feed from agency.txt or UTC if the
+// time zone is missing or unknown.
+func (f *Feed) Location() *time.Location {
+	return f.loc
+}
+
+// location loads the time zone of the feed.
+func (f *Feed) location() *time.Location {
+	for _, a := range f.Agencies {
+		if len(a.Timezone) == 0 {
+			continue
+		}
+
+		if loc, err := time.LoadLocation(a.Timezone); err == nil {
+			return loc
+		}
+	}
+
+	return time.UTC
+}
+
+// ServiceActive reports whether the service runs on the date. Only the
+// year, month and day of date are used. Exceptions in calendar_dates.txt
+// take precedence over calendar.txt.
+func (f *Feed) ServiceActive(serviceID string, date time.Time) bool {
+	y, m, d := date.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	for _, cd := range f.CalendarDates[serviceID] {
+		if cd.Date.Equal(day) {
+			return cd.ExceptionType == ServiceAdded
+		}
+	}
+
+	c, ok := f.Calendars[serviceID]
+	if !ok {
+		return false
+	}
+
+	return c.Weekdays[day.Weekday()] && !day.Before(c.StartDate) && !day.After(c.EndDate)
+}
+
+// Departures returns the scheduled departures from the site within the
+// window starting at from, in the same shape as RealtimeService.Search so
+// they can be used when the realtime API is unavailable. ExpectedDateTime
+// equals TimeTabledDateTime and ScheduledOnly is set.
+//
+// Trips of the previous service day are included, so times past midnight
+// like 25:10:00 are departures the next day.
+func (f *Feed) Departures(siteID string, from time.Time, window time.Duration) ([]*sl.Transport, error) {
+	station := f.StopBySiteID(siteID)
+	if station == nil {
+		return nil, ErrUnknownSite
+	}
+
+	loc := f.Location()
+	from = from.In(loc)
+	to := from.Add(window)
+
+	type departure struct {
+		st   *StopTime
+		time time.Time
+	}
+
+	var deps []departure
+
+	y, m, d := from.Date()
+	for day := time.Date(y, m, d-1, 0, 0, 0, 0, loc); !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, stop := range stops(station) {
+			for _, st := range f.StopTimes(stop.ID) {
+				if !departs(st) || !f.ServiceActive(st.Trip.ServiceID, day) {
+					continue
+				}
+
+				t := st.Departure.On(day, loc)
+				if t.Before(from) || !t.Before(to) {
+					continue
+				}
+
+				deps = append(deps, departure{st, t})
+			}
+		}
+	}
+
+	sort.SliceStable(deps, func(i, j int) bool { return deps[i].time.Before(deps[j].time) })
+
+	transports := make([]*sl.Transport, 0, len(deps))
+	for _, dep := range deps {
+		transports = append(transports, transport(station, dep.st, dep.time))
+	}
+
+	return transports, nil
+}
+
+// RealtimeResponse returns the scheduled departures from the site grouped
+// by transport mode like a realtime API response.
+func (f *Feed) RealtimeResponse(siteID string, from time.Time, window time.Duration) (*sl.RealtimeResponse, error) {
+	transports, err := f.Departures(siteID, from, window)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &sl.RealtimeResponse{}
+	for _, t := range transports {
+		switch t.TransportMode {
+		case "METRO":
+			r.Metros = append(r.Metros, t)
+		case "TRAIN":
+			r.Trains = append(r.Trains, t)
+		case "TRAM":
+			r.Trams = append(r.Trams, t)
+		case "SHIP":
+			r.Ships = append(r.Ships, t)
+		default:
+			r.Buses = append(r.Buses, t)
+		}
+	}
+
+	return r, nil
+}
+
+// stops returns the station and all stops within it.
+func stops(station *Stop) []*Stop {
+	all := []*Stop{station}
+	for _, c := range station.Children {
+		all = append(all, stops(c)...)
+	}
+	return all
+}
+
+// departs reports whether passengers can board at the stop time, i.e. it
+// is not the last stop of the trip and pickup is not disabled.
+func departs(st *StopTime) bool {
+	sts := st.Trip.StopTimes
+	return st.PickupType != 1 && len(sts) > 0 && sts[len(sts)-1] != st
+}
+
+// transport returns the departure as a realtime API transport.
+func transport(station *Stop, st *StopTime, t time.Time) *sl.Transport {
+	trip := st.Trip
+	route := trip.Route
+
+	destination := st.Headsign
+	if len(destination) == 0 {
+		destination = trip.Headsign
+	}
+	if len(destination) == 0 {
+		destination = trip.StopTimes[len(trip.StopTimes)-1].Stop.Name
+	}
+
+	tr := &sl.Transport{
+		Destination:          destination,
+		DisplayTime:          t.Format("15:04"),
+		ExpectedDateTime:     t.Format(sl.RealtimeLayout),
+		TimeTabledDateTime:   t.Format(sl.RealtimeLayout),
+		GroupOfLine:          route.LongName,
+		JourneyDirection:     trip.DirectionID + 1,
+		LineNumber:           route.ShortName,
+		StopAreaName:         station.Name,
+		StopPointDesignation: st.Stop.PlatformCode,
+		TransportMode:        transportMode(route.Type),
+		ScheduledOnly:        true,
+	}
+
+	tr.JourneyNumber, _ = strconv.Atoi(trip.ShortName)
+
+	if id, ok := parseStopID(station.ID); ok {
+		tr.StopAreaNumber = id.number
+	}
+
+	if id, ok := parseStopID(st.StopID); ok && !id.area {
+		tr.StopPointNumber = id.number
+	}
+
+	return tr
+}
+
+// transportMode returns the realtime API transport mode of a basic or
+// extended GTFS route type.
+func transportMode(routeType int) string {
+	switch {
+	case routeType == 1 || routeType == 401 || routeType == 402:
+		return "METRO"
+	case routeType == 0 || (routeType >= 900 && routeType < 1000):
+		return "TRAM"
+	case routeType == 2 || (routeType >= 100 && routeType < 200) || routeType == 400 || routeType == 403:
+		return "TRAIN"
+	case routeType == 4 || (routeType >= 1000 && routeType < 1300):
+		return "SHIP"
+	default:
+		return "BUS"
+	}
+}
@@ -0,0 +1,104 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServiceActive(t *testing.T) {
+	feed := readFeed(t)
+
+	tests := []struct {
+		service string
+		date    time.Time
+		active  bool
+	}{
+		{"1", time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), true},
+		{"1", time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC), false},
+		{"1", time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC), false},
+		{"1", time.Date(2027, 1, 4, 0, 0, 0, 0, time.UTC), false},
+		{"2", time.Date(2026, 12, 25, 23, 0, 0, 0, time.UTC), true},
+		{"2", time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC), false},
+		{"3", time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		if got := feed.ServiceActive(tt.service, tt.date); got != tt.active {
+			t.Errorf("Expected service %s active %v on %s got %v", tt.service, tt.active, tt.date.Format("2006-01-02"), got)
+		}
+	}
+}
+
+func TestDepartures(t *testing.T) {
+	feed := readFeed(t)
+	loc := feed.Location()
+
+	if loc.String() != "Europe/Stockholm" {
+		t.Fatalf("Expected Europe/Stockholm got %s", loc)
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		want []string
+	}{
+		{"past midnight", time.Date(2026, 10, 20, 23, 55, 0, 0, loc), []string{"2026-10-21T00:06:00"}},
+		{"no service on saturday", time.Date(2026, 10, 24, 23, 55, 0, 0, loc), nil},
+		{"added service", time.Date(2026, 12, 25, 7, 50, 0, 0, loc), []string{"2026-12-25T08:00:00"}},
+		{"removed service", time.Date(2026, 12, 26, 0, 0, 0, 0, loc), nil},
+		{"previous service day", time.Date(2026, 12, 25, 0, 0, 0, 0, loc), []string{"2026-12-25T00:06:00"}},
+	}
+
+	for _, tt := range tests {
+		deps, err := feed.Departures("9192", tt.from, 30*time.Minute)
+		if err != nil {
+			t.Fatalf("%s: Expected nil got error: %v", tt.name, err)
+		}
+
+		var got []string
+		for _, d := range deps {
+			got = append(got, d.ExpectedDateTime)
+		}
+
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Errorf("%s: Expected %v got %v", tt.name, tt.want, got)
+		}
+	}
+
+	deps, _ := feed.Departures("9192", time.Date(2026, 10, 20, 23, 55, 0, 0, loc), time.Hour)
+	d := deps[0]
+
+	if !d.ScheduledOnly || d.ExpectedDateTime != d.TimeTabledDateTime || d.DisplayTime != "00:06" {
+		t.Errorf("Expected scheduled departure got %v", d)
+	}
+
+	if d.LineNumber != "17" || d.Destination != "Åkeshov" || d.TransportMode != "METRO" || d.JourneyDirection != 2 {
+		t.Errorf("Expected metro 17 to Åkeshov got %v", d)
+	}
+
+	if d.StopAreaNumber != 9192 || d.StopPointNumber != 9192 || d.StopAreaName != "Slussen" || d.StopPointDesignation != "1" {
+		t.Errorf("Expected departure from Slussen platform 1 got %v", d)
+	}
+
+	// The trips end at T-Centralen, so there are no departures from there.
+	if deps, _ := feed.Departures("1002", time.Date(2026, 12, 25, 7, 50, 0, 0, loc), time.Hour); len(deps) != 0 {
+		t.Errorf("Expected no departures from the last stop got %v", deps)
+	}
+
+	if _, err := feed.Departures("1", time.Now(), time.Hour); err != ErrUnknownSite {
+		t.Errorf("Expected %v got %v", ErrUnknownSite, err)
+	}
+}
+
+func TestRealtimeResponse(t *testing.T) {
+	feed := readFeed(t)
+
+	r, err := feed.RealtimeResponse("9192", time.Date(2026, 12, 25, 7, 50, 0, 0, feed.Location()), time.Hour)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if len(r.Metros) != 1 || len(r.Departures()) != 1 {
+		t.Errorf("Expected 1 metro departure got %v", r.Departures())
+	}
+}
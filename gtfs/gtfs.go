@@ -0,0 +1,585 @@
+// Package gtfs parses static GTFS feeds, like the GTFS Regional zip files
+// Trafiklab publishes for SL, into an indexed in-memory model.
+//
+//	feed, err := gtfs.Open("sl.zip")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	for _, st := range feed.StopTimes(stopID) {
+//		fmt.Println(st.Departure, st.Trip.Route.ShortName, st.Trip.Headsign)
+//	}
+package gtfs
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Location types of a stop.
+const (
+	LocationStop = iota
+	LocationStation
+	LocationEntrance
+	LocationGenericNode
+	LocationBoardingArea
+)
+
+// Exception types of a calendar date.
+const (
+	ServiceAdded   = 1
+	ServiceRemoved = 2
+)
+
+// Agency represents a row in agency.txt.
+type Agency struct {
+	ID       string
+	Name     string
+	URL      string
+	Timezone string
+	Lang     string
+	Phone    string
+}
+
+// Stop represents a row in stops.txt.
+type Stop struct {
+	ID                 string
+	Code               string
+	Name               string
+	Desc               string
+	Lat                float64
+	Lon                float64
+	ZoneID             string
+	URL                string
+	LocationType       int
+	ParentStation      string
+	Timezone           string
+	WheelchairBoarding int
+	PlatformCode       string
+
+	// Parent is the parent station and Children the stops of a station.
+	Parent   *Stop
+	Children []*Stop
+}
+
+// Station returns the parent station of the stop or the stop itself if it
+// has no parent.
+func (s *Stop) Station() *Stop {
+	for s.Parent != nil {
+		s = s.Parent
+	}
+	return s
+}
+
+// Route represents a row in routes.txt.
+type Route struct {
+	ID        string
+	AgencyID  string
+	ShortName string
+	LongName  string
+	Desc      string
+	Type      int
+	URL       string
+	Color     string
+	TextColor string
+
+	Agency *Agency
+}
+
+// Trip represents a row in trips.txt.
+type Trip struct {
+	ID          string
+	RouteID     string
+	ServiceID   string
+	Headsign    string
+	ShortName   string
+	DirectionID int
+	BlockID     string
+	ShapeID     string
+
+	Route *Route
+
+	// StopTimes of the trip ordered by stop sequence.
+	StopTimes []*StopTime
+}
+
+// StopTime represents a row in stop_times.txt. Missing arrival and
+// departure times are interpolated from the surrounding stops.
+type StopTime struct {
+	TripID            string
+	Arrival           Time
+	Departure         Time
+	StopID            string
+	StopSequence      int
+	Headsign          string
+	PickupType        int
+	DropOffType       int
+	ShapeDistTraveled float64
+	Timepoint         int
+
+	Trip *Trip
+	Stop *Stop
+}
+
+// Calendar represents a row in calendar.txt. Dates are in UTC.
+type Calendar struct {
+	ServiceID string
+
+	// Weekdays the service runs, indexed by time.Weekday.
+	Weekdays [7]bool
+
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// CalendarDate represents a row in calendar_dates.txt. Date is in UTC.
+type CalendarDate struct {
+	ServiceID     string
+	Date          time.Time
+	ExceptionType int
+}
+
+// Transfer represents a row in transfers.txt.
+type Transfer struct {
+	FromStopID      string
+	ToStopID        string
+	FromRouteID     string
+	ToRouteID       string
+	FromTripID      string
+	ToTripID        string
+	TransferType    int
+	MinTransferTime time.Duration
+}
+
+// Time is a GTFS time in seconds after midnight of the service day. It can
+// be 24:00:00 or later for trips running past midnight.
+type Time int
+
+// ParseTime parses a time in the H:MM:SS format.
+func ParseTime(s string) (Time, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+
+	var n [3]int
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 || (i > 0 && (len(p) != 2 || v > 59)) {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		n[i] = v
+	}
+
+	return Time(n[0]*3600 + n[1]*60 + n[2]), nil
+}
+
+// String returns the time in the HH:MM:SS format.
+func (t Time) String() string {
+	return fmt.Sprintf("%02d:%02d:%02d", t/3600, t/60%60, t%60)
+}
+
+// Duration returns the time as a duration since midnight.
+func (t Time) Duration() time.Duration {
+	return time.Duration(t) * time.Second
+}
+
+// On returns the time on the service day of date in loc. GTFS times are
+// relative to noon minus 12 hours, which differs from midnight on days
+// with a daylight saving time change.
+func (t Time) On(date time.Time, loc *time.Location) time.Time {
+	y, m, d := date.Date()
+	noon := time.Date(y, m, d, 12, 0, 0, 0, loc)
+	return noon.Add(-12 * time.Hour).Add(t.Duration())
+}
+
+// Feed represents a parsed GTFS feed.
+type Feed struct {
+	Agencies      map[string]*Agency
+	Stops         map[string]*Stop
+	Routes        map[string]*Route
+	Trips         map[string]*Trip
+	Calendars     map[string]*Calendar
+	CalendarDates map[string][]*CalendarDate
+	Transfers     []*Transfer
+
+	stopTimes    map[string][]*StopTime
+	routeTrips   map[string][]*Trip
+	transfers    map[string][]*Transfer
+	siteStations map[string]*Stop
+	extStops     map[string]*Stop
+	loc          *time.Location
+}
+
+// Open parses the GTFS zip file at path.
+func Open(path string) (*Feed, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return Read(f, fi.Size())
+}
+
+// Read parses a GTFS zip file.
+func Read(r io.ReaderAt, size int64) (*Feed, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		// Some feeds put the files in a directory.
+		name := f.Name
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+		files[name] = f
+	}
+
+	feed := &Feed{
+		Agencies:      map[string]*Agency{},
+		Stops:         map[string]*Stop{},
+		Routes:        map[string]*Route{},
+		Trips:         map[string]*Trip{},
+		Calendars:     map[string]*Calendar{},
+		CalendarDates: map[string][]*CalendarDate{},
+		stopTimes:     map[string][]*StopTime{},
+		routeTrips:    map[string][]*Trip{},
+		transfers:     map[string][]*Transfer{},
+		siteStations:  map[string]*Stop{},
+		extStops:      map[string]*Stop{},
+	}
+
+	tables := []struct {
+		name     string
+		required bool
+		parse    func(*record) error
+	}{
+		{"agency.txt", true, feed.parseAgency},
+		{"stops.txt", true, feed.parseStop},
+		{"routes.txt", true, feed.parseRoute},
+		{"trips.txt", true, feed.parseTrip},
+		{"stop_times.txt", true, feed.parseStopTime},
+		{"calendar.txt", false, feed.parseCalendar},
+		{"calendar_dates.txt", false, feed.parseCalendarDate},
+		{"transfers.txt", false, feed.parseTransfer},
+	}
+
+	for _, t := range tables {
+		f, ok := files[t.name]
+		if !ok {
+			if t.required {
+				return nil, fmt.Errorf("gtfs: missing %s", t.name)
+			}
+			continue
+		}
+
+		if err := readTable(f, t.parse); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := feed.index(); err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// StopTimes returns the stop times at the stop ordered by departure.
+func (f *Feed) StopTimes(stopID string) []*StopTime {
+	return f.stopTimes[stopID]
+}
+
+// RouteTrips returns the trips of the route.
+func (f *Feed) RouteTrips(routeID string) []*Trip {
+	return f.routeTrips[routeID]
+}
+
+// TransfersFrom returns the transfers from the stop.
+func (f *Feed) TransfersFrom(stopID string) []*Transfer {
+	return f.transfers[stopID]
+}
+
+// index links the parsed rows and builds the indexes.
+func (f *Feed) index() error {
+	f.loc = f.location()
+
+	for _, s := range f.Stops {
+		if len(s.ParentStation) == 0 {
+			continue
+		}
+
+		p, ok := f.Stops[s.ParentStation]
+		if !ok {
+			return fmt.Errorf("gtfs: stop %s: unknown parent station %s", s.ID, s.ParentStation)
+		}
+
+		s.Parent = p
+		p.Children = append(p.Children, s)
+	}
+
+	for _, s := range f.Stops {
+		sort.Slice(s.Children, func(i, j int) bool { return s.Children[i].ID < s.Children[j].ID })
+
+		id, ok := parseStopID(s.ID)
+		if !ok {
+			continue
+		}
+
+		f.extStops[id.extID()] = s
+		if id.area {
+			f.siteStations[id.siteID()] = s
+		}
+	}
+
+	for _, r := range f.Routes {
+		if len(r.AgencyID) == 0 && len(f.Agencies) == 1 {
+			for _, a := range f.Agencies {
+				r.Agency = a
+			}
+			continue
+		}
+
+		r.Agency = f.Agencies[r.AgencyID]
+	}
+
+	for _, t := range f.Trips {
+		sort.Slice(t.StopTimes, func(i, j int) bool {
+			return t.StopTimes[i].StopSequence < t.StopTimes[j].StopSequence
+		})
+
+		if err := interpolate(t); err != nil {
+			return err
+		}
+
+		f.routeTrips[t.RouteID] = append(f.routeTrips[t.RouteID], t)
+	}
+
+	for _, sts := range f.stopTimes {
+		sort.Slice(sts, func(i, j int) bool { return sts[i].Departure < sts[j].Departure })
+	}
+
+	for _, t := range f.Transfers {
+		f.transfers[t.FromStopID] = append(f.transfers[t.FromStopID], t)
+	}
+
+	return nil
+}
+
+// interpolate fills in missing arrival and departure times of a trip,
+// marked with -1, from the surrounding stops.
+func interpolate(t *Trip) error {
+	sts := t.StopTimes
+	if len(sts) == 0 {
+		return nil
+	}
+
+	if sts[0].Departure < 0 || sts[len(sts)-1].Arrival < 0 {
+		return fmt.Errorf("gtfs: trip %s: missing time at first or last stop", t.ID)
+	}
+
+	prev := 0
+	for i := 1; i < len(sts); i++ {
+		if sts[i].Arrival < 0 {
+			continue
+		}
+
+		start := sts[prev].Departure
+		n := Time(i - prev)
+		for j := prev + 1; j < i; j++ {
+			v := start + (sts[i].Arrival-start)*Time(j-prev)/n
+			sts[j].Arrival, sts[j].Departure = v, v
+		}
+
+		prev = i
+	}
+
+	return nil
+}
+
+func (f *Feed) parseAgency(r *record) error {
+	a := &Agency{
+		ID:       r.get("agency_id"),
+		Name:     r.get("agency_name"),
+		URL:      r.get("agency_url"),
+		Timezone: r.get("agency_timezone"),
+		Lang:     r.get("agency_lang"),
+		Phone:    r.get("agency_phone"),
+	}
+
+	f.Agencies[a.ID] = a
+
+	return nil
+}
+
+func (f *Feed) parseStop(r *record) error {
+	s := &Stop{
+		ID:                 r.get("stop_id"),
+		Code:               r.get("stop_code"),
+		Name:               r.get("stop_name"),
+		Desc:               r.get("stop_desc"),
+		Lat:                r.float("stop_lat"),
+		Lon:                r.float("stop_lon"),
+		ZoneID:             r.get("zone_id"),
+		URL:                r.get("stop_url"),
+		LocationType:       r.int("location_type"),
+		ParentStation:      r.get("parent_station"),
+		Timezone:           r.get("stop_timezone"),
+		WheelchairBoarding: r.int("wheelchair_boarding"),
+		PlatformCode:       r.get("platform_code"),
+	}
+
+	if len(s.ID) == 0 {
+		return r.errorf("missing stop_id")
+	}
+
+	f.Stops[s.ID] = s
+
+	return r.err
+}
+
+func (f *Feed) parseRoute(r *record) error {
+	route := &Route{
+		ID:        r.get("route_id"),
+		AgencyID:  r.get("agency_id"),
+		ShortName: r.get("route_short_name"),
+		LongName:  r.get("route_long_name"),
+		Desc:      r.get("route_desc"),
+		Type:      r.int("route_type"),
+		URL:       r.get("route_url"),
+		Color:     r.get("route_color"),
+		TextColor: r.get("route_text_color"),
+	}
+
+	if len(route.ID) == 0 {
+		return r.errorf("missing route_id")
+	}
+
+	f.Routes[route.ID] = route
+
+	return r.err
+}
+
+func (f *Feed) parseTrip(r *record) error {
+	t := &Trip{
+		ID:          r.get("trip_id"),
+		RouteID:     r.get("route_id"),
+		ServiceID:   r.get("service_id"),
+		Headsign:    r.get("trip_headsign"),
+		ShortName:   r.get("trip_short_name"),
+		DirectionID: r.int("direction_id"),
+		BlockID:     r.get("block_id"),
+		ShapeID:     r.get("shape_id"),
+	}
+
+	route, ok := f.Routes[t.RouteID]
+	if !ok {
+		return r.errorf("trip %s: unknown route %s", t.ID, t.RouteID)
+	}
+
+	t.Route = route
+	f.Trips[t.ID] = t
+
+	return r.err
+}
+
+func (f *Feed) parseStopTime(r *record) error {
+	st := &StopTime{
+		TripID:            r.get("trip_id"),
+		Arrival:           r.time("arrival_time"),
+		Departure:         r.time("departure_time"),
+		StopID:            r.get("stop_id"),
+		StopSequence:      r.int("stop_sequence"),
+		Headsign:          r.get("stop_headsign"),
+		PickupType:        r.int("pickup_type"),
+		DropOffType:       r.int("drop_off_type"),
+		ShapeDistTraveled: r.float("shape_dist_traveled"),
+		Timepoint:         r.int("timepoint"),
+	}
+
+	if st.Arrival < 0 {
+		st.Arrival = st.Departure
+	}
+
+	if st.Departure < 0 {
+		st.Departure = st.Arrival
+	}
+
+	trip, ok := f.Trips[st.TripID]
+	if !ok {
+		return r.errorf("unknown trip %s", st.TripID)
+	}
+
+	stop, ok := f.Stops[st.StopID]
+	if !ok {
+		return r.errorf("unknown stop %s", st.StopID)
+	}
+
+	st.Trip = trip
+	st.Stop = stop
+	trip.StopTimes = append(trip.StopTimes, st)
+	f.stopTimes[st.StopID] = append(f.stopTimes[st.StopID], st)
+
+	return r.err
+}
+
+func (f *Feed) parseCalendar(r *record) error {
+	c := &Calendar{
+		ServiceID: r.get("service_id"),
+		StartDate: r.date("start_date"),
+		EndDate:   r.date("end_date"),
+	}
+
+	days := []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+	for i, d := range days {
+		c.Weekdays[i] = r.int(d) == 1
+	}
+
+	f.Calendars[c.ServiceID] = c
+
+	return r.err
+}
+
+func (f *Feed) parseCalendarDate(r *record) error {
+	d := &CalendarDate{
+		ServiceID:     r.get("service_id"),
+		Date:          r.date("date"),
+		ExceptionType: r.int("exception_type"),
+	}
+
+	f.CalendarDates[d.ServiceID] = append(f.CalendarDates[d.ServiceID], d)
+
+	return r.err
+}
+
+func (f *Feed) parseTransfer(r *record) error {
+	t := &Transfer{
+		FromStopID:      r.get("from_stop_id"),
+		ToStopID:        r.get("to_stop_id"),
+		FromRouteID:     r.get("from_route_id"),
+		ToRouteID:       r.get("to_route_id"),
+		FromTripID:      r.get("from_trip_id"),
+		ToTripID:        r.get("to_trip_id"),
+		TransferType:    r.int("transfer_type"),
+		MinTransferTime: time.Duration(r.int("min_transfer_time")) * time.Second,
+	}
+
+	f.Transfers = append(f.Transfers, t)
+
+	return r.err
+}
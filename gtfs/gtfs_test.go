@@ -0,0 +1,252 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFeed = map[string]string{
+	"agency.txt": "\xef\xbb\xbfagency_id,agency_name,agency_url,agency_timezone\n" +
+		"14010000000001001,SL,https://sl.se,Europe/Stockholm\n",
+	"stops.txt": "stop_id,stop_name,stop_lat,stop_lon,location_type,parent_station,platform_code\n" +
+		"9021001009192000,Slussen,59.319511,18.071491,1,,\n" +
+		"9022001009192001,Slussen,59.319601,18.072011,0,9021001009192000,1\n" +
+		"9021001001002000,T-Centralen,59.331358,18.061477,1,,\n" +
+		"9022001001002001,T-Centralen,59.331402,18.061201,0,9021001001002000,1\n" +
+		"9021001001000000,Gamla stan,59.323089,18.067556,1,,\n" +
+		"9022001001000001,Gamla stan,59.323108,18.067801,0,9021001001000000,1\n",
+	"routes.txt": "route_id,agency_id,route_short_name,route_long_name,route_type\n" +
+		"9011001001700000,14010000000001001,17,,401\n",
+	"trips.txt": "route_id,service_id,trip_id,trip_headsign,direction_id\n" +
+		"9011001001700000,1,14010000600000001,Åkeshov,1\n" +
+		"9011001001700000,2,14010000600000002,Åkeshov,1\n",
+	"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\n" +
+		"14010000600000001,24:10:00,24:10:30,9022001001002001,3\n" +
+		"14010000600000001,,,9022001001000001,2\n" +
+		"14010000600000001,24:06:00,24:06:00,9022001009192001,1\n" +
+		"14010000600000002,08:00:00,08:00:00,9022001009192001,1\n" +
+		"14010000600000002,08:04:00,08:04:00,9022001001002001,2\n",
+	"calendar.txt": "service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n" +
+		"1,1,1,1,1,1,0,0,20261001,20261231\n",
+	"calendar_dates.txt": "service_id,date,exception_type\n" +
+		"1,20261225,2\n" +
+		"2,20261225,1\n",
+	"transfers.txt": "from_stop_id,to_stop_id,transfer_type,min_transfer_time\n" +
+		"9022001009192001,9022001009192001,2,120\n",
+}
+
+func zipFeed(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func readFeed(t *testing.T) *Feed {
+	b := zipFeed(t, testFeed)
+
+	feed, err := Read(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	return feed
+}
+
+func TestRead(t *testing.T) {
+	feed := readFeed(t)
+
+	if len(feed.Agencies) != 1 || len(feed.Stops) != 6 || len(feed.Routes) != 1 || len(feed.Trips) != 2 {
+		t.Fatalf("Expected all rows to be parsed got %d agencies, %d stops, %d routes and %d trips",
+			len(feed.Agencies), len(feed.Stops), len(feed.Routes), len(feed.Trips))
+	}
+
+	route := feed.Routes["9011001001700000"]
+	if route.Agency == nil || route.Agency.Name != "SL" {
+		t.Errorf("Expected route agency SL got %v", route.Agency)
+	}
+
+	if trips := feed.RouteTrips(route.ID); len(trips) != 2 {
+		t.Errorf("Expected 2 route trips got %d", len(trips))
+	}
+
+	trip := feed.Trips["14010000600000001"]
+	if trip.Route != route || trip.Headsign != "Åkeshov" {
+		t.Errorf("Expected trip on route 17 to Åkeshov got %v", trip)
+	}
+
+	var got []string
+	for _, st := range trip.StopTimes {
+		got = append(got, st.Stop.Name+" "+st.Arrival.String()+" "+st.Departure.String())
+	}
+
+	want := "Slussen 24:06:00 24:06:00,Gamla stan 24:08:00 24:08:00,T-Centralen 24:10:00 24:10:30"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Expected %s got %s", want, strings.Join(got, ","))
+	}
+
+	sts := feed.StopTimes("9022001009192001")
+	if len(sts) != 2 || sts[0].TripID != "14010000600000002" {
+		t.Errorf("Expected stop times ordered by departure got %v", sts)
+	}
+
+	platform := feed.Stops["9022001009192001"]
+	if platform.Station().ID != "9021001009192000" || len(platform.Station().Children) != 1 {
+		t.Errorf("Expected parent station Slussen got %v", platform.Parent)
+	}
+
+	c := feed.Calendars["1"]
+	if c == nil || !c.Weekdays[time.Monday] || c.Weekdays[time.Sunday] || c.EndDate != time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("Expected weekday calendar got %v", c)
+	}
+
+	if d := feed.CalendarDates["2"]; len(d) != 1 || d[0].ExceptionType != ServiceAdded {
+		t.Errorf("Expected added calendar date got %v", d)
+	}
+
+	if tr := feed.TransfersFrom("9022001009192001"); len(tr) != 1 || tr[0].MinTransferTime != 2*time.Minute {
+		t.Errorf("Expected transfer with 2 minutes min transfer time got %v", tr)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"missing stop_times.txt": {"stop_times.txt": ""},
+		"unknown stop":           {"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\n14010000600000002,08:00:00,08:00:00,1,1\n"},
+		"invalid time":           {"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\n14010000600000002,8:0:00,08:00:00,9022001009192001,1\n"},
+	}
+
+	for name, override := range tests {
+		files := map[string]string{}
+		for k, v := range testFeed {
+			files[k] = v
+		}
+		for k, v := range override {
+			if len(v) == 0 {
+				delete(files, k)
+				continue
+			}
+			files[k] = v
+		}
+
+		b := zipFeed(t, files)
+		if _, err := Read(bytes.NewReader(b), int64(len(b))); err == nil {
+			t.Errorf("%s: Expected error got nil", name)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gtfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sl.zip")
+	if err := ioutil.WriteFile(path, zipFeed(t, testFeed), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if len(feed.Stops) != 6 {
+		t.Errorf("Expected 6 stops got %d", len(feed.Stops))
+	}
+}
+
+func TestTime(t *testing.T) {
+	tm, err := ParseTime("25:10:05")
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if tm.String() != "25:10:05" || tm.Duration() != 25*time.Hour+10*time.Minute+5*time.Second {
+		t.Errorf("Expected 25:10:05 got %s", tm)
+	}
+
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+
+	// Daylight saving time ends on 25 October 2026, so the service day
+	// starts at 01:00 summer time and 03:00:00 is 02:00 UTC.
+	got := Time(3*3600).On(time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC), loc)
+	if want := time.Date(2026, 10, 25, 2, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Expected %s got %s", want, got)
+	}
+
+	for _, s := range []string{"", "8", "08:60:00", "08:0:00", "x:00:00"} {
+		if _, err := ParseTime(s); err == nil {
+			t.Errorf("Expected error for %q got nil", s)
+		}
+	}
+}
+
+func TestStopIDs(t *testing.T) {
+	feed := readFeed(t)
+
+	tests := []struct {
+		stopID string
+		siteID string
+		extID  string
+	}{
+		{"9021001009192000", "9192", "300109192"},
+		{"9022001009192001", "9192", "400109192"},
+		{"9022001001002001", "1002", "400101002"},
+	}
+
+	for _, tt := range tests {
+		if got, ok := feed.SiteID(tt.stopID); !ok || got != tt.siteID {
+			t.Errorf("Expected site id %s for %s got %s", tt.siteID, tt.stopID, got)
+		}
+
+		if got, ok := feed.ExtID(tt.stopID); !ok || got != tt.extID {
+			t.Errorf("Expected ext id %s for %s got %s", tt.extID, tt.stopID, got)
+		}
+	}
+
+	if _, ok := feed.SiteID("1"); ok {
+		t.Errorf("Expected no site id for unknown stop")
+	}
+
+	if s := feed.StopBySiteID("9192"); s == nil || s.ID != "9021001009192000" {
+		t.Errorf("Expected Slussen got %v", s)
+	}
+
+	if s := feed.StopByExtID("300101002"); s == nil || s.Name != "T-Centralen" {
+		t.Errorf("Expected T-Centralen got %v", s)
+	}
+}
+
+func TestLocations(t *testing.T) {
+	locations := readFeed(t).Locations()
+
+	if len(locations) != 3 {
+		t.Fatalf("Expected 3 locations got %d", len(locations))
+	}
+
+	l := locations[2]
+	if l.SiteID != "9192" || l.Name != "Slussen" || l.Type != "Station" || l.X != "18071491" || l.Y != "59319511" {
+		t.Errorf("Expected Slussen at 18071491, 59319511 got %+v", l)
+	}
+}
@@ -0,0 +1,122 @@
+package gtfs
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/frozzare/go-sl"
+)
+
+// stopID represents a parsed Trafiklab GTFS stop id. Stop areas have ids
+// like 9021001009192000 and stop points like 9022001010307001, made up of a
+// 9021 or 9022 prefix, a three digit region code, a six digit number and a
+// three digit suffix.
+type stopID struct {
+	area   bool
+	region string
+	number int
+}
+
+// parseStopID parses a Trafiklab GTFS stop id.
+func parseStopID(s string) (stopID, bool) {
+	if len(s) != 16 || (s[:4] != "9021" && s[:4] != "9022") {
+		return stopID{}, false
+	}
+
+	n, err := strconv.Atoi(s[7:13])
+	if err != nil || n < 0 || n > 99999 {
+		return stopID{}, false
+	}
+
+	return stopID{
+		area:   s[:4] == "9021",
+		region: s[4:7],
+		number: n,
+	}, true
+}
+
+// siteID returns the site id used by Location and Transport.
+func (id stopID) siteID() string {
+	return strconv.Itoa(id.number)
+}
+
+// extID returns the external id used by the travel planner, like
+// 300109192 for a stop area and 400110307 for a stop point.
+func (id stopID) extID() string {
+	prefix := "4"
+	if id.area {
+		prefix = "3"
+	}
+	return fmt.Sprintf("%s%s%05d", prefix, id.region, id.number)
+}
+
+// SiteID returns the site id, as used by Location.SiteID and
+// RealtimeSearchOptions.SiteID, of the station of the GTFS stop. False is returned if
+// the stop is unknown or has no site id.
+func (f *Feed) SiteID(stopID string) (string, bool) {
+	s, ok := f.Stops[stopID]
+	if !ok {
+		return "", false
+	}
+
+	id, ok := parseStopID(s.Station().ID)
+	if !ok || !id.area {
+		return "", false
+	}
+
+	return id.siteID(), true
+}
+
+// ExtID returns the external id of the GTFS stop as used by the travel
+// planner in LegStop.ExtID and Stop.ExtID. False is returned if the stop
+// is unknown or has no external id.
+func (f *Feed) ExtID(stopID string) (string, bool) {
+	if _, ok := f.Stops[stopID]; !ok {
+		return "", false
+	}
+
+	id, ok := parseStopID(stopID)
+	if !ok {
+		return "", false
+	}
+
+	return id.extID(), true
+}
+
+// StopBySiteID returns the station with the site id or nil. The site id of
+// a Transport is its StopAreaNumber.
+func (f *Feed) StopBySiteID(siteID string) *Stop {
+	return f.siteStations[siteID]
+}
+
+// StopByExtID returns the stop or station with the travel planner
+// external id, e.g. LegStop.ExtID or LegStop.MainMastExtID, or nil.
+func (f *Feed) StopByExtID(extID string) *Stop {
+	return f.extStops[extID]
+}
+
+// Locations returns the stations with a site id as locations ordered by
+// site id, e.g. to add to the local index of a sl.Resolver.
+func (f *Feed) Locations() []*sl.Location {
+	locations := make([]*sl.Location, 0, len(f.siteStations))
+
+	for siteID, s := range f.siteStations {
+		locations = append(locations, &sl.Location{
+			Name:   s.Name,
+			SiteID: siteID,
+			Type:   "Station",
+			X:      strconv.Itoa(int(math.Round(s.Lon * 1e6))),
+			Y:      strconv.Itoa(int(math.Round(s.Lat * 1e6))),
+		})
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		a, _ := strconv.Atoi(locations[i].SiteID)
+		b, _ := strconv.Atoi(locations[j].SiteID)
+		return a < b
+	})
+
+	return locations
+}
@@ -0,0 +1,149 @@
+package gtfs
+
+import (
+	"github.com/frozzare/go-sl"
+)
+
+// TripUpdate represents a realtime trip update joined with the static trip.
+type TripUpdate struct {
+	*sl.TripUpdate
+
+	// Trip and Route are nil if the trip is not in the static feed.
+	Trip  *Trip
+	Route *Route
+
+	// StopTimeUpdates joined with the static stops and stop times.
+	StopTimeUpdates []*StopTimeUpdate
+}
+
+// StopTimeUpdate represents a realtime stop time update joined with the
+// static stop and scheduled stop time.
+type StopTimeUpdate struct {
+	*sl.StopTimeUpdate
+
+	// Stop and StopTime are nil if they are not in the static feed.
+	Stop     *Stop
+	StopTime *StopTime
+}
+
+// Vehicle represents a realtime vehicle position joined with the static
+// trip, route and stop.
+type Vehicle struct {
+	*sl.VehiclePosition
+
+	// Trip, Route and Stop are nil if they are not in the static feed.
+	Trip  *Trip
+	Route *Route
+	Stop  *Stop
+}
+
+// Complete sets the route and direction of trip descriptors in the feed
+// that only have a trip id, so the feed can be filtered by route with
+// FeedMessage.FilterRoutes.
+func (f *Feed) Complete(m *sl.FeedMessage) {
+	complete := func(d *sl.TripDescriptor) {
+		if d == nil || len(d.RouteID) > 0 {
+			return
+		}
+
+		if t, ok := f.Trips[d.TripID]; ok {
+			d.RouteID = t.RouteID
+			d.DirectionID = t.DirectionID
+		}
+	}
+
+	for _, e := range m.Entity {
+		switch {
+		case e.TripUpdate != nil:
+			complete(e.TripUpdate.Trip)
+		case e.Vehicle != nil:
+			complete(e.Vehicle.Trip)
+		case e.Alert != nil:
+			for _, s := range e.Alert.InformedEntity {
+				complete(s.Trip)
+			}
+		}
+	}
+}
+
+// TripUpdates returns the trip updates in the feed joined with the static feed.
+func (f *Feed) TripUpdates(m *sl.FeedMessage) []*TripUpdate {
+	var updates []*TripUpdate
+
+	for _, e := range m.Entity {
+		if e.TripUpdate == nil || e.IsDeleted {
+			continue
+		}
+
+		u := &TripUpdate{TripUpdate: e.TripUpdate}
+		u.Trip, u.Route = f.trip(e.TripUpdate.Trip)
+
+		for _, stu := range e.TripUpdate.StopTimeUpdate {
+			su := &StopTimeUpdate{StopTimeUpdate: stu, StopTime: stopTime(u.Trip, stu)}
+			if su.StopTime != nil {
+				su.Stop = su.StopTime.Stop
+			} else {
+				su.Stop = f.Stops[stu.StopID]
+			}
+
+			u.StopTimeUpdates = append(u.StopTimeUpdates, su)
+		}
+
+		updates = append(updates, u)
+	}
+
+	return updates
+}
+
+// Vehicles returns the vehicle positions in the feed joined with the static feed.
+func (f *Feed) Vehicles(m *sl.FeedMessage) []*Vehicle {
+	var vehicles []*Vehicle
+
+	for _, e := range m.Entity {
+		if e.Vehicle == nil || e.IsDeleted {
+			continue
+		}
+
+		v := &Vehicle{VehiclePosition: e.Vehicle, Stop: f.Stops[e.Vehicle.StopID]}
+		v.Trip, v.Route = f.trip(e.Vehicle.Trip)
+
+		vehicles = append(vehicles, v)
+	}
+
+	return vehicles
+}
+
+// trip returns the static trip and route of a trip descriptor.
+func (f *Feed) trip(d *sl.TripDescriptor) (*Trip, *Route) {
+	if d == nil {
+		return nil, nil
+	}
+
+	if t, ok := f.Trips[d.TripID]; ok {
+		return t, t.Route
+	}
+
+	return nil, f.Routes[d.RouteID]
+}
+
+// stopTime returns the scheduled stop time of a stop time update, matched
+// by stop sequence or else stop id.
+func stopTime(t *Trip, u *sl.StopTimeUpdate) *StopTime {
+	if t == nil {
+		return nil
+	}
+
+	for _, st := range t.StopTimes {
+		if u.StopSequence > 0 && st.StopSequence == u.StopSequence {
+			return st
+		}
+	}
+
+	for _, st := range t.StopTimes {
+		if len(u.StopID) > 0 && st.StopID == u.StopID {
+			return st
+		}
+	}
+
+	return nil
+}
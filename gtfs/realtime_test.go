@@ -0,0 +1,90 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/frozzare/go-sl"
+)
+
+func testFeedMessage() *sl.FeedMessage {
+	return &sl.FeedMessage{
+		Header: &sl.FeedHeader{GTFSRealtimeVersion: "2.0"},
+		Entity: []*sl.FeedEntity{
+			{
+				ID: "1",
+				TripUpdate: &sl.TripUpdate{
+					Trip: &sl.TripDescriptor{TripID: "14010000600000001"},
+					StopTimeUpdate: []*sl.StopTimeUpdate{
+						{StopSequence: 2},
+						{StopID: "9022001001002001"},
+					},
+				},
+			},
+			{
+				ID: "2",
+				Vehicle: &sl.VehiclePosition{
+					Trip:   &sl.TripDescriptor{TripID: "14010000600000002"},
+					StopID: "9022001001002001",
+				},
+			},
+			{
+				ID:      "3",
+				Vehicle: &sl.VehiclePosition{Trip: &sl.TripDescriptor{TripID: "unknown"}},
+			},
+		},
+	}
+}
+
+func TestComplete(t *testing.T) {
+	feed := readFeed(t)
+	m := testFeedMessage()
+
+	feed.Complete(m)
+
+	if f := m.FilterRoutes("9011001001700000"); len(f.Entity) != 2 {
+		t.Errorf("Expected 2 entities on route 17 got %d", len(f.Entity))
+	}
+
+	if d := m.Entity[0].TripUpdate.Trip; d.DirectionID != 1 {
+		t.Errorf("Expected direction 1 got %d", d.DirectionID)
+	}
+}
+
+func TestTripUpdates(t *testing.T) {
+	feed := readFeed(t)
+
+	updates := feed.TripUpdates(testFeedMessage())
+	if len(updates) != 1 {
+		t.Fatalf("Expected 1 trip update got %d", len(updates))
+	}
+
+	u := updates[0]
+	if u.Trip == nil || u.Route == nil || u.Route.ShortName != "17" {
+		t.Fatalf("Expected trip on route 17 got %v", u.Trip)
+	}
+
+	if s := u.StopTimeUpdates[0]; s.StopTime == nil || s.Stop.Name != "Gamla stan" {
+		t.Errorf("Expected Gamla stan by stop sequence got %v", s.Stop)
+	}
+
+	if s := u.StopTimeUpdates[1]; s.StopTime == nil || s.StopTime.Departure.String() != "24:10:30" {
+		t.Errorf("Expected T-Centralen by stop id got %v", s.StopTime)
+	}
+}
+
+func TestVehicles(t *testing.T) {
+	feed := readFeed(t)
+
+	vehicles := feed.Vehicles(testFeedMessage())
+	if len(vehicles) != 2 {
+		t.Fatalf("Expected 2 vehicles got %d", len(vehicles))
+	}
+
+	if v := vehicles[0]; v.Trip == nil || v.Stop == nil || v.Stop.Name != "T-Centralen" {
+		t.Errorf("Expected vehicle heading to T-Centralen got %v", v.Stop)
+	}
+
+	if v := vehicles[1]; v.Trip != nil || v.Route != nil {
+		t.Errorf("Expected unknown trip got %v", v.Trip)
+	}
+}
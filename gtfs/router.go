@@ -0,0 +1,1003 @@
+package gtfs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+var (
+	ErrNoOrigin      = errors.New("gtfs: unknown origin")
+	ErrNoDestination = errors.New("gtfs: unknown destination")
+)
+
+const (
+	// maxChanges is the max number of changes, as in the travel planner api.
+	maxChanges = 11
+
+	// defaultNumTrips is the default number of trips, as in the travel planner api.
+	defaultNumTrips = 5
+
+	// searchWindow is how far before the arrival time arrive-by trips are searched
+	// and how far after the departure time further depart-at trips are searched.
+	searchWindow = 6 * 60 * 60
+
+	// maxDays is the number of search dates trip instances are kept for.
+	maxDays = 3
+
+	// infinity is the arrival time at stops that can't be reached.
+	infinity = math.MaxInt32
+)
+
+// RouterOptions specifies optional parameters to NewRouter.
+type RouterOptions struct {
+	// Max distance in meters between stops to walk when changing. Default is 400 meters.
+	MaxWalkDistance float64
+
+	// Walking speed in meters per second. Default is 1.2.
+	WalkSpeed float64
+}
+
+// Router plans trips over a feed with the RAPTOR algorithm. It is safe for
+// concurrent use.
+type Router struct {
+	feed *Feed
+	opt  RouterOptions
+	loc  *time.Location
+
+	// Stops that trips stop at, indexed by node.
+	nodes []*Stop
+	node  map[string]int
+
+	patterns     []*pattern
+	stopPatterns [][]patternStop
+	footpaths    [][]footpath
+
+	// Change times in seconds within stops from transfers.txt, indexed by node.
+	changes []int
+
+	mu       sync.Mutex
+	days     map[string][][]instance
+	dayOrder []string
+}
+
+// pattern represents the trips of a route with the same sequence of stops.
+type pattern struct {
+	route *Route
+	stops []int
+	trips []*Trip
+}
+
+// patternStop represents a stop at an index of a pattern.
+type patternStop struct {
+	pattern int
+	idx     int
+}
+
+// footpath represents a walking transfer to another stop.
+type footpath struct {
+	to       int
+	duration int
+}
+
+// instance represents a trip on a service day, with times offset from the
+// service day of the search.
+type instance struct {
+	trip   *Trip
+	offset int
+}
+
+func (in instance) departure(i int) int {
+	return int(in.trip.StopTimes[i].Departure) + in.offset
+}
+
+func (in instance) arrival(i int) int {
+	return int(in.trip.StopTimes[i].Arrival) + in.offset
+}
+
+// NewRouter returns a new router for the feed.
+func NewRouter(feed *Feed, opt *RouterOptions) *Router {
+	r := &Router{
+		feed: feed,
+		loc:  feed.Location(),
+		node: map[string]int{},
+		days: map[string][][]instance{},
+	}
+
+	if opt != nil {
+		r.opt = *opt
+	}
+
+	if r.opt.MaxWalkDistance <= 0 {
+		r.opt.MaxWalkDistance = 400
+	}
+
+	if r.opt.WalkSpeed <= 0 {
+		r.opt.WalkSpeed = 1.2
+	}
+
+	r.buildPatterns()
+	r.buildFootpaths()
+
+	return r
+}
+
+// buildPatterns groups the trips by route and sequence of stops.
+func (r *Router) buildPatterns() {
+	ids := make([]string, 0, len(r.feed.Trips))
+	for id := range r.feed.Trips {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	patterns := map[string]*pattern{}
+	for _, id := range ids {
+		t := r.feed.Trips[id]
+		if len(t.StopTimes) < 2 {
+			continue
+		}
+
+		key := make([]string, 0, len(t.StopTimes)+1)
+		key = append(key, t.RouteID)
+		for _, st := range t.StopTimes {
+			key = append(key, st.StopID)
+		}
+
+		p, ok := patterns[strings.Join(key, "|")]
+		if !ok {
+			p = &pattern{route: t.Route}
+			for _, st := range t.StopTimes {
+				p.stops = append(p.stops, r.addNode(st.Stop))
+			}
+
+			patterns[strings.Join(key, "|")] = p
+			r.patterns = append(r.patterns, p)
+		}
+
+		p.trips = append(p.trips, t)
+	}
+
+	r.stopPatterns = make([][]patternStop, len(r.nodes))
+	for i, p := range r.patterns {
+		for idx, s := range p.stops {
+			r.stopPatterns[s] = append(r.stopPatterns[s], patternStop{i, idx})
+		}
+	}
+}
+
+// addNode adds the stop as a node and returns its index.
+func (r *Router) addNode(s *Stop) int {
+	if n, ok := r.node[s.ID]; ok {
+		return n
+	}
+
+	r.node[s.ID] = len(r.nodes)
+	r.nodes = append(r.nodes, s)
+
+	return len(r.nodes) - 1
+}
+
+// buildFootpaths adds walking transfers between stops within
+// MaxWalkDistance and the transfers in transfers.txt. Transfers from a stop
+// to itself set the change time within the stop.
+func (r *Router) buildFootpaths() {
+	r.footpaths = make([][]footpath, len(r.nodes))
+	r.changes = make([]int, len(r.nodes))
+
+	// Put the stops in a grid with cells of MaxWalkDistance, so only stops in
+	// neighbouring cells have to be compared.
+	const metersPerDegree = 111320
+	cell := r.opt.MaxWalkDistance / metersPerDegree
+
+	type key struct{ lat, lon int }
+	grid := map[key][]int{}
+	cellOf := func(s *Stop) key {
+		return key{int(math.Floor(s.Lat / cell)), int(math.Floor(s.Lon * math.Cos(s.Lat*math.Pi/180) / cell))}
+	}
+
+	for n, s := range r.nodes {
+		k := cellOf(s)
+		grid[k] = append(grid[k], n)
+	}
+
+	for n, s := range r.nodes {
+		k := cellOf(s)
+		for dlat := -1; dlat <= 1; dlat++ {
+			for dlon := -1; dlon <= 1; dlon++ {
+				for _, m := range grid[key{k.lat + dlat, k.lon + dlon}] {
+					if m == n {
+						continue
+					}
+
+					d := distance(s, r.nodes[m])
+					if d <= r.opt.MaxWalkDistance {
+						r.addFootpath(n, m, int(math.Ceil(d/r.opt.WalkSpeed)))
+					}
+				}
+			}
+		}
+	}
+
+	for _, t := range r.feed.Transfers {
+		from, ok := r.node[t.FromStopID]
+		if !ok {
+			continue
+		}
+
+		to, ok := r.node[t.ToStopID]
+		if !ok || t.TransferType == 3 {
+			continue
+		}
+
+		d := int(t.MinTransferTime / time.Second)
+		if from == to {
+			r.changes[from] = max(r.changes[from], d)
+			continue
+		}
+
+		if d == 0 {
+			d = int(math.Ceil(distance(r.nodes[from], r.nodes[to]) / r.opt.WalkSpeed))
+		}
+
+		r.addFootpath(from, to, d)
+	}
+}
+
+// addFootpath adds a footpath or sets the duration of an existing one to
+// the longest duration.
+func (r *Router) addFootpath(from, to, duration int) {
+	for i, f := range r.footpaths[from] {
+		if f.to == to {
+			if duration > f.duration {
+				r.footpaths[from][i].duration = duration
+			}
+			return
+		}
+	}
+
+	r.footpaths[from] = append(r.footpaths[from], footpath{to, duration})
+}
+
+// distance returns the distance in meters between two stops.
+func distance(a, b *Stop) float64 {
+	return sl.LatLon{Lat: a.Lat, Lon: a.Lon}.Distance(sl.LatLon{Lat: b.Lat, Lon: b.Lon})
+}
+
+// instances returns the trips running on the service days before, on and
+// after date for each pattern, ordered by departure. The instances of the
+// last maxDays dates are kept.
+func (r *Router) instances(date time.Time) [][]instance {
+	k := date.Format(dateLayout)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if in, ok := r.days[k]; ok {
+		return in
+	}
+
+	// Service days are not 24 hours long on days with a daylight saving
+	// time change, so offsets are computed from the service day starts.
+	base := Time(0).On(date, r.loc)
+
+	all := make([][]instance, len(r.patterns))
+	for i, p := range r.patterns {
+		for d := -1; d <= 1; d++ {
+			sd := date.AddDate(0, 0, d)
+			offset := int(Time(0).On(sd, r.loc).Sub(base) / time.Second)
+			for _, t := range p.trips {
+				if r.feed.ServiceActive(t.ServiceID, sd) {
+					all[i] = append(all[i], instance{t, offset})
+				}
+			}
+		}
+
+		in := all[i]
+		sort.SliceStable(in, func(a, b int) bool { return in[a].departure(0) < in[b].departure(0) })
+	}
+
+	if len(r.dayOrder) >= maxDays {
+		delete(r.days, r.dayOrder[0])
+		r.dayOrder = r.dayOrder[1:]
+	}
+
+	r.days[k] = all
+	r.dayOrder = append(r.dayOrder, k)
+
+	return all
+}
+
+// query represents a search from origins to targets.
+type query struct {
+	origins   []int
+	targets   map[int]bool
+	departure int
+	rounds    int
+	change    int
+	percent   int
+	instances [][]instance
+}
+
+// label represents how a stop was reached in a round.
+type label struct {
+	// walk is true if the stop was reached by walking from the stop from.
+	walk bool
+	from int
+
+	// Trip ridden from the stop at index board to the stop at index alight.
+	in      instance
+	pattern int
+	board   int
+	alight  int
+}
+
+// journey represents a found journey.
+type journey struct {
+	legs      []*leg
+	arrival   int
+	departure int
+}
+
+// leg represents a ride or a walk in a journey.
+type leg struct {
+	walk      bool
+	from, to  int
+	departure int
+	arrival   int
+
+	in      instance
+	pattern int
+	board   int
+	alight  int
+}
+
+// search runs RAPTOR and returns the journeys that arrive earlier than all
+// journeys with fewer changes.
+func (r *Router) search(q *query) []*journey {
+	n := len(r.nodes)
+
+	arrivals := make([][]int, q.rounds+1)
+	labels := make([][]*label, q.rounds+1)
+	for k := range arrivals {
+		arrivals[k] = make([]int, n)
+		labels[k] = make([]*label, n)
+		for i := range arrivals[k] {
+			arrivals[k][i] = infinity
+		}
+	}
+
+	best := make([]int, n)
+	for i := range best {
+		best[i] = infinity
+	}
+
+	bestTarget := infinity
+	improve := func(k, s, t int, l *label) bool {
+		if t >= best[s] || t >= bestTarget {
+			return false
+		}
+
+		arrivals[k][s] = t
+		best[s] = t
+		labels[k][s] = l
+		if q.targets[s] {
+			bestTarget = t
+		}
+
+		return true
+	}
+
+	marked := map[int]bool{}
+	for _, o := range q.origins {
+		arrivals[0][o] = q.departure
+		best[o] = q.departure
+		marked[o] = true
+	}
+
+	r.walk(q, 0, marked, arrivals, improve)
+
+	for k := 1; k <= q.rounds && len(marked) > 0; k++ {
+		// Collect the patterns serving marked stops from the first marked index.
+		queue := map[int]int{}
+		for s := range marked {
+			for _, ps := range r.stopPatterns[s] {
+				if idx, ok := queue[ps.pattern]; !ok || ps.idx < idx {
+					queue[ps.pattern] = ps.idx
+				}
+			}
+		}
+
+		marked = map[int]bool{}
+
+		for pi, start := range queue {
+			p := r.patterns[pi]
+			trips := q.instances[pi]
+
+			var (
+				in    instance
+				board = -1
+			)
+
+			for i := start; i < len(p.stops); i++ {
+				s := p.stops[i]
+
+				if board >= 0 && in.trip.StopTimes[i].DropOffType != 1 {
+					l := &label{in: in, pattern: pi, board: board, alight: i, from: p.stops[board]}
+					if improve(k, s, in.arrival(i), l) {
+						marked[s] = true
+					}
+				}
+
+				prev := arrivals[k-1][s]
+				if prev == infinity {
+					continue
+				}
+
+				// Changing from another trip takes the change time and the
+				// change time within the stop, which are included in the
+				// duration of walks.
+				if k > 1 && labels[k-1][s] != nil && !labels[k-1][s].walk {
+					prev += r.changes[s]*q.percent/100 + q.change
+				}
+
+				if board >= 0 && in.departure(i) <= prev {
+					continue
+				}
+
+				j := sort.Search(len(trips), func(j int) bool { return trips[j].departure(i) >= prev })
+				for ; j < len(trips); j++ {
+					if trips[j].trip.StopTimes[i].PickupType != 1 {
+						break
+					}
+				}
+
+				if j < len(trips) && (board < 0 || trips[j].departure(i) < in.departure(i)) {
+					in = trips[j]
+					board = i
+				}
+			}
+		}
+
+		r.walk(q, k, marked, arrivals, improve)
+	}
+
+	var journeys []*journey
+	last := infinity
+	for k := 0; k <= q.rounds; k++ {
+		target, arr := -1, infinity
+		for t := range q.targets {
+			if arrivals[k][t] < arr {
+				target, arr = t, arrivals[k][t]
+			}
+		}
+
+		if target < 0 || arr >= last {
+			continue
+		}
+
+		last = arr
+		if j := r.journey(q, labels, k, target); j != nil {
+			journeys = append(journeys, j)
+		}
+	}
+
+	return journeys
+}
+
+// walk relaxes the footpaths from the stops marked in round k and marks
+// the stops reached.
+func (r *Router) walk(q *query, k int, marked map[int]bool, arrivals [][]int, improve func(k, s, t int, l *label) bool) {
+	from := make([]int, 0, len(marked))
+	for s := range marked {
+		from = append(from, s)
+	}
+	sort.Ints(from)
+
+	// Walks after a ride include the change time.
+	change := q.change
+	if k == 0 {
+		change = 0
+	}
+
+	for _, s := range from {
+		for _, f := range r.footpaths[s] {
+			d := f.duration*q.percent/100 + change
+			if improve(k, f.to, arrivals[k][s]+d, &label{walk: true, from: s}) {
+				marked[f.to] = true
+			}
+		}
+	}
+}
+
+// journey reconstructs the journey to the target reached in round k.
+func (r *Router) journey(q *query, labels [][]*label, k, target int) *journey {
+	var legs []*leg
+
+	s := target
+	for {
+		l := labels[k][s]
+		if l == nil {
+			break
+		}
+
+		if l.walk {
+			legs = append(legs, &leg{walk: true, from: l.from, to: s})
+			s = l.from
+			continue
+		}
+
+		legs = append(legs, &leg{
+			from:      l.from,
+			to:        s,
+			departure: l.in.departure(l.board),
+			arrival:   l.in.arrival(l.alight),
+			in:        l.in,
+			pattern:   l.pattern,
+			board:     l.board,
+			alight:    l.alight,
+		})
+
+		s = l.from
+		k--
+	}
+
+	if len(legs) == 0 {
+		return nil
+	}
+
+	for i, j := 0, len(legs)-1; i < j; i, j = i+1, j-1 {
+		legs[i], legs[j] = legs[j], legs[i]
+	}
+
+	// Walks start when the previous ride arrives, except a first walk that
+	// ends when the first ride departs.
+	for i, l := range legs {
+		if !l.walk {
+			continue
+		}
+
+		d := r.walkDuration(q, l.from, l.to)
+		switch {
+		case i > 0:
+			l.departure = legs[i-1].arrival
+			l.arrival = l.departure + d
+		case len(legs) > 1:
+			l.arrival = legs[1].departure
+			l.departure = l.arrival - d
+		default:
+			l.departure = q.departure
+			l.arrival = l.departure + d
+		}
+	}
+
+	return &journey{
+		legs:      legs,
+		departure: legs[0].departure,
+		arrival:   legs[len(legs)-1].arrival,
+	}
+}
+
+// walkDuration returns the duration of the walk between two stops without the change time.
+func (r *Router) walkDuration(q *query, from, to int) int {
+	for _, f := range r.footpaths[from] {
+		if f.to == to {
+			return f.duration * q.percent / 100
+		}
+	}
+	return 0
+}
+
+// Trip plans trips like TravelPlannerService.Trip using the feed instead
+// of the travel planner API. The origin and destination are given with
+// OriginExtID or OriginID and DestExtID or DestID as site ids, travel
+// planner ids like 300109192 or GTFS stop ids.
+//
+// Date, Time, SearchForArrival, MaxChange, MinChangeTime, AddChangeTime,
+// ChangeTimePercent, NumTrips and Passlist are supported. As in the travel
+// planner API a zero MaxChange means no limit.
+func (r *Router) Trip(opt *sl.TripOptions) ([]*sl.Trip, error) {
+	origins := r.resolve(opt.OriginExtID, opt.OriginID)
+	if len(origins) == 0 {
+		return nil, ErrNoOrigin
+	}
+
+	targets := map[int]bool{}
+	for _, n := range r.resolve(opt.DestExtID, opt.DestID) {
+		targets[n] = true
+	}
+	if len(targets) == 0 {
+		return nil, ErrNoDestination
+	}
+
+	at, err := r.searchTime(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	y, m, d := at.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, r.loc)
+	base := Time(0).On(date, r.loc)
+
+	q := &query{
+		origins:   origins,
+		targets:   targets,
+		rounds:    maxChanges + 1,
+		change:    (opt.MinChangeTime + opt.AddChangeTime) * 60,
+		percent:   100,
+		instances: r.instances(date),
+	}
+
+	if opt.MaxChange > 0 && opt.MaxChange < maxChanges {
+		q.rounds = opt.MaxChange + 1
+	}
+
+	if opt.ChangeTimePercent > 0 {
+		q.percent = opt.ChangeTimePercent
+	}
+
+	num := opt.NumTrips
+	if num <= 0 {
+		num = defaultNumTrips
+	}
+
+	t := int(at.Sub(base) / time.Second)
+
+	var journeys []*journey
+	if opt.SearchForArrival == 1 {
+		journeys = r.arriveBy(q, t, num)
+	} else {
+		journeys = r.departAt(q, t, num)
+	}
+
+	if len(journeys) == 0 {
+		return nil, sl.ErrNoTripFound
+	}
+
+	trips := make([]*sl.Trip, 0, len(journeys))
+	for i, j := range journeys {
+		trips = append(trips, r.trip(i, j, base, opt.Passlist == 1))
+	}
+
+	return trips, nil
+}
+
+// departAt returns up to num journeys departing at or after t.
+func (r *Router) departAt(q *query, t, num int) []*journey {
+	var journeys []*journey
+	seen := map[string]bool{}
+
+	for dep := t; len(journeys) < num && dep < t+searchWindow; {
+		q.departure = dep
+		found := r.search(q)
+		if len(found) == 0 {
+			break
+		}
+
+		added := false
+		next := infinity
+		for _, j := range found {
+			if k := j.key(); !seen[k] {
+				seen[k] = true
+				added = true
+				journeys = append(journeys, j)
+			}
+			if j.rides() > 0 && j.departure < next {
+				next = j.departure
+			}
+		}
+
+		// Walking all the way is found for every departure, so search
+		// again after the first departing ride.
+		if !added || next == infinity {
+			break
+		}
+
+		dep = max(next, dep) + 1
+	}
+
+	sortJourneys(journeys)
+	if len(journeys) > num {
+		journeys = journeys[:num]
+	}
+
+	return journeys
+}
+
+// arriveBy returns up to num journeys arriving at or before t with the
+// latest departure.
+func (r *Router) arriveBy(q *query, t, num int) []*journey {
+	var journeys []*journey
+	seen := map[string]bool{}
+
+	arrival := func(dep int) int {
+		q.departure = dep
+		a := infinity
+		for _, j := range r.search(q) {
+			if j.arrival < a {
+				a = j.arrival
+			}
+		}
+		return a
+	}
+
+	for len(journeys) < num {
+		// The earliest arrival never decreases with a later departure, so
+		// the latest departure arriving in time can be found by bisection.
+		lo, hi := t-searchWindow, t
+		if arrival(lo) > t {
+			break
+		}
+
+		for lo < hi {
+			mid := lo + (hi-lo+1)/2
+			if arrival(mid) <= t {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+
+		q.departure = lo
+		added := false
+		latest := infinity
+		for _, j := range r.search(q) {
+			if j.arrival > t {
+				continue
+			}
+			if k := j.key(); !seen[k] {
+				seen[k] = true
+				added = true
+				journeys = append(journeys, j)
+			}
+			if j.arrival < latest {
+				latest = j.arrival
+			}
+		}
+
+		if !added {
+			break
+		}
+		t = latest - 1
+	}
+
+	sortJourneys(journeys)
+	if len(journeys) > num {
+		journeys = journeys[len(journeys)-num:]
+	}
+
+	return journeys
+}
+
+// sortJourneys sorts journeys by departure and arrival.
+func sortJourneys(journeys []*journey) {
+	sort.SliceStable(journeys, func(i, j int) bool {
+		if journeys[i].departure != journeys[j].departure {
+			return journeys[i].departure < journeys[j].departure
+		}
+		return journeys[i].arrival < journeys[j].arrival
+	})
+}
+
+// rides returns the number of rides in the journey.
+func (j *journey) rides() int {
+	n := 0
+	for _, l := range j.legs {
+		if !l.walk {
+			n++
+		}
+	}
+	return n
+}
+
+// key returns a key identifying the rides of the journey.
+func (j *journey) key() string {
+	var parts []string
+	for _, l := range j.legs {
+		if !l.walk {
+			parts = append(parts, fmt.Sprintf("%s@%d:%d-%d", l.in.trip.ID, l.in.offset, l.board, l.alight))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// resolve returns the nodes of a site id, travel planner id or GTFS stop
+// id. Stations resolve to all their stops.
+func (r *Router) resolve(ids ...string) []int {
+	for _, id := range ids {
+		if len(id) == 0 {
+			continue
+		}
+
+		s := r.feed.Stops[id]
+		if s == nil {
+			s = r.feed.StopByExtID(id)
+		}
+		if s == nil {
+			s = r.feed.StopBySiteID(id)
+		}
+		if s == nil {
+			continue
+		}
+
+		var nodes []int
+		for _, c := range stops(s) {
+			if n, ok := r.node[c.ID]; ok {
+				nodes = append(nodes, n)
+			}
+		}
+
+		return nodes
+	}
+
+	return nil
+}
+
+// searchTime returns the time of the search from the Date and Time options
+// in the time zone of the feed. Default is now.
+func (r *Router) searchTime(opt *sl.TripOptions) (time.Time, error) {
+	now := time.Now().In(r.loc)
+
+	date := now.Format("2006-01-02")
+	if len(opt.Date) > 0 {
+		date = opt.Date
+	}
+
+	clock := now.Format("15:04")
+	if len(opt.Time) > 0 {
+		clock = opt.Time
+	}
+
+	t, err := time.ParseInLocation("2006-01-02 15:04", date+" "+clock, r.loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("gtfs: invalid date or time: %v", err)
+	}
+
+	return t, nil
+}
+
+// Product names and categories of the transport modes, as in the travel planner api.
+var products = map[string]struct{ name, category string }{
+	"METRO": {"Tunnelbana", "MET"},
+	"BUS":   {"Buss", "BUS"},
+	"TRAIN": {"Pendeltåg", "TRN"},
+	"TRAM":  {"Spårväg", "TRM"},
+	"SHIP":  {"Båt", "SHP"},
+}
+
+// trip returns the journey as a travel planner trip.
+func (r *Router) trip(idx int, j *journey, base time.Time, passlist bool) *sl.Trip {
+	t := &sl.Trip{
+		Idx:      idx,
+		TripID:   fmt.Sprintf("C-%d", idx),
+		Duration: isoDuration(j.arrival - j.departure),
+	}
+
+	for i, l := range j.legs {
+		leg := &sl.Leg{
+			Idx:         fmt.Sprint(i),
+			Origin:      r.legStop(r.nodes[l.from], base, l.departure),
+			Destination: r.legStop(r.nodes[l.to], base, l.arrival),
+			Reachable:   true,
+		}
+
+		if l.walk {
+			leg.Type = "WALK"
+			t.LegList.Leg = append(t.LegList.Leg, leg)
+			continue
+		}
+
+		trip := l.in.trip
+		route := trip.Route
+		p := products[transportMode(route.Type)]
+
+		leg.Type = "JNY"
+		leg.Category = p.category
+		leg.Name = strings.ToUpper(p.name) + " " + route.ShortName
+		leg.Number = trip.ShortName
+		leg.Direction = trip.Headsign
+		leg.Origin.Track = r.nodes[l.from].PlatformCode
+		leg.Destination.Track = r.nodes[l.to].PlatformCode
+		leg.Product = sl.Product{
+			Name:    leg.Name,
+			Num:     trip.ShortName,
+			Line:    route.ShortName,
+			CatIn:   p.category,
+			CatOut:  strings.ToUpper(p.name),
+			CatOutS: p.category,
+			CatOutL: p.name,
+		}
+
+		if len(leg.Direction) == 0 {
+			leg.Direction = trip.StopTimes[len(trip.StopTimes)-1].Stop.Name
+		}
+
+		if route.Agency != nil {
+			leg.Product.Operator = route.Agency.Name
+		}
+
+		if passlist {
+			for i := l.board; i <= l.alight; i++ {
+				leg.Stops.Stop = append(leg.Stops.Stop, r.stop(l, i, base))
+			}
+		}
+
+		t.LegList.Leg = append(t.LegList.Leg, leg)
+	}
+
+	return t
+}
+
+// legStop returns the stop as a travel planner leg stop at the time.
+func (r *Router) legStop(s *Stop, base time.Time, t int) sl.LegStop {
+	at := base.Add(time.Duration(t) * time.Second)
+
+	ls := sl.LegStop{
+		Name: s.Name,
+		Type: "ST",
+		Lat:  s.Lat,
+		Lon:  s.Lon,
+		Date: at.Format("2006-01-02"),
+		Time: at.Format("15:04:05"),
+	}
+
+	ls.ExtID, _ = r.feed.ExtID(s.ID)
+
+	if st := s.Station(); st != s {
+		ls.HasMainMast = true
+		ls.MainMastExtID, _ = r.feed.ExtID(st.ID)
+	}
+
+	return ls
+}
+
+// stop returns the stop at index i of a ride as a travel planner stop.
+func (r *Router) stop(l *leg, i int, base time.Time) *sl.Stop {
+	st := l.in.trip.StopTimes[i]
+	ls := r.legStop(st.Stop, base, 0)
+
+	s := &sl.Stop{
+		Name:          ls.Name,
+		ExtID:         ls.ExtID,
+		HasMainMast:   ls.HasMainMast,
+		MainMastExtID: ls.MainMastExtID,
+		Lat:           ls.Lat,
+		Lon:           ls.Lon,
+		RouteIdx:      i,
+	}
+
+	if i > l.board {
+		at := base.Add(time.Duration(l.in.arrival(i)) * time.Second)
+		s.ArrDate, s.ArrTime, s.ArrTrack = at.Format("2006-01-02"), at.Format("15:04:05"), st.Stop.PlatformCode
+	}
+
+	if i < l.alight {
+		at := base.Add(time.Duration(l.in.departure(i)) * time.Second)
+		s.DepDate, s.DepTime, s.DepTrack = at.Format("2006-01-02"), at.Format("15:04:05"), st.Stop.PlatformCode
+	}
+
+	return s
+}
+
+// isoDuration returns seconds as an ISO 8601 duration like PT1H5M.
+func isoDuration(sec int) string {
+	h, m := sec/3600, (sec%3600+59)/60
+	if m == 60 {
+		h, m = h+1, 0
+	}
+
+	s := "PT"
+	if h > 0 {
+		s += fmt.Sprintf("%dH", h)
+	}
+	if m > 0 || h == 0 {
+		s += fmt.Sprintf("%dM", m)
+	}
+
+	return s
+}
@@ -0,0 +1,312 @@
+package gtfs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+// routerFeed has metro 17 from Slussen to T-Centralen every ten minutes,
+// bus 4 from T-Centralen to Odenplan and a slower bus 1 from Slussen to
+// Odenplan. Changing at T-Centralen means walking between the platforms.
+var routerFeed = map[string]string{
+	"agency.txt": "agency_id,agency_name,agency_url,agency_timezone\n" +
+		"1,SL,https://sl.se,Europe/Stockholm\n",
+	"stops.txt": "stop_id,stop_name,stop_lat,stop_lon,location_type,parent_station,platform_code\n" +
+		"9021001009192000,Slussen,59.319511,18.071491,1,,\n" +
+		"9022001009192001,Slussen,59.319601,18.072011,0,9021001009192000,1\n" +
+		"9021001001000000,Gamla stan,59.323089,18.067556,1,,\n" +
+		"9022001001000001,Gamla stan,59.323108,18.067801,0,9021001001000000,1\n" +
+		"9021001001002000,T-Centralen,59.331358,18.061477,1,,\n" +
+		"9022001001002001,T-Centralen,59.331402,18.061201,0,9021001001002000,1\n" +
+		"9022001001002002,T-Centralen,59.332000,18.062000,0,9021001001002000,K\n" +
+		"9021001009117000,Odenplan,59.342944,18.049762,1,,\n" +
+		"9022001009117001,Odenplan,59.343012,18.049501,0,9021001009117000,A\n",
+	"routes.txt": "route_id,agency_id,route_short_name,route_type\n" +
+		"17,1,17,401\n" +
+		"4,1,4,700\n" +
+		"1,1,1,700\n",
+	"trips.txt": "route_id,service_id,trip_id,trip_headsign,trip_short_name\n" +
+		"17,1,17-0800,Åkeshov,20101\n" +
+		"17,1,17-0810,Åkeshov,20102\n" +
+		"4,1,4-0808,Radiohuset,\n" +
+		"4,1,4-0818,Radiohuset,\n" +
+		"4,1,4-0828,Radiohuset,\n" +
+		"1,1,1-0805,Stora Essingen,\n",
+	"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\n" +
+		"17-0800,08:00:00,08:00:00,9022001009192001,1\n" +
+		"17-0800,08:02:00,08:02:00,9022001001000001,2\n" +
+		"17-0800,08:04:00,08:04:00,9022001001002001,3\n" +
+		"17-0810,08:10:00,08:10:00,9022001009192001,1\n" +
+		"17-0810,08:12:00,08:12:00,9022001001000001,2\n" +
+		"17-0810,08:14:00,08:14:00,9022001001002001,3\n" +
+		"4-0808,08:08:00,08:08:00,9022001001002002,1\n" +
+		"4-0808,08:16:00,08:16:00,9022001009117001,2\n" +
+		"4-0818,08:18:00,08:18:00,9022001001002002,1\n" +
+		"4-0818,08:26:00,08:26:00,9022001009117001,2\n" +
+		"4-0828,08:28:00,08:28:00,9022001001002002,1\n" +
+		"4-0828,08:36:00,08:36:00,9022001009117001,2\n" +
+		"1-0805,08:05:00,08:05:00,9022001009192001,1\n" +
+		"1-0805,08:30:00,08:30:00,9022001009117001,2\n",
+	"calendar.txt": "service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n" +
+		"1,1,1,1,1,1,0,0,20261001,20261231\n",
+}
+
+// chainFeed has buses 72, 73 and 74 from Ropsten to Östermalmstorg with a
+// change at Gärdet and at Karlaplan. Bus 72 also runs after midnight on
+// Saturdays.
+var chainFeed = map[string]string{
+	"agency.txt": "agency_id,agency_name,agency_url,agency_timezone\n" +
+		"1,SL,https://sl.se,Europe/Stockholm\n",
+	"stops.txt": "stop_id,stop_name,stop_lat,stop_lon\n" +
+		"9022001009220001,Ropsten,59.357290,18.102269\n" +
+		"9022001009221001,Gärdet,59.347162,18.099349\n" +
+		"9022001009222001,Karlaplan,59.338836,18.090971\n" +
+		"9022001009206001,Östermalmstorg,59.334907,18.074181\n",
+	"routes.txt": "route_id,agency_id,route_short_name,route_type\n" +
+		"72,1,72,700\n" +
+		"73,1,73,700\n" +
+		"74,1,74,700\n",
+	"trips.txt": "route_id,service_id,trip_id,trip_headsign\n" +
+		"72,1,72-0800,Gärdet\n" +
+		"72,2,72-2530,Gärdet\n" +
+		"73,1,73-0810,Karlaplan\n" +
+		"73,1,73-0820,Karlaplan\n" +
+		"74,1,74-0830,Östermalmstorg\n",
+	"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\n" +
+		"72-0800,08:00:00,08:00:00,9022001009220001,1\n" +
+		"72-0800,08:05:00,08:05:00,9022001009221001,2\n" +
+		"72-2530,25:30:00,25:30:00,9022001009220001,1\n" +
+		"72-2530,25:35:00,25:35:00,9022001009221001,2\n" +
+		"73-0810,08:10:00,08:10:00,9022001009221001,1\n" +
+		"73-0810,08:15:00,08:15:00,9022001009222001,2\n" +
+		"73-0820,08:20:00,08:20:00,9022001009221001,1\n" +
+		"73-0820,08:25:00,08:25:00,9022001009222001,2\n" +
+		"74-0830,08:30:00,08:30:00,9022001009222001,1\n" +
+		"74-0830,08:35:00,08:35:00,9022001009206001,2\n",
+	"calendar.txt": "service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n" +
+		"1,1,1,1,1,1,0,0,20261001,20261231\n" +
+		"2,0,0,0,0,0,1,0,20261001,20261231\n",
+}
+
+func newRouter(t *testing.T) *Router {
+	return newFeedRouter(t, routerFeed)
+}
+
+func newFeedRouter(t *testing.T, files map[string]string) *Router {
+	b := zipFeed(t, files)
+
+	feed, err := Read(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	return NewRouter(feed, nil)
+}
+
+func legTimes(trip *sl.Trip) []string {
+	var times []string
+	for _, l := range trip.LegList.Leg {
+		times = append(times, l.Type+" "+l.Origin.Time[:5]+"-"+l.Destination.Time[:5])
+	}
+	return times
+}
+
+func TestRouterTrip(t *testing.T) {
+	r := newRouter(t)
+
+	trips, err := r.Trip(&sl.TripOptions{
+		OriginExtID: "9192",
+		DestExtID:   "300109117",
+		Date:        "2026-10-20",
+		Time:        "08:00",
+		NumTrips:    2,
+		Passlist:    1,
+	})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if len(trips) != 2 {
+		t.Fatalf("Expected 2 trips got %d", len(trips))
+	}
+
+	want := []string{"JNY 08:00-08:04", "WALK 08:04-08:05", "JNY 08:08-08:16"}
+	if got := legTimes(trips[0]); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+
+	if got := legTimes(trips[1]); len(got) != 1 || got[0] != "JNY 08:05-08:30" {
+		t.Errorf("Expected direct bus got %v", got)
+	}
+
+	leg := trips[0].LegList.Leg[0]
+	if leg.Name != "TUNNELBANA 17" || leg.Category != "MET" || leg.Product.Line != "17" || leg.Direction != "Åkeshov" || leg.Product.Operator != "SL" {
+		t.Errorf("Expected metro 17 to Åkeshov got %v", leg)
+	}
+
+	if leg.Origin.ExtID != "400109192" || leg.Origin.MainMastExtID != "300109192" || leg.Origin.Date != "2026-10-20" || leg.Origin.Track != "1" {
+		t.Errorf("Expected origin Slussen got %v", leg.Origin)
+	}
+
+	if stops := leg.IntermediateStops(); len(stops) != 1 || stops[0].Name != "Gamla stan" || stops[0].ArrTime != "08:02:00" {
+		t.Errorf("Expected Gamla stan as intermediate stop got %v", stops)
+	}
+
+	if trips[0].Duration != "PT16M" || trips[0].Idx != 0 {
+		t.Errorf("Expected duration PT16M got %s", trips[0].Duration)
+	}
+}
+
+func TestRouterTripChangeTime(t *testing.T) {
+	r := newRouter(t)
+
+	trips, err := r.Trip(&sl.TripOptions{
+		OriginExtID:   "9192",
+		DestExtID:     "9117",
+		Date:          "2026-10-20",
+		Time:          "08:00",
+		MinChangeTime: 5,
+		NumTrips:      1,
+	})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	want := []string{"JNY 08:00-08:04", "WALK 08:04-08:05", "JNY 08:18-08:26"}
+	if got := legTimes(trips[0]); len(got) != len(want) || got[2] != want[2] {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+}
+
+func TestRouterTripArriveBy(t *testing.T) {
+	r := newRouter(t)
+
+	trips, err := r.Trip(&sl.TripOptions{
+		OriginExtID:      "9192",
+		DestExtID:        "9117",
+		Date:             "2026-10-20",
+		Time:             "08:27",
+		SearchForArrival: 1,
+		NumTrips:         1,
+	})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if got := legTimes(trips[0]); len(got) != 3 || got[0] != "JNY 08:10-08:14" || got[2] != "JNY 08:18-08:26" {
+		t.Errorf("Expected latest departure 08:10 got %v", got)
+	}
+}
+
+func TestRouterTripErrors(t *testing.T) {
+	r := newRouter(t)
+
+	tests := []struct {
+		opt *sl.TripOptions
+		err error
+	}{
+		{&sl.TripOptions{OriginExtID: "1", DestExtID: "9117"}, ErrNoOrigin},
+		{&sl.TripOptions{OriginExtID: "9192", DestExtID: "1"}, ErrNoDestination},
+		{&sl.TripOptions{OriginExtID: "9192", DestExtID: "9117", Date: "2026-10-24", Time: "08:00"}, sl.ErrNoTripFound},
+		{&sl.TripOptions{OriginExtID: "9117", DestExtID: "9192", Date: "2026-10-20", Time: "08:00"}, sl.ErrNoTripFound},
+	}
+
+	for _, tt := range tests {
+		if _, err := r.Trip(tt.opt); err != tt.err {
+			t.Errorf("Expected %v got %v", tt.err, err)
+		}
+	}
+}
+
+func TestRouterTripMaxChange(t *testing.T) {
+	r := newFeedRouter(t, chainFeed)
+
+	opt := &sl.TripOptions{
+		OriginExtID: "9022001009220001",
+		DestExtID:   "9022001009206001",
+		Date:        "2026-10-20",
+		Time:        "08:00",
+		MaxChange:   1,
+		NumTrips:    1,
+	}
+
+	if _, err := r.Trip(opt); err != sl.ErrNoTripFound {
+		t.Errorf("Expected %v got %v", sl.ErrNoTripFound, err)
+	}
+
+	opt.MaxChange = 0
+
+	trips, err := r.Trip(opt)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	want := []string{"JNY 08:00-08:05", "JNY 08:10-08:15", "JNY 08:30-08:35"}
+	if got := legTimes(trips[0]); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+}
+
+func TestRouterTripStopChangeTime(t *testing.T) {
+	files := map[string]string{}
+	for name, data := range chainFeed {
+		files[name] = data
+	}
+
+	files["transfers.txt"] = "from_stop_id,to_stop_id,transfer_type,min_transfer_time\n" +
+		"9022001009221001,9022001009221001,2,600\n"
+
+	r := newFeedRouter(t, files)
+
+	trips, err := r.Trip(&sl.TripOptions{
+		OriginExtID: "9022001009220001",
+		DestExtID:   "9022001009222001",
+		Date:        "2026-10-20",
+		Time:        "08:00",
+		NumTrips:    1,
+	})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	want := []string{"JNY 08:00-08:05", "JNY 08:20-08:25"}
+	if got := legTimes(trips[0]); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+}
+
+func TestRouterTripDaylightSaving(t *testing.T) {
+	r := newFeedRouter(t, chainFeed)
+
+	// The service day of Saturday 2026-10-24 is 25 hours long since
+	// daylight saving time ends in the night.
+	trips, err := r.Trip(&sl.TripOptions{
+		OriginExtID: "9022001009220001",
+		DestExtID:   "9022001009221001",
+		Date:        "2026-10-25",
+		Time:        "01:00",
+		NumTrips:    1,
+	})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if got := legTimes(trips[0]); len(got) != 1 || got[0] != "JNY 01:30-01:35" {
+		t.Errorf("Expected bus at 01:30 got %v", got)
+	}
+}
+
+func TestRouterInstancesCache(t *testing.T) {
+	r := newRouter(t)
+
+	for d := 0; d < 10; d++ {
+		r.instances(time.Date(2026, 10, 1+d, 0, 0, 0, 0, r.loc))
+	}
+
+	if len(r.days) != maxDays {
+		t.Errorf("Expected %d cached dates got %d", maxDays, len(r.days))
+	}
+}
@@ -0,0 +1,636 @@
+package sl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Default base URL of the GTFS Realtime feeds.
+const defaultGTFSRealtimeURL = "https://opendata.samtrafiken.se/gtfs-rt/sl/"
+
+// GTFSRealtimeService handles communication with the GTFS Realtime feeds
+// published by Trafiklab. The feeds are decoded from protocol buffers.
+//
+// Trafiklab docs: https://www.trafiklab.se/api/gtfs-datasets/gtfs-regional/
+type GTFSRealtimeService service
+
+// Trip schedule relationships.
+const (
+	TripScheduled   = 0
+	TripAdded       = 1
+	TripUnscheduled = 2
+	TripCanceled    = 3
+	TripReplacement = 5
+	TripDuplicated  = 6
+	TripDeleted     = 7
+)
+
+// Stop time schedule relationships.
+const (
+	StopTimeScheduled   = 0
+	StopTimeSkipped     = 1
+	StopTimeNoData      = 2
+	StopTimeUnscheduled = 3
+)
+
+// Vehicle stop statuses.
+const (
+	VehicleIncomingAt  = 0
+	VehicleStoppedAt   = 1
+	VehicleInTransitTo = 2
+)
+
+// FeedMessage represents a GTFS Realtime feed.
+type FeedMessage struct {
+	Header *FeedHeader
+	Entity []*FeedEntity
+}
+
+// FeedHeader represents the header of a feed.
+type FeedHeader struct {
+	GTFSRealtimeVersion string
+	Incrementality      int
+	Timestamp           time.Time
+}
+
+// FeedEntity represents an entity in a feed. One of TripUpdate, Vehicle and
+// Alert is set.
+type FeedEntity struct {
+	ID         string
+	IsDeleted  bool
+	TripUpdate *TripUpdate
+	Vehicle    *VehiclePosition
+	Alert      *Alert
+}
+
+// TripDescriptor identifies a trip in the GTFS static data.
+type TripDescriptor struct {
+	TripID               string
+	RouteID              string
+	DirectionID          int
+	StartTime            string
+	StartDate            string
+	ScheduleRelationship int
+}
+
+// VehicleDescriptor identifies a vehicle.
+type VehicleDescriptor struct {
+	ID           string
+	Label        string
+	LicensePlate string
+}
+
+// TripUpdate represents realtime progress of a trip.
+type TripUpdate struct {
+	Trip           *TripDescriptor
+	Vehicle        *VehicleDescriptor
+	StopTimeUpdate []*StopTimeUpdate
+	Timestamp      time.Time
+	Delay          time.Duration
+}
+
+// StopTimeUpdate represents a realtime update of a stop time.
+type StopTimeUpdate struct {
+	StopSequence         int
+	StopID               string
+	Arrival              *StopTimeEvent
+	Departure            *StopTimeEvent
+	ScheduleRelationship int
+}
+
+// StopTimeEvent represents the predicted arrival or departure at a stop.
+type StopTimeEvent struct {
+	Delay       time.Duration
+	Time        time.Time
+	Uncertainty int
+}
+
+// VehiclePosition represents the realtime position of a vehicle.
+type VehiclePosition struct {
+	Trip                *TripDescriptor
+	Vehicle             *VehicleDescriptor
+	Position            *Position
+	CurrentStopSequence int
+	StopID              string
+	CurrentStatus       int
+	Timestamp           time.Time
+	CongestionLevel     int
+	OccupancyStatus     int
+}
+
+// Position represents a geographic position of a vehicle.
+type Position struct {
+	Latitude  float64
+	Longitude float64
+	Bearing   float64
+	Odometer  float64
+	Speed     float64
+}
+
+// Alert represents a service alert.
+type Alert struct {
+	ActivePeriod    []*TimeRange
+	InformedEntity  []*EntitySelector
+	Cause           int
+	Effect          int
+	URL             TranslatedString
+	HeaderText      TranslatedString
+	DescriptionText TranslatedString
+}
+
+// TimeRange represents a time interval. A zero Start or End means the
+// interval is open.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// EntitySelector selects the agencies, routes, trips or stops affected by an alert.
+type EntitySelector struct {
+	AgencyID  string
+	RouteID   string
+	RouteType int
+	Trip      *TripDescriptor
+	StopID    string
+}
+
+// Translation represents a text in one language.
+type Translation struct {
+	Text     string
+	Language string
+}
+
+// TranslatedString represents a text in multiple languages.
+type TranslatedString []*Translation
+
+// Text returns the translation in the language or the first translation
+// if there is none in the language.
+func (t TranslatedString) Text(language string) string {
+	for _, tr := range t {
+		if tr.Language == language {
+			return tr.Text
+		}
+	}
+
+	if len(t) > 0 {
+		return t[0].Text
+	}
+
+	return ""
+}
+
+// GTFSRealtimeOptions specifies parameters to the GTFSRealtimeService methods.
+type GTFSRealtimeOptions struct {
+	// API Key.
+	Key string `url:"key,omitempty"`
+}
+
+// TripUpdates fetches the trip updates feed.
+func (s *GTFSRealtimeService) TripUpdates(ctx context.Context, opt *GTFSRealtimeOptions) (*FeedMessage, error) {
+	feed, _, err := s.TripUpdatesWithResponse(ctx, opt)
+	return feed, err
+}
+
+// TripUpdatesWithResponse is like TripUpdates but also returns the API response.
+func (s *GTFSRealtimeService) TripUpdatesWithResponse(ctx context.Context, opt *GTFSRealtimeOptions) (*FeedMessage, *Response, error) {
+	return s.fetch(ctx, "tripupdates", "TripUpdates.pb", opt)
+}
+
+// VehiclePositions fetches the vehicle positions feed.
+func (s *GTFSRealtimeService) VehiclePositions(ctx context.Context, opt *GTFSRealtimeOptions) (*FeedMessage, error) {
+	feed, _, err := s.VehiclePositionsWithResponse(ctx, opt)
+	return feed, err
+}
+
+// VehiclePositionsWithResponse is like VehiclePositions but also returns the API response.
+func (s *GTFSRealtimeService) VehiclePositionsWithResponse(ctx context.Context, opt *GTFSRealtimeOptions) (*FeedMessage, *Response, error) {
+	return s.fetch(ctx, "vehiclepositions", "VehiclePositions.pb", opt)
+}
+
+// ServiceAlerts fetches the service alerts feed.
+func (s *GTFSRealtimeService) ServiceAlerts(ctx context.Context, opt *GTFSRealtimeOptions) (*FeedMessage, error) {
+	feed, _, err := s.ServiceAlertsWithResponse(ctx, opt)
+	return feed, err
+}
+
+// ServiceAlertsWithResponse is like ServiceAlerts but also returns the API response.
+func (s *GTFSRealtimeService) ServiceAlertsWithResponse(ctx context.Context, opt *GTFSRealtimeOptions) (*FeedMessage, *Response, error) {
+	return s.fetch(ctx, "servicealerts", "ServiceAlerts.pb", opt)
+}
+
+// fetch fetches and decodes a feed.
+func (s *GTFSRealtimeService) fetch(ctx context.Context, method, feed string, opt *GTFSRealtimeOptions) (*FeedMessage, *Response, error) {
+	if opt == nil || len(opt.Key) == 0 {
+		return nil, nil, ErrNoKey
+	}
+
+	u, err := s.client.GTFSRealtimeURL.Parse(feed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+	call := &Call{
+		Service:  "gtfsrealtime",
+		Method:   method,
+		Endpoint: u.String(),
+		Options:  opt,
+		Header:   http.Header{"Accept": {"application/x-protobuf"}},
+		Result:   &buf,
+	}
+
+	if err := s.client.call(ctx, call); err != nil {
+		return nil, newResponse(call), err
+	}
+
+	resp := newResponse(call)
+	if resp != nil && resp.StatusCode != http.StatusOK {
+		return nil, resp, fmt.Errorf("GTFS Realtime feed %s returned %s", feed, resp.Status)
+	}
+
+	m, err := DecodeFeedMessage(buf.Bytes())
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return m, resp, nil
+}
+
+// FilterRoutes returns a feed with the entities that concern any of the
+// routes. Trip descriptors without a route id do not match.
+func (m *FeedMessage) FilterRoutes(routeIDs ...string) *FeedMessage {
+	ids := set(routeIDs)
+
+	return m.filter(func(e *FeedEntity) bool {
+		switch {
+		case e.TripUpdate != nil:
+			return e.TripUpdate.Trip != nil && ids[e.TripUpdate.Trip.RouteID]
+		case e.Vehicle != nil:
+			return e.Vehicle.Trip != nil && ids[e.Vehicle.Trip.RouteID]
+		case e.Alert != nil:
+			for _, s := range e.Alert.InformedEntity {
+				if ids[s.RouteID] || (s.Trip != nil && ids[s.Trip.RouteID]) {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// FilterStops returns a feed with the entities that concern any of the
+// stops, i.e. trip updates with a stop time update at the stops, vehicles
+// at or heading to the stops and alerts for the stops.
+func (m *FeedMessage) FilterStops(stopIDs ...string) *FeedMessage {
+	ids := set(stopIDs)
+
+	return m.filter(func(e *FeedEntity) bool {
+		switch {
+		case e.TripUpdate != nil:
+			for _, u := range e.TripUpdate.StopTimeUpdate {
+				if ids[u.StopID] {
+					return true
+				}
+			}
+		case e.Vehicle != nil:
+			return ids[e.Vehicle.StopID]
+		case e.Alert != nil:
+			for _, s := range e.Alert.InformedEntity {
+				if ids[s.StopID] {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// filter returns a feed with the same header and the entities that match.
+func (m *FeedMessage) filter(match func(*FeedEntity) bool) *FeedMessage {
+	f := &FeedMessage{Header: m.Header}
+	for _, e := range m.Entity {
+		if match(e) {
+			f.Entity = append(f.Entity, e)
+		}
+	}
+	return f
+}
+
+// set returns a set of the non-empty strings.
+func set(values []string) map[string]bool {
+	s := map[string]bool{}
+	for _, v := range values {
+		if len(v) > 0 {
+			s[v] = true
+		}
+	}
+	return s
+}
+
+// DecodeFeedMessage decodes a GTFS Realtime feed, e.g. a captured feed file.
+func DecodeFeedMessage(b []byte) (*FeedMessage, error) {
+	m := &FeedMessage{Header: &FeedHeader{}}
+	r := &protoReader{b: b}
+
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			r.message(m.Header.unmarshal)
+		case 2<<3 | wireBytes:
+			e := &FeedEntity{}
+			r.message(e.unmarshal)
+			m.Entity = append(m.Entity, e)
+		default:
+			r.skip(tag)
+		}
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return m, nil
+}
+
+// unixTime returns the time of a POSIX timestamp or zero time if it is zero.
+func unixTime(sec uint64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(sec), 0)
+}
+
+func (h *FeedHeader) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			h.GTFSRealtimeVersion = r.string()
+		case 2<<3 | wireVarint:
+			h.Incrementality = int(r.varint())
+		case 3<<3 | wireVarint:
+			h.Timestamp = unixTime(r.varint())
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (e *FeedEntity) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			e.ID = r.string()
+		case 2<<3 | wireVarint:
+			e.IsDeleted = r.bool()
+		case 3<<3 | wireBytes:
+			e.TripUpdate = &TripUpdate{}
+			r.message(e.TripUpdate.unmarshal)
+		case 4<<3 | wireBytes:
+			e.Vehicle = &VehiclePosition{CurrentStatus: VehicleInTransitTo}
+			r.message(e.Vehicle.unmarshal)
+		case 5<<3 | wireBytes:
+			e.Alert = &Alert{}
+			r.message(e.Alert.unmarshal)
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (t *TripDescriptor) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			t.TripID = r.string()
+		case 2<<3 | wireBytes:
+			t.StartTime = r.string()
+		case 3<<3 | wireBytes:
+			t.StartDate = r.string()
+		case 4<<3 | wireVarint:
+			t.ScheduleRelationship = int(r.varint())
+		case 5<<3 | wireBytes:
+			t.RouteID = r.string()
+		case 6<<3 | wireVarint:
+			t.DirectionID = int(r.varint())
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (v *VehicleDescriptor) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			v.ID = r.string()
+		case 2<<3 | wireBytes:
+			v.Label = r.string()
+		case 3<<3 | wireBytes:
+			v.LicensePlate = r.string()
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (t *TripUpdate) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			t.Trip = &TripDescriptor{}
+			r.message(t.Trip.unmarshal)
+		case 2<<3 | wireBytes:
+			u := &StopTimeUpdate{}
+			r.message(u.unmarshal)
+			t.StopTimeUpdate = append(t.StopTimeUpdate, u)
+		case 3<<3 | wireBytes:
+			t.Vehicle = &VehicleDescriptor{}
+			r.message(t.Vehicle.unmarshal)
+		case 4<<3 | wireVarint:
+			t.Timestamp = unixTime(r.varint())
+		case 5<<3 | wireVarint:
+			t.Delay = time.Duration(r.int32()) * time.Second
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (u *StopTimeUpdate) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireVarint:
+			u.StopSequence = int(r.varint())
+		case 2<<3 | wireBytes:
+			u.Arrival = &StopTimeEvent{}
+			r.message(u.Arrival.unmarshal)
+		case 3<<3 | wireBytes:
+			u.Departure = &StopTimeEvent{}
+			r.message(u.Departure.unmarshal)
+		case 4<<3 | wireBytes:
+			u.StopID = r.string()
+		case 5<<3 | wireVarint:
+			u.ScheduleRelationship = int(r.varint())
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (e *StopTimeEvent) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireVarint:
+			e.Delay = time.Duration(r.int32()) * time.Second
+		case 2<<3 | wireVarint:
+			if sec := int64(r.varint()); sec != 0 {
+				e.Time = time.Unix(sec, 0)
+			}
+		case 3<<3 | wireVarint:
+			e.Uncertainty = int(r.int32())
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (v *VehiclePosition) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			v.Trip = &TripDescriptor{}
+			r.message(v.Trip.unmarshal)
+		case 2<<3 | wireBytes:
+			v.Position = &Position{}
+			r.message(v.Position.unmarshal)
+		case 3<<3 | wireVarint:
+			v.CurrentStopSequence = int(r.varint())
+		case 4<<3 | wireVarint:
+			v.CurrentStatus = int(r.varint())
+		case 5<<3 | wireVarint:
+			v.Timestamp = unixTime(r.varint())
+		case 6<<3 | wireVarint:
+			v.CongestionLevel = int(r.varint())
+		case 7<<3 | wireBytes:
+			v.StopID = r.string()
+		case 8<<3 | wireBytes:
+			v.Vehicle = &VehicleDescriptor{}
+			r.message(v.Vehicle.unmarshal)
+		case 9<<3 | wireVarint:
+			v.OccupancyStatus = int(r.varint())
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (p *Position) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireFixed32:
+			p.Latitude = float64(r.float())
+		case 2<<3 | wireFixed32:
+			p.Longitude = float64(r.float())
+		case 3<<3 | wireFixed32:
+			p.Bearing = float64(r.float())
+		case 4<<3 | wireFixed64:
+			p.Odometer = r.double()
+		case 5<<3 | wireFixed32:
+			p.Speed = float64(r.float())
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (a *Alert) unmarshal(r *protoReader) {
+	// Defaults are UNKNOWN_CAUSE and UNKNOWN_EFFECT.
+	a.Cause, a.Effect = 1, 8
+
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			t := &TimeRange{}
+			r.message(t.unmarshal)
+			a.ActivePeriod = append(a.ActivePeriod, t)
+		case 5<<3 | wireBytes:
+			s := &EntitySelector{}
+			r.message(s.unmarshal)
+			a.InformedEntity = append(a.InformedEntity, s)
+		case 6<<3 | wireVarint:
+			a.Cause = int(r.varint())
+		case 7<<3 | wireVarint:
+			a.Effect = int(r.varint())
+		case 8<<3 | wireBytes:
+			r.message(a.URL.unmarshal)
+		case 10<<3 | wireBytes:
+			r.message(a.HeaderText.unmarshal)
+		case 11<<3 | wireBytes:
+			r.message(a.DescriptionText.unmarshal)
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (t *TimeRange) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireVarint:
+			t.Start = unixTime(r.varint())
+		case 2<<3 | wireVarint:
+			t.End = unixTime(r.varint())
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (s *EntitySelector) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			s.AgencyID = r.string()
+		case 2<<3 | wireBytes:
+			s.RouteID = r.string()
+		case 3<<3 | wireVarint:
+			s.RouteType = int(r.int32())
+		case 4<<3 | wireBytes:
+			s.Trip = &TripDescriptor{}
+			r.message(s.Trip.unmarshal)
+		case 5<<3 | wireBytes:
+			s.StopID = r.string()
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (t *TranslatedString) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			tr := &Translation{}
+			r.message(tr.unmarshal)
+			*t = append(*t, tr)
+		default:
+			r.skip(tag)
+		}
+	}
+}
+
+func (t *Translation) unmarshal(r *protoReader) {
+	for r.more() {
+		switch tag := r.tag(); tag {
+		case 1<<3 | wireBytes:
+			t.Text = r.string()
+		case 2<<3 | wireBytes:
+			t.Language = r.string()
+		default:
+			r.skip(tag)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package sl
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// protoWriter encodes the protocol buffers wire format for tests.
+type protoWriter []byte
+
+func (w *protoWriter) varint(field int, v uint64) *protoWriter {
+	*w = binary.AppendUvarint(*w, uint64(field<<3|wireVarint))
+	*w = binary.AppendUvarint(*w, v)
+	return w
+}
+
+func (w *protoWriter) bytes(field int, b []byte) *protoWriter {
+	*w = binary.AppendUvarint(*w, uint64(field<<3|wireBytes))
+	*w = binary.AppendUvarint(*w, uint64(len(b)))
+	*w = append(*w, b...)
+	return w
+}
+
+func (w *protoWriter) string(field int, s string) *protoWriter {
+	return w.bytes(field, []byte(s))
+}
+
+func (w *protoWriter) message(field int, m *protoWriter) *protoWriter {
+	return w.bytes(field, *m)
+}
+
+func (w *protoWriter) float(field int, f float32) *protoWriter {
+	*w = binary.AppendUvarint(*w, uint64(field<<3|wireFixed32))
+	*w = binary.LittleEndian.AppendUint32(*w, math.Float32bits(f))
+	return w
+}
+
+func testFeed() []byte {
+	header := new(protoWriter).string(1, "2.0").varint(3, 1760870400)
+
+	trip := new(protoWriter).string(1, "14010000600000001").string(5, "9011001001700000").varint(6, 1)
+	// Negative int32 values are sign extended to ten byte varints.
+	delay := int64(-30)
+	arrival := new(protoWriter).varint(1, uint64(delay)).varint(2, 1760870460)
+	update := new(protoWriter).
+		varint(1, 2).
+		message(2, arrival).
+		string(4, "9022001009192001")
+	tripUpdate := new(protoWriter).
+		message(1, trip).
+		message(2, update).
+		varint(5, 120)
+
+	position := new(protoWriter).float(1, 59.3195).float(2, 18.0715).float(3, 90)
+	vehicle := new(protoWriter).
+		message(1, new(protoWriter).string(1, "14010000600000002").string(5, "9011001001800000")).
+		message(2, position).
+		string(7, "9022001001002001").
+		message(8, new(protoWriter).string(1, "9031001004500001").string(2, "C20"))
+
+	alert := new(protoWriter).
+		message(1, new(protoWriter).varint(1, 1760870400)).
+		message(5, new(protoWriter).string(5, "9022001001000001")).
+		varint(7, 4).
+		message(10, new(protoWriter).
+			message(1, new(protoWriter).string(1, "Hissen ur funktion").string(2, "sv")).
+			message(1, new(protoWriter).string(1, "Elevator out of order").string(2, "en")))
+
+	// Unknown fields are skipped.
+	feed := new(protoWriter).
+		message(1, header).
+		message(2, new(protoWriter).string(1, "1").message(3, tripUpdate)).
+		message(2, new(protoWriter).string(1, "2").message(4, vehicle).float(99, 1)).
+		message(2, new(protoWriter).string(1, "3").message(5, alert)).
+		varint(100, 1)
+
+	return *feed
+}
+
+func TestDecodeFeedMessage(t *testing.T) {
+	m, err := DecodeFeedMessage(testFeed())
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if m.Header.GTFSRealtimeVersion != "2.0" || m.Header.Timestamp.Unix() != 1760870400 {
+		t.Errorf("Expected header with version 2.0 got %v", m.Header)
+	}
+
+	if len(m.Entity) != 3 {
+		t.Fatalf("Expected 3 entities got %d", len(m.Entity))
+	}
+
+	tu := m.Entity[0].TripUpdate
+	if tu == nil || tu.Trip.TripID != "14010000600000001" || tu.Delay != 2*time.Minute {
+		t.Fatalf("Expected trip update got %v", tu)
+	}
+
+	stu := tu.StopTimeUpdate[0]
+	if stu.StopSequence != 2 || stu.StopID != "9022001009192001" || stu.Arrival.Delay != -30*time.Second || stu.Arrival.Time.Unix() != 1760870460 {
+		t.Errorf("Expected stop time update with negative delay got %v", stu)
+	}
+
+	v := m.Entity[1].Vehicle
+	if v == nil || v.Vehicle.Label != "C20" || v.CurrentStatus != VehicleInTransitTo {
+		t.Fatalf("Expected vehicle C20 in transit got %v", v)
+	}
+
+	if math.Abs(v.Position.Latitude-59.3195) > 1e-5 || math.Abs(v.Position.Longitude-18.0715) > 1e-5 || v.Position.Bearing != 90 {
+		t.Errorf("Expected position got %v", v.Position)
+	}
+
+	a := m.Entity[2].Alert
+	if a == nil || a.Effect != 4 || a.Cause != 1 || a.ActivePeriod[0].End != (time.Time{}) {
+		t.Fatalf("Expected alert got %v", a)
+	}
+
+	if a.HeaderText.Text("en") != "Elevator out of order" || a.HeaderText.Text("de") != "Hissen ur funktion" {
+		t.Errorf("Expected translated header text got %v", a.HeaderText)
+	}
+}
+
+// The feeds in testdata are encoded by the reference protobuf implementation
+// from gtfs-realtime.proto, independent of protoWriter.
+func TestDecodeFeedMessageTripUpdates(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "tripupdates.pb"))
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	m, err := DecodeFeedMessage(b)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if m.Header.GTFSRealtimeVersion != "2.0" || m.Header.Incrementality != 0 || m.Header.Timestamp.Unix() != 1760870400 {
+		t.Errorf("Expected full dataset header got %v", m.Header)
+	}
+
+	if len(m.Entity) != 3 {
+		t.Fatalf("Expected 3 entities got %d", len(m.Entity))
+	}
+
+	tu := m.Entity[0].TripUpdate
+	if tu == nil || tu.Trip.TripID != "14010000663283285" || tu.Trip.StartDate != "20251019" || tu.Vehicle.ID != "9031001004500123" {
+		t.Fatalf("Expected trip update got %v", tu)
+	}
+
+	if tu.Timestamp.Unix() != 1760870395 || len(tu.StopTimeUpdate) != 3 {
+		t.Fatalf("Expected trip update with 3 stop time updates got %v", tu)
+	}
+
+	stu := tu.StopTimeUpdate[1]
+	if stu.StopSequence != 2 || stu.StopID != "9022001010010002" {
+		t.Errorf("Expected stop sequence 2 got %v", stu)
+	}
+
+	if stu.Arrival.Delay != -45*time.Second || stu.Arrival.Time.Unix() != 1760870415 {
+		t.Errorf("Expected arrival 45 seconds early got %v", stu.Arrival)
+	}
+
+	if stu.Departure.Delay != 30*time.Second || stu.Departure.Uncertainty != 60 {
+		t.Errorf("Expected departure 30 seconds late got %v", stu.Departure)
+	}
+
+	if got := tu.StopTimeUpdate[2].ScheduleRelationship; got != 1 {
+		t.Errorf("Expected skipped stop got %d", got)
+	}
+
+	if got := m.Entity[1].TripUpdate.Trip.ScheduleRelationship; got != 3 {
+		t.Errorf("Expected canceled trip got %d", got)
+	}
+
+	if !m.Entity[2].IsDeleted {
+		t.Errorf("Expected deleted entity got %v", m.Entity[2])
+	}
+}
+
+func TestDecodeFeedMessageVehiclePositions(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "vehiclepositions.pb"))
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	m, err := DecodeFeedMessage(b)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if len(m.Entity) != 2 {
+		t.Fatalf("Expected 2 entities got %d", len(m.Entity))
+	}
+
+	v := m.Entity[0].Vehicle
+	if v == nil || v.Trip.TripID != "14010000663283285" || v.Vehicle.ID != "9031001004500123" {
+		t.Fatalf("Expected vehicle position got %v", v)
+	}
+
+	if v.CurrentStatus != VehicleStoppedAt || v.StopID != "9022001010010002" || v.Timestamp.Unix() != 1760870398 {
+		t.Errorf("Expected vehicle stopped at 9022001010010002 got %v", v)
+	}
+
+	p := v.Position
+	if math.Abs(p.Latitude-59.33146) > 1e-5 || math.Abs(p.Longitude-18.05993) > 1e-5 || p.Bearing != 212.5 || math.Abs(p.Speed-8.3) > 1e-5 {
+		t.Errorf("Expected position got %v", p)
+	}
+
+	v = m.Entity[1].Vehicle
+	if v == nil || v.Trip != nil || v.CurrentStatus != VehicleInTransitTo || v.OccupancyStatus != 1 {
+		t.Errorf("Expected vehicle without trip in transit got %v", v)
+	}
+}
+
+func TestDecodeFeedMessageInvalid(t *testing.T) {
+	b := testFeed()
+
+	for _, invalid := range [][]byte{b[:len(b)-5], {0x0a, 0x05, 0x01}, {0x0b}} {
+		if _, err := DecodeFeedMessage(invalid); err == nil {
+			t.Errorf("Expected error for %x got nil", invalid)
+		}
+	}
+}
+
+func TestFeedMessageFilter(t *testing.T) {
+	m, _ := DecodeFeedMessage(testFeed())
+
+	if f := m.FilterRoutes("9011001001700000"); len(f.Entity) != 1 || f.Entity[0].ID != "1" {
+		t.Errorf("Expected trip update for route got %v", f.Entity)
+	}
+
+	if f := m.FilterStops("9022001001002001", "9022001001000001"); len(f.Entity) != 2 || f.Entity[0].ID != "2" || f.Entity[1].ID != "3" {
+		t.Errorf("Expected vehicle and alert for stops got %v", f.Entity)
+	}
+
+	if f := m.FilterRoutes(""); len(f.Entity) != 0 || f.Header != m.Header {
+		t.Errorf("Expected no entities got %v", f.Entity)
+	}
+}
+
+func TestGTFSRealtimeService(t *testing.T) {
+	client, mux, serverURL, teardown := setupClient()
+	defer teardown()
+
+	client.GTFSRealtimeURL, _ = url.Parse(serverURL + baseURLPath + "/gtfs-rt/sl/")
+
+	mux.HandleFunc("/gtfs-rt/sl/VehiclePositions.pb", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if r.URL.Query().Get("key") != "XXXX" || r.Header.Get("Accept") != "application/x-protobuf" {
+			t.Errorf("Expected key and protobuf accept header got %s and %s", r.URL, r.Header.Get("Accept"))
+		}
+		w.Write(testFeed())
+	})
+
+	m, resp, err := client.GTFSRealtime.VehiclePositionsWithResponse(context.Background(), &GTFSRealtimeOptions{Key: "XXXX"})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if len(m.Entity) != 3 || resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 3 entities got %d", len(m.Entity))
+	}
+
+	if _, err := client.GTFSRealtime.TripUpdates(context.Background(), &GTFSRealtimeOptions{Key: "XXXX"}); err == nil {
+		t.Errorf("Expected error for missing feed got nil")
+	}
+
+	if _, err := client.GTFSRealtime.ServiceAlerts(context.Background(), &GTFSRealtimeOptions{}); err != ErrNoKey {
+		t.Errorf("Expected %v got %v", ErrNoKey, err)
+	}
+}
@@ -3,6 +3,7 @@ package sl
 import (
 	"context"
 	"errors"
+	"strconv"
 )
 
 // typeaheadEndpoint is the endpoint to the typeahead api.
@@ -27,6 +28,21 @@ type Location struct {
 	Y      string `json:"Y"`
 }
 
+// LatLon returns the location coordinate. X and Y are given in microdegrees by the SL API.
+func (l *Location) LatLon() (LatLon, error) {
+	x, err := strconv.Atoi(l.X)
+	if err != nil {
+		return LatLon{}, err
+	}
+
+	y, err := strconv.Atoi(l.Y)
+	if err != nil {
+		return LatLon{}, err
+	}
+
+	return LatLon{Lat: float64(y) / 1e6, Lon: float64(x) / 1e6}, nil
+}
+
 // TypeaheadResponseData represents the typeahead response data SL API.
 type TypeaheadResponseData struct {
 	ExecutionTime int         `json:"ExecutionTime"`
@@ -35,6 +51,11 @@ type TypeaheadResponseData struct {
 	StatusCode    int         `json:"StatusCode"`
 }
 
+// apiError implements errorResponse.
+func (r *TypeaheadResponseData) apiError() error {
+	return messageError(r.Message)
+}
+
 // LocationSearchOptions specifies optional parameters to the LocationSearch.Search.
 type LocationSearchOptions struct {
 	// Exclude buses if true. Default is false that are reversed to true.
@@ -55,34 +76,33 @@ type LocationSearchOptions struct {
 
 // Search does a location lookup and response with the location list or a error.
 func (s *LocationService) Search(ctx context.Context, opt *LocationSearchOptions) ([]*Location, error) {
+	locations, _, err := s.SearchWithResponse(ctx, opt)
+	return locations, err
+}
+
+// SearchWithResponse is like Search but also returns the API response.
+func (s *LocationService) SearchWithResponse(ctx context.Context, opt *LocationSearchOptions) ([]*Location, *Response, error) {
 	opt.StationsOnly = !opt.StationsOnly
 
 	if len(opt.Key) == 0 {
-		return nil, ErrNoKey
+		return nil, nil, ErrNoKey
 	}
 
 	if len(opt.SearchString) == 0 {
-		return nil, ErrNoSearchString
+		return nil, nil, ErrNoSearchString
 	}
 
-	r, err := addOptions(typeaheadEndpoint, opt)
-	if err != nil {
-		return nil, err
+	resp := &TypeaheadResponseData{}
+	call := &Call{
+		Service:  "location",
+		Method:   "search",
+		Endpoint: typeaheadEndpoint,
+		Options:  opt,
+		Result:   resp,
 	}
-
-	req, err := s.client.NewRequest("GET", r, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var resp *TypeaheadResponseData
-	if _, err := s.client.Do(ctx, req, &resp); err != nil {
-		return nil, err
-	}
-
-	if len(resp.Message) > 0 {
-		return nil, errors.New(resp.Message)
+	if err := s.client.call(ctx, call); err != nil {
+		return nil, newResponse(call), err
 	}
 
-	return resp.ResponseData, nil
+	return resp.ResponseData, newResponse(call), nil
 }
@@ -29,3 +29,21 @@ func TestLocationSearch(t *testing.T) {
 		t.Errorf("Expected 'Södra station (på Rosenlundsg) (Stockholm)' got %s", locations[0].Name)
 	}
 }
+
+func TestLocationLatLon(t *testing.T) {
+	l := &Location{X: "18057738", Y: "59312688"}
+
+	ll, err := l.LatLon()
+
+	if err != nil {
+		t.Errorf("Expected nil got error: %v", err)
+	}
+
+	if ll.Lat != 59.312688 || ll.Lon != 18.057738 {
+		t.Errorf("Expected '{59.312688 18.057738}' got %v", ll)
+	}
+
+	if _, err := (&Location{}).LatLon(); err == nil {
+		t.Errorf("Expected error got nil")
+	}
+}
@@ -0,0 +1,78 @@
+package sl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// bodyRecorder wraps a response body to count the bytes read and
+// optionally capture them for logging.
+type bodyRecorder struct {
+	io.ReadCloser
+
+	capture bool
+	size    int64
+	buf     bytes.Buffer
+}
+
+// Read implements io.Reader.
+func (b *bodyRecorder) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if b.capture && n > 0 {
+		b.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// logResponse logs a sent request at debug level or at warn level if it failed.
+func (c *Client) logResponse(ctx context.Context, req *http.Request, resp *http.Response, v interface{}, body *bodyRecorder, duration time.Duration, err error) {
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", sanitizedURL(req.URL)),
+		slog.Duration("duration", duration),
+	}
+
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode), slog.Int64("size", body.size))
+	}
+
+	if _, t, ok := apiStatus(v); ok {
+		attrs = append(attrs, slog.Int("execution_time", t))
+	}
+
+	if body.capture && body.buf.Len() > 0 {
+		attrs = append(attrs, slog.String("body", body.buf.String()))
+	}
+
+	if err != nil {
+		c.Logger.LogAttrs(ctx, slog.LevelWarn, "sl request failed", append(attrs, slog.String("error", err.Error()))...)
+		return
+	}
+
+	c.Logger.LogAttrs(ctx, slog.LevelDebug, "sl request", attrs...)
+}
+
+// logAPIError logs an error returned in an API response at warn level.
+func (c *Client) logAPIError(ctx context.Context, call *Call, req *http.Request, err error) {
+	if c.Logger == nil {
+		return
+	}
+
+	c.Logger.LogAttrs(ctx, slog.LevelWarn, "sl api error",
+		slog.String("call", call.Name()),
+		slog.String("url", sanitizedURL(req.URL)),
+		slog.String("error", err.Error()))
+}
+
+// sanitizedURL returns a sanitized copy of the URL as a string without
+// modifying the URL.
+func sanitizedURL(u *url.URL) string {
+	c := *u
+	return SanitizeURL(&c).String()
+}
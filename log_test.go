@@ -0,0 +1,116 @@
+package sl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func setupLogger(client *Client) *bytes.Buffer {
+	var buf bytes.Buffer
+	client.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return &buf
+}
+
+func testLogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("Expected nil got error: %v", err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestClientLogger(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+	mux.HandleFunc("/realtimedeparturesV4.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"StatusCode":0,"Message":null,"ExecutionTime":621,"ResponseData":{}}`)
+	})
+
+	buf := setupLogger(client)
+
+	if _, err := client.Realtime.Search(context.Background(), &RealtimeSearchOptions{
+		Key:    "secret",
+		SiteID: "1002",
+	}); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("Expected key to be masked got %s", buf)
+	}
+
+	lines := testLogLines(t, buf)
+
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 log line got %d", len(lines))
+	}
+
+	line := lines[0]
+
+	if line["level"] != "DEBUG" || line["method"] != "GET" {
+		t.Errorf("Expected debug GET log line got %v", line)
+	}
+
+	if line["execution_time"] != float64(621) || line["status"] != float64(200) || line["size"] != float64(69) {
+		t.Errorf("Expected execution time, status and size got %v", line)
+	}
+
+	if _, ok := line["body"]; ok {
+		t.Errorf("Expected no body got %v", line["body"])
+	}
+
+	buf.Reset()
+	client.LogBodies = true
+
+	if _, err := client.Realtime.Search(context.Background(), &RealtimeSearchOptions{
+		Key:    "secret",
+		SiteID: "1002",
+	}); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if line := testLogLines(t, buf)[0]; !strings.Contains(fmt.Sprint(line["body"]), `"ExecutionTime":621`) {
+		t.Errorf("Expected body got %v", line["body"])
+	}
+}
+
+func TestClientLoggerAPIError(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+	mux.HandleFunc("/typeahead.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"StatusCode":1002,"Message":"Key is invalid","ExecutionTime":0,"ResponseData":null}`)
+	})
+
+	buf := setupLogger(client)
+
+	if _, err := client.Location.Search(context.Background(), &LocationSearchOptions{
+		Key:          "secret",
+		SearchString: "Slussen",
+	}); err == nil {
+		t.Fatalf("Expected error got nil")
+	}
+
+	lines := testLogLines(t, buf)
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines got %d", len(lines))
+	}
+
+	if lines[1]["level"] != "WARN" || lines[1]["error"] != "Key is invalid" || lines[1]["call"] != "sl.location.search" {
+		t.Errorf("Expected api error warning got %v", lines[1])
+	}
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("Expected key to be masked got %s", buf)
+	}
+}
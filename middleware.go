@@ -0,0 +1,122 @@
+package sl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Call represents a service call passing through the middleware chain.
+type Call struct {
+	// Service name, e.g. realtime.
+	Service string
+
+	// Service method name, e.g. search.
+	Method string
+
+	// Endpoint relative to the base url, e.g. realtimedeparturesV4.json.
+	Endpoint string
+
+	// Options struct given to the service method, e.g. *RealtimeSearchOptions.
+	Options interface{}
+
+	// Header contains extra headers added to the http request.
+	Header http.Header
+
+	// Result is the response data the API response is decoded into,
+	// e.g. *RealtimeResponseData. It is filled when the call returns.
+	Result interface{}
+
+	// Response is the http response. It is nil until the request has been sent.
+	Response *http.Response
+
+	// Duration is the time it took to send the request and decode the response.
+	Duration time.Duration
+
+	// Stale is true if Result is a previous response served by StaleFallback.
+	Stale bool
+
+	// Age is the age of a stale result.
+	Age time.Duration
+}
+
+// Name returns the call name, e.g. sl.realtime.search.
+func (c *Call) Name() string {
+	return "sl." + c.Service + "." + c.Method
+}
+
+// Handler sends a call and decodes the response into call.Result.
+type Handler func(ctx context.Context, call *Call) error
+
+// Middleware wraps a handler. A middleware can inspect or modify the call
+// before calling next, inspect the result and error after or return
+// without calling next at all.
+type Middleware func(next Handler) Handler
+
+// Use adds middleware to the client. Middleware added first is called first.
+// Use should be called before the client is used.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// call runs the call through the middleware chain.
+func (c *Client) call(ctx context.Context, call *Call) error {
+	h := c.send
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+
+	return h(ctx, call)
+}
+
+// send creates the request for the call, sends it and returns the API error
+// in the decoded result if any.
+func (c *Client) send(ctx context.Context, call *Call) error {
+	u, err := addOptions(call.Endpoint, call.Options)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range call.Header {
+		req.Header[k] = v
+	}
+
+	start := time.Now()
+	resp, err := c.Do(ctx, req, call.Result)
+	call.Response = resp
+	call.Duration = time.Since(start)
+	if err != nil {
+		return err
+	}
+
+	if r, ok := call.Result.(errorResponse); ok {
+		if err := r.apiError(); err != nil {
+			c.logAPIError(ctx, call, req, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// errorResponse is implemented by response data that can contain an API error.
+type errorResponse interface {
+	apiError() error
+}
+
+// messageError returns the first non-empty message as an error.
+func messageError(messages ...string) error {
+	for _, m := range messages {
+		if len(m) > 0 {
+			return errors.New(m)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package sl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestClientUse(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+	mux.HandleFunc("/realtimedeparturesV4.json", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Request-Id"); got != "123" {
+			t.Errorf("Expected '123' got %s", got)
+		}
+
+		fmt.Fprint(w, `{"StatusCode":0,"Message":null,"ExecutionTime":621,"ResponseData":{"Metros":[{"LineNumber":"11"}]}}`)
+	})
+
+	var order []string
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			order = append(order, "first")
+
+			if call.Header == nil {
+				call.Header = http.Header{}
+			}
+			call.Header.Set("X-Request-Id", "123")
+
+			return next(ctx, call)
+		}
+	}, func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			order = append(order, "second")
+
+			if got := call.Name(); got != "sl.realtime.search" {
+				t.Errorf("Expected 'sl.realtime.search' got %s", got)
+			}
+
+			if opt, ok := call.Options.(*RealtimeSearchOptions); !ok || opt.SiteID != "1002" {
+				t.Errorf("Expected realtime search options got %v", call.Options)
+			}
+
+			err := next(ctx, call)
+
+			if resp, ok := call.Result.(*RealtimeResponseData); !ok || resp.ExecutionTime != 621 {
+				t.Errorf("Expected decoded realtime response data got %v", call.Result)
+			}
+
+			if call.Response == nil || call.Response.StatusCode != http.StatusOK {
+				t.Errorf("Expected http response got %v", call.Response)
+			}
+
+			return err
+		}
+	})
+
+	if _, err := client.Realtime.Search(context.Background(), &RealtimeSearchOptions{
+		Key:    "XXXX",
+		SiteID: "1002",
+	}); err != nil {
+		t.Errorf("Expected nil got error: %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("Expected '[first second]' got %v", order)
+	}
+}
+
+func TestClientUseFault(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+	mux.HandleFunc("/typeahead.json", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected no request")
+	})
+
+	fault := errors.New("fault")
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			return fault
+		}
+	})
+
+	if _, err := client.Location.Search(context.Background(), &LocationSearchOptions{
+		Key:          "XXXX",
+		SearchString: "Slussen",
+	}); err != fault {
+		t.Errorf("Expected %v got %v", fault, err)
+	}
+}
+
+func TestClientUseAPIError(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+	mux.HandleFunc("/TravelplannerV3/trip.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"errorCode":"SVC_LOC","errorText":"Location missing or invalid"}`)
+	})
+
+	var got error
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			got = next(ctx, call)
+			return got
+		}
+	})
+
+	if _, err := client.TravelPlanner.Trip(context.Background(), &TripOptions{Key: "XXXX"}); err == nil {
+		t.Errorf("Expected error got nil")
+	}
+
+	if got == nil || got.Error() != "Location missing or invalid" {
+		t.Errorf("Expected 'Location missing or invalid' got %v", got)
+	}
+}
@@ -0,0 +1,250 @@
+package sl
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// municipalities contains the municipalities SL serves in lower case.
+var municipalities = map[string]bool{
+	"botkyrka": true, "danderyd": true, "ekerö": true, "gnesta": true,
+	"haninge": true, "huddinge": true, "håbo": true, "järfälla": true,
+	"knivsta": true, "lidingö": true, "nacka": true, "norrtälje": true,
+	"nykvarn": true, "nynäshamn": true, "salem": true, "sigtuna": true,
+	"sollentuna": true, "solna": true, "stockholm": true, "sundbyberg": true,
+	"södertälje": true, "tyresö": true, "täby": true, "upplands väsby": true,
+	"upplands-bro": true, "uppsala": true, "vallentuna": true, "vaxholm": true,
+	"värmdö": true, "österåker": true,
+}
+
+// splitMunicipality splits the name into the name and the municipality
+// suffix. A trailing parenthetical is the municipality if it is a known
+// municipality or follows another parenthetical, e.g. "Södra station
+// (på Rosenlundsg) (Stockholm)", while "Södra station (på Rosenlundsg)"
+// has no municipality.
+func splitMunicipality(name string) (string, string) {
+	name = strings.TrimSpace(name)
+
+	i := strings.LastIndex(name, " (")
+	if i <= 0 || !strings.HasSuffix(name, ")") {
+		return name, ""
+	}
+
+	rest, suffix := strings.TrimSpace(name[:i]), name[i+2:len(name)-1]
+	if !municipalities[strings.ToLower(suffix)] && !strings.HasSuffix(rest, ")") {
+		return name, ""
+	}
+
+	return rest, suffix
+}
+
+// StripMunicipality returns the name without the municipality suffix the
+// location API adds, e.g. "Slussen (Stockholm)" becomes "Slussen".
+func StripMunicipality(name string) string {
+	name, _ = splitMunicipality(name)
+	return name
+}
+
+// Municipality returns the municipality suffix of the name or an empty
+// string, e.g. "Stockholm" for "Slussen (Stockholm)".
+func Municipality(name string) string {
+	_, m := splitMunicipality(name)
+	return m
+}
+
+// CollapseSpaces trims the string and replaces runs of whitespace with a
+// single space, e.g. "TUNNELBANA  13" becomes "TUNNELBANA 13".
+func CollapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// NormalizeName returns the name as shown to users, without municipality
+// suffix and with collapsed whitespace.
+func NormalizeName(name string) string {
+	return CollapseSpaces(StripMunicipality(name))
+}
+
+// NameKey returns a key to compare names by, in lower case without
+// diacritics, punctuation and municipality suffix, e.g. "SLUSSEN",
+// "Slussen (Stockholm)" and "slussen" all have the key "slussen" and
+// "Södra station" has the key "sodra station".
+func NameKey(name string) string {
+	var b strings.Builder
+	space := false
+
+	for _, r := range strings.ToLower(StripMunicipality(name)) {
+		if s, ok := foldRunes[r]; ok {
+			b.WriteString(s)
+			space = false
+			continue
+		}
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+
+		if !space && b.Len() > 0 {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// SameName reports whether two names are the same ignoring case,
+// diacritics, punctuation, whitespace and municipality suffix.
+func SameName(a, b string) bool {
+	return NameKey(a) == NameKey(b)
+}
+
+// foldRunes maps lower case letters with diacritics to ASCII.
+var foldRunes = map[rune]string{
+	'å': "a", 'ä': "a", 'à': "a", 'á': "a", 'â': "a", 'ã': "a",
+	'ö': "o", 'ø': "o", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o",
+	'é': "e", 'è': "e", 'ê': "e", 'ë': "e",
+	'ü': "u", 'ù': "u", 'ú': "u", 'û': "u",
+	'í': "i", 'ì': "i", 'î': "i", 'ï': "i",
+	'ý': "y", 'ÿ': "y", 'ç': "c", 'ñ': "n",
+	'æ': "ae", 'ß': "ss",
+}
+
+// SiteIDFromExtID returns the site id of a travel planner external id of
+// a stop area, like LegStop.MainMastExtID. Stop area ids are nine digits
+// starting with 3, e.g. 300109192 is site 9192. False is returned for
+// other ids, e.g. stop points starting with 4.
+func SiteIDFromExtID(extID string) (string, bool) {
+	if len(extID) != 9 || extID[0] != '3' {
+		return "", false
+	}
+
+	n, err := strconv.Atoi(extID[4:])
+	if err != nil || n <= 0 {
+		return "", false
+	}
+
+	return strconv.Itoa(n), true
+}
+
+// mainMastExtIDFromID returns the L= value of a main mast id like
+// A=1@O=Slussen@X=18071860@Y=59320284@U=74@L=300109192@.
+func mainMastExtIDFromID(id string) string {
+	for _, part := range strings.Split(id, "@") {
+		if strings.HasPrefix(part, "L=") {
+			return part[2:]
+		}
+	}
+
+	return ""
+}
+
+// ShortName returns the location name without municipality suffix.
+func (l *Location) ShortName() string {
+	return NormalizeName(l.Name)
+}
+
+// Municipality returns the municipality of the location or an empty string.
+func (l *Location) Municipality() string {
+	return Municipality(l.Name)
+}
+
+// SiteID returns the site id of the stop area of the transport.
+func (t *Transport) SiteID() string {
+	if t.StopAreaNumber <= 0 {
+		return ""
+	}
+
+	return strconv.Itoa(t.StopAreaNumber)
+}
+
+// siteIDFromIDs returns the site id of the first of the main mast external
+// id, the L= value of the main mast id and the external id that is a stop
+// area id or an empty string.
+func siteIDFromIDs(mainMastExtID, mainMastID, extID string) string {
+	for _, id := range []string{mainMastExtID, mainMastExtIDFromID(mainMastID), extID} {
+		if siteID, ok := SiteIDFromExtID(id); ok {
+			return siteID
+		}
+	}
+
+	return ""
+}
+
+// SiteID returns the site id of the main mast, i.e. the stop area, of the
+// stop or an empty string if the stop has no main mast.
+func (s *LegStop) SiteID() string {
+	return siteIDFromIDs(s.MainMastExtID, s.MainMastID, s.ExtID)
+}
+
+// SiteID returns the site id of the main mast, i.e. the stop area, of the
+// stop or an empty string if the stop has no main mast.
+func (s *Stop) SiteID() string {
+	return siteIDFromIDs(s.MainMastExtID, s.MainMastID, s.ExtID)
+}
+
+// DisplayName returns the product name with collapsed whitespace, e.g.
+// "TUNNELBANA 13".
+func (p *Product) DisplayName() string {
+	return CollapseSpaces(p.Name)
+}
+
+// Category returns the output category without padding, e.g. "METRO".
+func (p *Product) Category() string {
+	return strings.TrimSpace(p.CatOut)
+}
+
+// DisplayName returns the leg name with collapsed whitespace.
+func (l *Leg) DisplayName() string {
+	return CollapseSpaces(l.Name)
+}
+
+// SiteNames maps site ids to site names, so stops the APIs name
+// differently, e.g. a stop point and its main mast, get the same name.
+type SiteNames map[string]string
+
+// Add adds the locations with a site id, without municipality suffix.
+func (n SiteNames) Add(locations ...*Location) {
+	for _, l := range locations {
+		if len(l.SiteID) > 0 {
+			n[l.SiteID] = l.ShortName()
+		}
+	}
+}
+
+// Name returns the name of the site or an empty string.
+func (n SiteNames) Name(siteID string) string {
+	return n[siteID]
+}
+
+// LegStop returns the name of the site of the stop, or the stop name
+// without municipality suffix if the site is unknown.
+func (n SiteNames) LegStop(s *LegStop) string {
+	if name, ok := n[s.SiteID()]; ok {
+		return name
+	}
+
+	return NormalizeName(s.Name)
+}
+
+// Stop returns the name of the site of the stop, or the stop name without
+// municipality suffix if the site is unknown.
+func (n SiteNames) Stop(s *Stop) string {
+	if name, ok := n[s.SiteID()]; ok {
+		return name
+	}
+
+	return NormalizeName(s.Name)
+}
+
+// Transport returns the name of the site of the transport, or its stop
+// area name if the site is unknown.
+func (n SiteNames) Transport(t *Transport) string {
+	if name, ok := n[t.SiteID()]; ok {
+		return name
+	}
+
+	return NormalizeName(t.StopAreaName)
+}
@@ -0,0 +1,110 @@
+package sl
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name, normalized, key, municipality string
+	}{
+		{"Slussen (Stockholm)", "Slussen", "slussen", "Stockholm"},
+		{"SLUSSEN", "SLUSSEN", "slussen", ""},
+		{"  T-Centralen ", "T-Centralen", "t centralen", ""},
+		{"Södra station (på Rosenlundsg) (Stockholm)", "Södra station (på Rosenlundsg)", "sodra station pa rosenlundsg", "Stockholm"},
+		{"Södra station (på Rosenlundsg)", "Södra station (på Rosenlundsg)", "sodra station pa rosenlundsg", ""},
+		{"Centralen (Klarabergsviad.) (Stockholm)", "Centralen (Klarabergsviad.)", "centralen klarabergsviad", "Stockholm"},
+		{"Gustavsberg (Värmdö)", "Gustavsberg", "gustavsberg", "Värmdö"},
+		{"TUNNELBANA  13", "TUNNELBANA 13", "tunnelbana 13", ""},
+		{"S:t Eriksplan", "S:t Eriksplan", "s t eriksplan", ""},
+		{"Ærø", "Ærø", "aero", ""},
+		{"(Stockholm)", "(Stockholm)", "stockholm", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeName(tt.name); got != tt.normalized {
+			t.Errorf("Expected %q got %q", tt.normalized, got)
+		}
+
+		if got := NameKey(tt.name); got != tt.key {
+			t.Errorf("Expected %q got %q", tt.key, got)
+		}
+
+		if got := Municipality(tt.name); got != tt.municipality {
+			t.Errorf("Expected %q got %q", tt.municipality, got)
+		}
+	}
+
+	if !SameName("Slussen (Stockholm)", "SLUSSEN") || SameName("Slussen", "Skanstull") {
+		t.Errorf("Expected Slussen (Stockholm) and SLUSSEN to be the same name")
+	}
+}
+
+func TestSiteIDFromExtID(t *testing.T) {
+	tests := map[string]string{
+		"300109192": "9192",
+		"300101002": "1002",
+		"400102011": "",
+		"9192":      "",
+		"3001abcde": "",
+	}
+
+	for extID, want := range tests {
+		got, ok := SiteIDFromExtID(extID)
+		if got != want || ok != (len(want) > 0) {
+			t.Errorf("Expected %q for %s got %q", want, extID, got)
+		}
+	}
+}
+
+func TestSiteNames(t *testing.T) {
+	names := SiteNames{}
+	names.Add(&Location{Name: "T-Centralen (Stockholm)", SiteID: "1002"}, &Location{Name: "Slussen (Stockholm)", SiteID: "9192"})
+
+	stops := []struct {
+		stop *LegStop
+		want string
+	}{
+		{&LegStop{Name: "Sergels torg (Stockholm)", MainMastExtID: "300101002"}, "T-Centralen"},
+		{&LegStop{Name: "Sergels torg", MainMastID: "A=1@O=T-Centralen (Stockholm)@X=18061486@Y=59331134@U=74@L=300101002@"}, "T-Centralen"},
+		{&LegStop{Name: "Slussen (Stockholm)", ExtID: "300109192"}, "Slussen"},
+		{&LegStop{Name: "Gullmarsplan (Stockholm)", ExtID: "400109189"}, "Gullmarsplan"},
+	}
+
+	for _, tt := range stops {
+		if got := names.LegStop(tt.stop); got != tt.want {
+			t.Errorf("Expected %q got %q", tt.want, got)
+		}
+	}
+
+	if got := names.Stop(&Stop{Name: "Sergels torg", MainMastExtID: "300101002"}); got != "T-Centralen" {
+		t.Errorf("Expected 'T-Centralen' got %q", got)
+	}
+
+	if got := names.Transport(&Transport{StopAreaName: "Slussen", StopAreaNumber: 9192}); got != "Slussen" {
+		t.Errorf("Expected 'Slussen' got %q", got)
+	}
+
+	if got := names.Transport(&Transport{StopAreaName: "Skanstull ", StopAreaNumber: 9190}); got != "Skanstull" {
+		t.Errorf("Expected 'Skanstull' got %q", got)
+	}
+}
+
+func TestProductNames(t *testing.T) {
+	p := &Product{Name: "TUNNELBANA  13", CatOut: "METRO   "}
+
+	if got := p.DisplayName(); got != "TUNNELBANA 13" {
+		t.Errorf("Expected 'TUNNELBANA 13' got %q", got)
+	}
+
+	if got := p.Category(); got != "METRO" {
+		t.Errorf("Expected 'METRO' got %q", got)
+	}
+
+	if got := (&Leg{Name: "BUSS  54"}).DisplayName(); got != "BUSS 54" {
+		t.Errorf("Expected 'BUSS 54' got %q", got)
+	}
+
+	l := &Location{Name: "Slussen (Stockholm)"}
+	if l.ShortName() != "Slussen" || l.Municipality() != "Stockholm" {
+		t.Errorf("Expected Slussen in Stockholm got %q in %q", l.ShortName(), l.Municipality())
+	}
+}
@@ -0,0 +1,109 @@
+package sl
+
+import "math"
+
+// LatLon represents a WGS84 coordinate.
+type LatLon struct {
+	Lat float64
+	Lon float64
+}
+
+// Distance returns the great-circle distance in meters to q.
+func (p LatLon) Distance(q LatLon) float64 {
+	const earthRadius = 6371000
+
+	lat1, lat2 := p.Lat*math.Pi/180, q.Lat*math.Pi/180
+	dlat := lat2 - lat1
+	dlon := (q.Lon - p.Lon) * math.Pi / 180
+
+	h := math.Sin(dlat/2)*math.Sin(dlat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dlon/2)*math.Sin(dlon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Polyline represents the detailed route geometry returned by the travel planner when Poly is 1.
+type Polyline struct {
+	// Coordinates as a flat array of x (longitude) and y (latitude) values.
+	Crd []float64 `json:"crd"`
+
+	// Delta is true when every coordinate after the first one is relative to the previous one.
+	Delta bool `json:"delta"`
+
+	// Number of values per coordinate. Default is 2.
+	Dim int `json:"dim"`
+
+	// Coordinate type, e.g. WGS84.
+	Type string `json:"type"`
+}
+
+// PolylineGroup represents a group of polylines.
+type PolylineGroup struct {
+	Desc         string      `json:"desc"`
+	PolylineDesc []*Polyline `json:"polylineDesc"`
+}
+
+// Points decodes the polyline coordinates into a list of latitude and longitude pairs.
+func (p *Polyline) Points() []LatLon {
+	if p == nil {
+		return nil
+	}
+
+	dim := p.Dim
+	if dim < 2 {
+		dim = 2
+	}
+
+	points := make([]LatLon, 0, len(p.Crd)/dim)
+
+	var x, y float64
+	for i := 0; i+1 < len(p.Crd); i += dim {
+		if p.Delta && len(points) > 0 {
+			x += p.Crd[i]
+			y += p.Crd[i+1]
+		} else {
+			x = p.Crd[i]
+			y = p.Crd[i+1]
+		}
+
+		points = append(points, LatLon{Lat: y, Lon: x})
+	}
+
+	return points
+}
+
+// Points decodes all polylines in the group into one list of latitude and longitude pairs.
+func (g *PolylineGroup) Points() []LatLon {
+	if g == nil {
+		return nil
+	}
+
+	var points []LatLon
+	for _, p := range g.PolylineDesc {
+		points = append(points, p.Points()...)
+	}
+
+	return points
+}
+
+// path returns the points of the polyline if any, otherwise the points of the polyline group.
+func path(p *Polyline, g *PolylineGroup) []LatLon {
+	if points := p.Points(); len(points) > 0 {
+		return points
+	}
+
+	if points := g.Points(); len(points) > 0 {
+		return points
+	}
+
+	return nil
+}
+
+// Path returns the detailed route geometry of the leg or nil if it was not requested.
+func (l *Leg) Path() []LatLon {
+	return path(l.Polyline, l.PolylineGroup)
+}
+
+// Path returns the detailed route geometry of the journey or nil if it was not requested.
+func (j *Journey) Path() []LatLon {
+	return path(j.Polyline, j.PolylineGroup)
+}
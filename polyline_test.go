@@ -0,0 +1,70 @@
+package sl
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func testLatLon(t *testing.T, got, want LatLon) {
+	if math.Abs(got.Lat-want.Lat) > 1e-9 || math.Abs(got.Lon-want.Lon) > 1e-9 {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+}
+
+func TestPolylinePoints(t *testing.T) {
+	p := &Polyline{
+		Crd:   []float64{18.071491, 59.319511, -0.001, 0.002, -0.002, 0.003},
+		Delta: true,
+		Dim:   2,
+	}
+
+	points := p.Points()
+
+	if len(points) != 3 {
+		t.Fatalf("Expected 3 points got %d", len(points))
+	}
+
+	testLatLon(t, points[0], LatLon{Lat: 59.319511, Lon: 18.071491})
+	testLatLon(t, points[2], LatLon{Lat: 59.324511, Lon: 18.068491})
+
+	p = &Polyline{
+		Crd: []float64{18.071491, 59.319511, 0, 18.061477, 59.331358, 0},
+		Dim: 3,
+	}
+
+	points = p.Points()
+
+	if len(points) != 2 {
+		t.Fatalf("Expected 2 points got %d", len(points))
+	}
+
+	testLatLon(t, points[1], LatLon{Lat: 59.331358, Lon: 18.061477})
+}
+
+func TestLegPath(t *testing.T) {
+	var leg *Leg
+	if err := json.Unmarshal([]byte(`{"name":"TUNNELBANA  13","Polyline":{"crd":[18.071491,59.319511,-0.010014,0.011847],"delta":true,"dim":2,"type":"WGS84"}}`), &leg); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	points := leg.Path()
+
+	if len(points) != 2 {
+		t.Fatalf("Expected 2 points got %d", len(points))
+	}
+
+	testLatLon(t, points[1], LatLon{Lat: 59.331358, Lon: 18.061477})
+
+	if err := json.Unmarshal([]byte(`{"name":"TUNNELBANA  13","PolylineGroup":{"polylineDesc":[{"crd":[18.071491,59.319511],"dim":2},{"crd":[18.061477,59.331358],"dim":2}]}}`), &leg); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if points := leg.Path(); len(points) != 2 {
+		t.Errorf("Expected 2 points got %d", len(points))
+	}
+
+	if points := (&Leg{}).Path(); points != nil {
+		t.Errorf("Expected nil got %v", points)
+	}
+}
@@ -0,0 +1,150 @@
+package sl
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Protocol buffers wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errProtobuf = errors.New("invalid protocol buffer")
+
+// protoReader decodes the protocol buffers wire format. The first error is
+// kept and all reads after it return zero values, so a message can be read
+// field by field and the error checked once.
+type protoReader struct {
+	b   []byte
+	err error
+}
+
+// more reports whether there are more fields to read.
+func (r *protoReader) more() bool {
+	return r.err == nil && len(r.b) > 0
+}
+
+// tag reads a field key, i.e. the field number shifted left by three bits
+// and combined with the wire type.
+func (r *protoReader) tag() uint64 {
+	return r.varint()
+}
+
+func (r *protoReader) varint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.err = errProtobuf
+		return 0
+	}
+
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *protoReader) fixed32() uint32 {
+	if r.err != nil {
+		return 0
+	}
+
+	if len(r.b) < 4 {
+		r.err = errProtobuf
+		return 0
+	}
+
+	v := binary.LittleEndian.Uint32(r.b)
+	r.b = r.b[4:]
+	return v
+}
+
+func (r *protoReader) fixed64() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	if len(r.b) < 8 {
+		r.err = errProtobuf
+		return 0
+	}
+
+	v := binary.LittleEndian.Uint64(r.b)
+	r.b = r.b[8:]
+	return v
+}
+
+func (r *protoReader) bytes() []byte {
+	n := r.varint()
+	if r.err != nil {
+		return nil
+	}
+
+	if n > uint64(len(r.b)) {
+		r.err = errProtobuf
+		return nil
+	}
+
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *protoReader) string() string {
+	return string(r.bytes())
+}
+
+func (r *protoReader) bool() bool {
+	return r.varint() != 0
+}
+
+// int32 reads an int32, which is sign extended to 64 bits when negative.
+func (r *protoReader) int32() int32 {
+	return int32(r.varint())
+}
+
+func (r *protoReader) float() float32 {
+	return math.Float32frombits(r.fixed32())
+}
+
+func (r *protoReader) double() float64 {
+	return math.Float64frombits(r.fixed64())
+}
+
+// message reads an embedded message with unmarshal.
+func (r *protoReader) message(unmarshal func(*protoReader)) {
+	b := r.bytes()
+	if r.err != nil {
+		return
+	}
+
+	m := &protoReader{b: b}
+	unmarshal(m)
+	if m.err != nil {
+		r.err = m.err
+	}
+}
+
+// skip skips the field with the tag.
+func (r *protoReader) skip(tag uint64) {
+	switch tag & 7 {
+	case wireVarint:
+		r.varint()
+	case wireFixed64:
+		r.fixed64()
+	case wireBytes:
+		r.bytes()
+	case wireFixed32:
+		r.fixed32()
+	default:
+		if r.err == nil {
+			r.err = errProtobuf
+		}
+	}
+}
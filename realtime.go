@@ -2,12 +2,44 @@ package sl
 
 import (
 	"context"
-	"errors"
+	"time"
+
+	// The realtime date times are in Stockholm time, which must be known
+	// on systems without a time zone database.
+	_ "time/tzdata"
 )
 
 // realtimeEndpoint is the endpoint to the realtime api.
 const realtimeEndpoint = "realtimedeparturesV4.json"
 
+// RealtimeLayout is the layout of the date times in the realtime api,
+// like Transport.ExpectedDateTime. The date times are in Stockholm time.
+const RealtimeLayout = "2006-01-02T15:04:05"
+
+// stockholm is the time zone of the SL API date times.
+var stockholm = mustLoadLocation("Europe/Stockholm")
+
+// mustLoadLocation returns the named location and panics if it can't be
+// loaded, which can't happen with the embedded time zone database.
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic("sl: " + err.Error())
+	}
+
+	return loc
+}
+
+// Stockholm returns the time zone of the SL API date times.
+func Stockholm() *time.Location {
+	return stockholm
+}
+
+// ParseRealtimeTime parses a realtime api date time in the Stockholm time zone.
+func ParseRealtimeTime(s string) (time.Time, error) {
+	return time.ParseInLocation(RealtimeLayout, s, stockholm)
+}
+
 // RealtimeService handles communication with the realtime related
 // methods of the SL API.
 //
@@ -34,6 +66,10 @@ type Transport struct {
 	StopPointNumber      int    `json:"StopPointNumber"`
 	TimeTabledDateTime   string `json:"TimeTabledDateTime"`
 	TransportMode        string `json:"TransportMode"`
+
+	// ScheduledOnly is true if the departure is computed from the timetable
+	// without realtime data, like the departures from gtfs.Feed.Departures.
+	ScheduledOnly bool `json:"ScheduledOnly,omitempty"`
 }
 
 // RealtimeResponse represents the realtime response from SL.
@@ -60,6 +96,17 @@ type RealtimeResponse struct {
 	Trams  []*Transport `json:"Trams"`
 }
 
+// Departures returns all transports in the response, i.e. metros, buses, trains, trams and ships.
+func (r *RealtimeResponse) Departures() []*Transport {
+	var all []*Transport
+
+	for _, list := range [][]*Transport{r.Metros, r.Buses, r.Trains, r.Trams, r.Ships} {
+		all = append(all, list...)
+	}
+
+	return all
+}
+
 // RealtimeResponseData represents the realtime response data SL API.
 type RealtimeResponseData struct {
 	ExecutionTime int               `json:"ExecutionTime"`
@@ -68,6 +115,11 @@ type RealtimeResponseData struct {
 	StatusCode    int               `json:"StatusCode"`
 }
 
+// apiError implements errorResponse.
+func (r *RealtimeResponseData) apiError() error {
+	return messageError(r.Message)
+}
+
 // RealtimeSearchOptions specifies optional parameters to the RealtimeService.Search.
 type RealtimeSearchOptions struct {
 	// Exclude buses if true. Default is false that are reversed to true.
@@ -97,6 +149,12 @@ type RealtimeSearchOptions struct {
 
 // Search does a realtime search and response with the realtime list or a error.
 func (s *RealtimeService) Search(ctx context.Context, opt *RealtimeSearchOptions) (*RealtimeResponse, error) {
+	realtime, _, err := s.SearchWithResponse(ctx, opt)
+	return realtime, err
+}
+
+// SearchWithResponse is like Search but also returns the API response.
+func (s *RealtimeService) SearchWithResponse(ctx context.Context, opt *RealtimeSearchOptions) (*RealtimeResponse, *Response, error) {
 	// Reverse transport options.
 	opt.Bus = !opt.Bus
 	opt.Metro = !opt.Metro
@@ -105,31 +163,24 @@ func (s *RealtimeService) Search(ctx context.Context, opt *RealtimeSearchOptions
 	opt.Tram = !opt.Tram
 
 	if len(opt.Key) == 0 {
-		return nil, ErrNoKey
+		return nil, nil, ErrNoKey
 	}
 
 	if len(opt.SiteID) == 0 {
-		return nil, ErrNoSiteID
+		return nil, nil, ErrNoSiteID
 	}
 
-	r, err := addOptions(realtimeEndpoint, opt)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := s.client.NewRequest("GET", r, nil)
-	if err != nil {
-		return nil, err
+	resp := &RealtimeResponseData{}
+	call := &Call{
+		Service:  "realtime",
+		Method:   "search",
+		Endpoint: realtimeEndpoint,
+		Options:  opt,
+		Result:   resp,
 	}
-
-	var resp *RealtimeResponseData
-	if _, err := s.client.Do(ctx, req, &resp); err != nil {
-		return nil, err
-	}
-
-	if len(resp.Message) > 0 {
-		return nil, errors.New(resp.Message)
+	if err := s.client.call(ctx, call); err != nil {
+		return nil, newResponse(call), err
 	}
 
-	return resp.ResponseData, nil
+	return resp.ResponseData, newResponse(call), nil
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestRealtimeSearch(t *testing.T) {
@@ -28,4 +29,23 @@ func TestRealtimeSearch(t *testing.T) {
 	if realtime.Metros[0].GroupOfLine != "tunnelbanans blå linje" {
 		t.Errorf("Expected 'tunnelbanans blå linje' got %s", realtime.Metros[0].GroupOfLine)
 	}
+
+	if departures := realtime.Departures(); len(departures) != 1 {
+		t.Errorf("Expected 1 departure got %d", len(departures))
+	}
+}
+
+func TestParseRealtimeTime(t *testing.T) {
+	got, err := ParseRealtimeTime("2017-12-18T20:10:45")
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if want := time.Date(2017, 12, 18, 19, 10, 45, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Expected %s got %s", want, got)
+	}
+
+	if _, err := ParseRealtimeTime("20:10"); err == nil {
+		t.Errorf("Expected error got nil")
+	}
 }
@@ -0,0 +1,335 @@
+package sl
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultAbbreviations maps common abbreviations of stop names to the
+// names they are short for. Abbreviations are matched ignoring case,
+// diacritics and punctuation, as whole words.
+var DefaultAbbreviations = map[string]string{
+	"T-C":      "T-Centralen",
+	"TC":       "T-Centralen",
+	"T-Cen":    "T-Centralen",
+	"Cst":      "Stockholm City",
+	"Sthlm C":  "Stockholm City",
+	"Sthlm":    "Stockholm",
+	"S:t":      "Sankt",
+	"St":       "Sankt",
+	"Gamla st": "Gamla stan",
+	"KTH":      "Tekniska högskolan",
+}
+
+// defaultTypeWeights weights the ranking of locations by type, so stations
+// are ranked above points of interest and addresses with similar names.
+var defaultTypeWeights = map[string]float64{
+	"Station": 1,
+	"Poi":     0.8,
+	"Address": 0.7,
+}
+
+// ResolverOptions specifies optional parameters to NewResolver.
+type ResolverOptions struct {
+	// API Key for typeahead searches. Only the local index is searched if empty.
+	Key string
+
+	// Abbreviations in addition to DefaultAbbreviations.
+	Abbreviations map[string]string
+
+	// Ranking weight by location type. Default is 1 for stations, 0.8 for
+	// points of interest and 0.7 for addresses. Other types weigh 0.9.
+	TypeWeights map[string]float64
+
+	// Min confidence of matches. Default is 0.5.
+	MinConfidence float64
+}
+
+// ResolveOptions specifies optional parameters to Resolver.Resolve.
+type ResolveOptions struct {
+	// Near ranks locations closer to the coordinate higher.
+	Near *LatLon
+
+	// Max matches. Default is 10.
+	MaxResults int
+}
+
+// Match represents a location matching a query.
+type Match struct {
+	*Location
+
+	// Confidence of the name matching the query, from 0 to 1 for an exact
+	// match ignoring case, diacritics, punctuation and municipality.
+	Confidence float64
+
+	// Distance in meters from ResolveOptions.Near, zero if not given or
+	// the location has no coordinate.
+	Distance float64
+
+	// Score the matches are ranked by, the confidence weighted by type
+	// and distance.
+	Score float64
+}
+
+// resolverEntry is a location in the local index.
+type resolverEntry struct {
+	location *Location
+	name     string
+	trigrams map[string]bool
+}
+
+// Resolver resolves stop names to locations with tolerance for typos,
+// missing diacritics and abbreviations. It combines typeahead searches with
+// a local index of locations, e.g. the stations of a GTFS feed, and ranks
+// the locations by how well they match, their type and distance.
+type Resolver struct {
+	client        *Client
+	opt           ResolverOptions
+	abbreviations [][2]string
+
+	mu    sync.RWMutex
+	index []*resolverEntry
+}
+
+// NewResolver creates a resolver using the client for typeahead searches.
+func NewResolver(client *Client, opt *ResolverOptions) *Resolver {
+	r := &Resolver{client: client}
+
+	if opt != nil {
+		r.opt = *opt
+	}
+
+	if r.opt.TypeWeights == nil {
+		r.opt.TypeWeights = defaultTypeWeights
+	}
+
+	if r.opt.MinConfidence <= 0 {
+		r.opt.MinConfidence = 0.5
+	}
+
+	abbrs := map[string]string{}
+	for _, m := range []map[string]string{DefaultAbbreviations, r.opt.Abbreviations} {
+		for k, v := range m {
+			abbrs[NameKey(k)] = NameKey(v)
+		}
+	}
+
+	for k, v := range abbrs {
+		r.abbreviations = append(r.abbreviations, [2]string{k, v})
+	}
+
+	// Longer abbreviations first, so Sthlm C is expanded before Sthlm.
+	sort.Slice(r.abbreviations, func(i, j int) bool {
+		a, b := r.abbreviations[i][0], r.abbreviations[j][0]
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+
+	return r
+}
+
+// Add adds locations to the local index.
+func (r *Resolver) Add(locations ...*Location) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range locations {
+		name := r.normalize(l.Name)
+		r.index = append(r.index, &resolverEntry{location: l, name: name, trigrams: trigrams(name)})
+	}
+}
+
+// Resolve returns the locations matching the query, best match first.
+// Typeahead errors are ignored if the local index has locations.
+func (r *Resolver) Resolve(ctx context.Context, query string, opt *ResolveOptions) ([]*Match, error) {
+	if opt == nil {
+		opt = &ResolveOptions{}
+	}
+
+	limit := opt.MaxResults
+	if limit <= 0 {
+		limit = 10
+	}
+
+	q := r.normalize(query)
+	if len(q) == 0 {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	entries := make([]*resolverEntry, len(r.index))
+	copy(entries, r.index)
+	r.mu.RUnlock()
+
+	if len(r.opt.Key) > 0 {
+		locations, err := r.client.Location.Search(ctx, &LocationSearchOptions{
+			Key:          r.opt.Key,
+			SearchString: query,
+		})
+		if err != nil && len(entries) == 0 {
+			return nil, err
+		}
+
+		for _, l := range locations {
+			name := r.normalize(l.Name)
+			entries = append(entries, &resolverEntry{location: l, name: name, trigrams: trigrams(name)})
+		}
+	}
+
+	qt := trigrams(q)
+	seen := map[string]*Match{}
+	var matches []*Match
+
+	for _, e := range entries {
+		confidence := similarity(q, qt, e)
+		if confidence < r.opt.MinConfidence {
+			continue
+		}
+
+		m := &Match{Location: e.location, Confidence: confidence}
+
+		weight, ok := r.opt.TypeWeights[e.location.Type]
+		if !ok {
+			weight = 0.9
+		}
+		m.Score = confidence * weight
+
+		if opt.Near != nil {
+			if ll, err := e.location.LatLon(); err == nil && (ll.Lat != 0 || ll.Lon != 0) {
+				m.Distance = opt.Near.Distance(ll)
+				m.Score *= 0.5 + 0.5*math.Exp(-m.Distance/5000)
+			}
+		}
+
+		// The same site from both the typeahead and the index is kept once.
+		key := e.location.SiteID
+		if len(key) == 0 {
+			key = e.location.Type + "\n" + e.location.Name
+		}
+
+		if prev, ok := seen[key]; ok {
+			if m.Score > prev.Score {
+				*prev = *m
+			}
+			continue
+		}
+
+		seen[key] = m
+		matches = append(matches, m)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Name < matches[j].Name
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+// normalize returns the folded name with abbreviations expanded.
+func (r *Resolver) normalize(name string) string {
+	s := " " + NameKey(name) + " "
+
+	for _, a := range r.abbreviations {
+		s = strings.Replace(s, " "+a[0]+" ", " "+a[1]+" ", -1)
+	}
+
+	return strings.TrimSpace(s)
+}
+
+// similarity returns how well the normalized query q matches the entry,
+// from 0 to 1. Prefixes of the name or a word in it score high, other
+// names are scored by edit distance and shared trigrams.
+func similarity(q string, qt map[string]bool, e *resolverEntry) float64 {
+	name := e.name
+
+	switch {
+	case q == name:
+		return 1
+	case strings.HasPrefix(name, q):
+		return 0.9 + 0.09*float64(len(q))/float64(len(name))
+	case strings.Contains(" "+name, " "+q):
+		return 0.8 + 0.09*float64(len(q))/float64(len(name))
+	}
+
+	score := 1 - float64(levenshtein(q, name))/float64(max(utf8.RuneCountInString(q), utf8.RuneCountInString(name)))
+
+	// A query with a typo in a prefix of a longer name.
+	if n := utf8.RuneCountInString(q); n >= 4 && utf8.RuneCountInString(name) > n {
+		prefix := string([]rune(name)[:n])
+		if s := 0.85 * (1 - float64(levenshtein(q, prefix))/float64(n)); s > score {
+			score = s
+		}
+	}
+
+	if s := jaccard(qt, e.trigrams); s > score {
+		score = s
+	}
+
+	return score
+}
+
+// levenshtein returns the edit distance between a and b in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// trigrams returns the trigrams of s padded with spaces.
+func trigrams(s string) map[string]bool {
+	r := []rune("  " + s + " ")
+	t := map[string]bool{}
+
+	for i := 0; i+3 <= len(r); i++ {
+		t[string(r[i:i+3])] = true
+	}
+
+	return t
+}
+
+// jaccard returns the jaccard index of two sets.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+
+	n := 0
+	for k := range a {
+		if b[k] {
+			n++
+		}
+	}
+
+	return float64(n) / float64(len(a)+len(b)-n)
+}
@@ -0,0 +1,134 @@
+package sl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func testResolver(client *Client, opt *ResolverOptions) *Resolver {
+	r := NewResolver(client, opt)
+	r.Add(
+		&Location{Name: "T-Centralen (Stockholm)", SiteID: "9001", Type: "Station", X: "18061486", Y: "59331134"},
+		&Location{Name: "Slussen (Stockholm)", SiteID: "9192", Type: "Station", X: "18071860", Y: "59320284"},
+		&Location{Name: "Södra station (Stockholm)", SiteID: "9530", Type: "Station", X: "18061405", Y: "59313389"},
+		&Location{Name: "S:t Eriksplan (Stockholm)", SiteID: "9118", Type: "Station", X: "18037219", Y: "59339746"},
+		&Location{Name: "Storgatan (Solna)", SiteID: "3401", Type: "Station", X: "18001000", Y: "59360000"},
+		&Location{Name: "Storgatan (Södertälje)", SiteID: "7401", Type: "Station", X: "17627000", Y: "59196000"},
+	)
+	return r
+}
+
+func TestResolverLocal(t *testing.T) {
+	r := testResolver(nil, nil)
+
+	tests := []struct {
+		query      string
+		siteID     string
+		confidence float64
+	}{
+		{"T-C", "9001", 1},
+		{"t-centralen", "9001", 1},
+		{"Sodra station", "9530", 1},
+		{"sodra", "9530", 0.9},
+		{"St Eriksplan", "9118", 1},
+		{"Slusen", "9192", 0.85},
+		{"eriksplan", "9118", 0.8},
+	}
+
+	for _, tt := range tests {
+		matches, err := r.Resolve(context.Background(), tt.query, nil)
+		if err != nil {
+			t.Fatalf("Expected nil got error: %v", err)
+		}
+
+		if len(matches) == 0 || matches[0].SiteID != tt.siteID {
+			t.Errorf("Expected %s for %q got %v", tt.siteID, tt.query, matches)
+			continue
+		}
+
+		if c := matches[0].Confidence; c < tt.confidence || c > 1 {
+			t.Errorf("Expected confidence at least %v for %q got %v", tt.confidence, tt.query, c)
+		}
+	}
+
+	if matches, _ := r.Resolve(context.Background(), "Kungsträdgården", nil); len(matches) != 0 {
+		t.Errorf("Expected no matches got %v", matches[0].Location)
+	}
+}
+
+func TestResolverNear(t *testing.T) {
+	r := testResolver(nil, nil)
+
+	matches, err := r.Resolve(context.Background(), "storgatan", &ResolveOptions{
+		Near:       &LatLon{Lat: 59.19, Lon: 17.62},
+		MaxResults: 1,
+	})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if len(matches) != 1 || matches[0].SiteID != "7401" {
+		t.Fatalf("Expected Storgatan in Södertälje got %v", matches)
+	}
+
+	if d := matches[0].Distance; d < 500 || d > 1500 {
+		t.Errorf("Expected about 1 km got %v", d)
+	}
+}
+
+func TestResolverTypeahead(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+
+	fail := false
+	mux.HandleFunc("/typeahead.json", func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		if got := r.URL.Query().Get("searchstring"); got != "Medborgarplatsen" {
+			t.Errorf("Expected 'Medborgarplatsen' got %s", got)
+		}
+
+		fmt.Fprint(w, `{"StatusCode":0,"Message":null,"ExecutionTime":0,"ResponseData":[{"Name":"Medborgarplatsen 3, Stockholm","SiteId":"","Type":"Address","X":"18073000","Y":"59314000"},{"Name":"Medborgarplatsen (Stockholm)","SiteId":"9191","Type":"Station","X":"18073550","Y":"59314382"},{"Name":"Slussen (Stockholm)","SiteId":"9192","Type":"Station","X":"18071860","Y":"59320284"}]}`)
+	})
+
+	r := testResolver(client, &ResolverOptions{Key: "XXXX"})
+
+	matches, err := r.Resolve(context.Background(), "Medborgarplatsen", nil)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 matches got %d", len(matches))
+	}
+
+	// The station is ranked above the address SL returned first.
+	if matches[0].SiteID != "9191" || matches[0].Confidence != 1 || matches[1].Type != "Address" {
+		t.Errorf("Expected station before address got %v and %v", matches[0].Location, matches[1].Location)
+	}
+
+	fail = true
+
+	if _, err := r.Resolve(context.Background(), "Medborgarplatsen", nil); err != nil {
+		t.Errorf("Expected nil with a local index got error: %v", err)
+	}
+
+	if _, err := NewResolver(client, &ResolverOptions{Key: "XXXX"}).Resolve(context.Background(), "Medborgarplatsen", nil); err == nil {
+		t.Errorf("Expected error without a local index got nil")
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	if d := levenshtein("slussen", "slusen"); d != 1 {
+		t.Errorf("Expected 1 got %d", d)
+	}
+
+	if d := levenshtein("", "abc"); d != 3 {
+		t.Errorf("Expected 3 got %d", d)
+	}
+}
@@ -0,0 +1,110 @@
+package sl
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Response wraps the http response from the SL API and adds the parsed
+// rate limit headers and the API status.
+type Response struct {
+	*http.Response
+
+	// Rate contains the rate limit and quota values from the response headers.
+	Rate Rate
+
+	// APIStatusCode is the SL status code in the response body. Only the
+	// location and realtime APIs return it, zero means success.
+	APIStatusCode int
+
+	// ExecutionTime is the time in milliseconds SL spent on the request.
+	// Only the location and realtime APIs return it.
+	ExecutionTime int
+
+	// Duration is the time it took to send the request and decode the response.
+	Duration time.Duration
+
+	// Stale is true if the response is a previous response served by
+	// StaleFallback because the API could not be reached.
+	Stale bool
+
+	// Age is the age of a stale response.
+	Age time.Duration
+}
+
+// Rate represents the rate limit and quota headers of a response. Values
+// not present in the response headers are zero.
+type Rate struct {
+	// Limit, Remaining and Reset are read from the X-RateLimit-Limit,
+	// X-RateLimit-Remaining and X-RateLimit-Reset headers.
+	Limit     int
+	Remaining int
+	Reset     time.Time
+
+	// Per minute limit read from X-RateLimit-Limit-Minute and X-RateLimit-Remaining-Minute.
+	MinuteLimit     int
+	MinuteRemaining int
+
+	// Per month quota read from X-RateLimit-Limit-Month and X-RateLimit-Remaining-Month.
+	MonthLimit     int
+	MonthRemaining int
+
+	// RetryAfter is read from the Retry-After header.
+	RetryAfter time.Duration
+}
+
+// newResponse returns the response of a finished call or nil if no request was sent.
+func newResponse(call *Call) *Response {
+	if call.Response == nil {
+		return nil
+	}
+
+	r := &Response{
+		Response: call.Response,
+		Rate:     parseRate(call.Response.Header),
+		Duration: call.Duration,
+		Stale:    call.Stale,
+		Age:      call.Age,
+	}
+
+	r.APIStatusCode, r.ExecutionTime, _ = apiStatus(call.Result)
+
+	return r
+}
+
+// parseRate parses the rate limit headers.
+func parseRate(h http.Header) Rate {
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(h.Get(key))
+		return n
+	}
+
+	r := Rate{
+		Limit:           atoi("X-RateLimit-Limit"),
+		Remaining:       atoi("X-RateLimit-Remaining"),
+		MinuteLimit:     atoi("X-RateLimit-Limit-Minute"),
+		MinuteRemaining: atoi("X-RateLimit-Remaining-Minute"),
+		MonthLimit:      atoi("X-RateLimit-Limit-Month"),
+		MonthRemaining:  atoi("X-RateLimit-Remaining-Month"),
+		RetryAfter:      time.Duration(atoi("Retry-After")) * time.Second,
+	}
+
+	if reset := atoi("X-RateLimit-Reset"); reset > 0 {
+		r.Reset = time.Unix(int64(reset), 0)
+	}
+
+	return r
+}
+
+// apiStatus returns the SL status code and execution time from decoded response data.
+func apiStatus(v interface{}) (int, int, bool) {
+	switch r := v.(type) {
+	case *TypeaheadResponseData:
+		return r.StatusCode, r.ExecutionTime, true
+	case *RealtimeResponseData:
+		return r.StatusCode, r.ExecutionTime, true
+	default:
+		return 0, 0, false
+	}
+}
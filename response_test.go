@@ -0,0 +1,78 @@
+package sl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSearchWithResponse(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+	mux.HandleFunc("/realtimedeparturesV4.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit-Minute", "30")
+		w.Header().Set("X-RateLimit-Remaining-Minute", "29")
+		w.Header().Set("X-RateLimit-Limit-Month", "10000")
+		w.Header().Set("X-RateLimit-Remaining-Month", "9000")
+		w.Header().Set("X-RateLimit-Reset", "1513627819")
+		fmt.Fprint(w, `{"StatusCode":0,"Message":null,"ExecutionTime":621,"ResponseData":{"Metros":[{"LineNumber":"11"}]}}`)
+	})
+
+	realtime, resp, err := client.Realtime.SearchWithResponse(context.Background(), &RealtimeSearchOptions{
+		Key:    "XXXX",
+		SiteID: "1002",
+	})
+
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if realtime.Metros[0].LineNumber != "11" {
+		t.Errorf("Expected '11' got %s", realtime.Metros[0].LineNumber)
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.ExecutionTime != 621 || resp.APIStatusCode != 0 {
+		t.Errorf("Expected status 200 and execution time 621 got %d and %d", resp.StatusCode, resp.ExecutionTime)
+	}
+
+	want := Rate{
+		MinuteLimit:     30,
+		MinuteRemaining: 29,
+		MonthLimit:      10000,
+		MonthRemaining:  9000,
+		Reset:           time.Unix(1513627819, 0),
+	}
+
+	if resp.Rate != want {
+		t.Errorf("Expected %+v got %+v", want, resp.Rate)
+	}
+
+	if resp.Duration <= 0 {
+		t.Errorf("Expected positive duration got %s", resp.Duration)
+	}
+}
+
+func TestTripWithResponseError(t *testing.T) {
+	client, mux, _, teardown := setupClient()
+	defer teardown()
+	mux.HandleFunc("/TravelplannerV3/trip.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		fmt.Fprint(w, `{"errorCode":"API_QUOTA","errorText":"Quota exceeded"}`)
+	})
+
+	_, resp, err := client.TravelPlanner.TripWithResponse(context.Background(), &TripOptions{Key: "XXXX"})
+
+	if err == nil || err.Error() != "Quota exceeded" {
+		t.Errorf("Expected 'Quota exceeded' got %v", err)
+	}
+
+	if resp == nil || resp.Rate.RetryAfter != time.Minute {
+		t.Errorf("Expected response with retry after 1m got %v", resp)
+	}
+
+	if _, resp, err := client.TravelPlanner.TripWithResponse(context.Background(), &TripOptions{}); err != ErrNoKey || resp != nil {
+		t.Errorf("Expected %v and nil response got %v and %v", ErrNoKey, err, resp)
+	}
+}
@@ -0,0 +1,213 @@
+package siri
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+// maxBodySize is the max size of a SIRI request body.
+const maxBodySize = 1 << 20
+
+// HandlerOptions specifies optional parameters to NewHandler.
+type HandlerOptions struct {
+	// API Key for the realtime API.
+	Key string
+
+	// ProducerRef of the service deliveries. Default is SL.
+	ProducerRef string
+
+	// Sites whose deviations are returned for situation exchange requests
+	// without a stop monitoring request.
+	Sites []string
+}
+
+// handler implements http.Handler.
+type handler struct {
+	client *sl.Client
+	opt    HandlerOptions
+	now    func() time.Time
+}
+
+// NewHandler returns a handler that answers SIRI stop monitoring and
+// situation exchange requests by calling RealtimeService.Search.
+//
+// POST requests take a SIRI ServiceRequest. GET requests take the
+// MonitoringRef query parameter with a site id, optionally PreviewInterval
+// as a duration like PT30M, and return both deliveries.
+func NewHandler(client *sl.Client, opt *HandlerOptions) http.Handler {
+	h := &handler{client: client, now: time.Now}
+
+	if opt != nil {
+		h.opt = *opt
+	}
+
+	if len(h.opt.ProducerRef) == 0 {
+		h.opt.ProducerRef = "SL"
+	}
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req *ServiceRequest
+
+	switch r.Method {
+	case http.MethodGet:
+		refs := r.URL.Query()["MonitoringRef"]
+		if len(refs) == 0 {
+			http.Error(w, "MonitoringRef can't be empty", http.StatusBadRequest)
+			return
+		}
+
+		req = &ServiceRequest{SituationExchangeRequest: []*SituationExchangeRequest{{Version: Version}}}
+		for _, ref := range refs {
+			req.StopMonitoringRequest = append(req.StopMonitoringRequest, &StopMonitoringRequest{
+				Version:         Version,
+				MonitoringRef:   ref,
+				PreviewInterval: r.URL.Query().Get("PreviewInterval"),
+			})
+		}
+	case http.MethodPost:
+		var s Siri
+		if err := xml.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&s); err != nil || s.ServiceRequest == nil {
+			http.Error(w, "invalid SIRI service request", http.StatusBadRequest)
+			return
+		}
+		req = s.ServiceRequest
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if len(req.StopMonitoringRequest) == 0 && len(req.SituationExchangeRequest) == 0 {
+		http.Error(w, "no stop monitoring or situation exchange request", http.StatusBadRequest)
+		return
+	}
+
+	now := h.now()
+	delivery := &ServiceDelivery{
+		ResponseTimestamp: now,
+		ProducerRef:       h.opt.ProducerRef,
+		Status:            true,
+	}
+
+	// Deviations from the monitored stops, or the configured sites if
+	// no stops are monitored, make up the situations.
+	var responses []*sl.RealtimeResponse
+	var sxErr error
+
+	for _, smr := range req.StopMonitoringRequest {
+		resp, err := h.search(r.Context(), smr.MonitoringRef, previewMinutes(smr.PreviewInterval))
+		if err != nil {
+			delivery.Status = false
+			delivery.StopMonitoringDelivery = append(delivery.StopMonitoringDelivery, &StopMonitoringDelivery{
+				Version:           Version,
+				ResponseTimestamp: now,
+				ErrorCondition:    &ErrorCondition{err.Error()},
+			})
+			continue
+		}
+
+		sm := StopMonitoring(resp, smr.MonitoringRef, now)
+		if smr.MaximumStopVisits > 0 && len(sm.MonitoredStopVisit) > smr.MaximumStopVisits {
+			sm.MonitoredStopVisit = sm.MonitoredStopVisit[:smr.MaximumStopVisits]
+		}
+
+		delivery.StopMonitoringDelivery = append(delivery.StopMonitoringDelivery, sm)
+		responses = append(responses, resp)
+	}
+
+	if len(req.SituationExchangeRequest) > 0 {
+		if len(req.StopMonitoringRequest) == 0 {
+			for _, site := range h.opt.Sites {
+				resp, err := h.search(r.Context(), site, 0)
+				if err != nil {
+					sxErr = err
+					continue
+				}
+				responses = append(responses, resp)
+			}
+		}
+
+		delivery.SituationExchangeDelivery = append(delivery.SituationExchangeDelivery, h.situations(responses, now, sxErr))
+	}
+
+	// Encode before writing so an error can still be sent as a response.
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&Siri{Version: Version, ServiceDelivery: delivery}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// search calls RealtimeService.Search for the site.
+func (h *handler) search(ctx context.Context, siteID string, window int) (*sl.RealtimeResponse, error) {
+	return h.client.Realtime.Search(ctx, &sl.RealtimeSearchOptions{
+		Key:        h.opt.Key,
+		SiteID:     siteID,
+		TimeWindow: window,
+	})
+}
+
+// situations merges the situations of the responses.
+func (h *handler) situations(responses []*sl.RealtimeResponse, now time.Time, err error) *SituationExchangeDelivery {
+	sx := &SituationExchangeDelivery{
+		Version:           Version,
+		ResponseTimestamp: now,
+		Status:            err == nil,
+	}
+
+	if err != nil {
+		sx.ErrorCondition = &ErrorCondition{err.Error()}
+	}
+
+	merged := &sl.RealtimeResponse{}
+	for _, r := range responses {
+		merged.Metros = append(merged.Metros, r.Metros...)
+		merged.Buses = append(merged.Buses, r.Buses...)
+		merged.Trains = append(merged.Trains, r.Trains...)
+		merged.Trams = append(merged.Trams, r.Trams...)
+		merged.Ships = append(merged.Ships, r.Ships...)
+		merged.StopPointDeviations = append(merged.StopPointDeviations, r.StopPointDeviations...)
+	}
+
+	sx.Situations = SituationExchange(merged, now).Situations
+
+	return sx
+}
+
+// previewMinutes returns the minutes of a preview interval like PT30M,
+// capped to the 60 minutes the realtime API allows. Zero is returned for
+// invalid intervals so the API default is used.
+func previewMinutes(interval string) int {
+	if !strings.HasPrefix(interval, "PT") {
+		return 0
+	}
+
+	d, err := time.ParseDuration(strings.ToLower(interval[2:]))
+	if err != nil || d <= 0 {
+		return 0
+	}
+
+	if d > time.Hour {
+		d = time.Hour
+	}
+
+	return int(d / time.Minute)
+}
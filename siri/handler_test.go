@@ -0,0 +1,114 @@
+package siri
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go-sl/sltest"
+)
+
+func TestHandler(t *testing.T) {
+	server := sltest.NewServer()
+	defer server.Close()
+
+	h := NewHandler(server.Client(), &HandlerOptions{Key: "XXXX", Sites: []string{"1051"}})
+
+	body := `<?xml version="1.0"?>
+<Siri xmlns="http://www.siri.org.uk/siri" version="2.0">
+  <ServiceRequest>
+    <RequestorRef>pis</RequestorRef>
+    <StopMonitoringRequest version="2.0">
+      <MonitoringRef>1051</MonitoringRef>
+      <PreviewInterval>PT90M</PreviewInterval>
+    </StopMonitoringRequest>
+  </ServiceRequest>
+</Siri>`
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/siri", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 got %d: %s", w.Code, w.Body)
+	}
+
+	var s Siri
+	if err := xml.Unmarshal(w.Body.Bytes(), &s); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	d := s.ServiceDelivery
+	if d == nil || !d.Status || len(d.StopMonitoringDelivery) != 1 || len(d.SituationExchangeDelivery) != 0 {
+		t.Fatalf("Expected one stop monitoring delivery got %v", d)
+	}
+
+	if visits := d.StopMonitoringDelivery[0].MonitoredStopVisit; len(visits) != 1 || visits[0].MonitoredVehicleJourney.LineRef != "11" {
+		t.Errorf("Expected metro 11 got %v", visits)
+	}
+
+	reqs := server.Requests()
+	if len(reqs) != 1 || reqs[0].Query.Get("siteId") != "1051" || reqs[0].Query.Get("timeWindow") != "60" {
+		t.Errorf("Expected realtime search for 1051 within 60 minutes got %v", reqs)
+	}
+}
+
+func TestHandlerGet(t *testing.T) {
+	server := sltest.NewServer()
+	defer server.Close()
+
+	server.SetError(sltest.EndpointRealtime, http.StatusInternalServerError, "")
+
+	h := NewHandler(server.Client(), &HandlerOptions{Key: "XXXX"})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/siri?MonitoringRef=1051", nil))
+
+	var s Siri
+	if err := xml.Unmarshal(w.Body.Bytes(), &s); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	d := s.ServiceDelivery
+	if d.Status || len(d.StopMonitoringDelivery) != 1 || d.StopMonitoringDelivery[0].ErrorCondition == nil {
+		t.Errorf("Expected stop monitoring delivery with error condition got %v", d)
+	}
+
+	if len(d.SituationExchangeDelivery) != 1 {
+		t.Errorf("Expected situation exchange delivery got %v", d.SituationExchangeDelivery)
+	}
+}
+
+func TestHandlerBadRequest(t *testing.T) {
+	h := NewHandler(sltest.NewServer().Client(), nil)
+
+	tests := []struct {
+		method string
+		body   string
+		code   int
+	}{
+		{"POST", "<Siri>", http.StatusBadRequest},
+		{"POST", `<Siri xmlns="http://www.siri.org.uk/siri"><ServiceRequest></ServiceRequest></Siri>`, http.StatusBadRequest},
+		{"GET", "", http.StatusBadRequest},
+		{"DELETE", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tt.method, "/siri", strings.NewReader(tt.body)))
+		if w.Code != tt.code {
+			t.Errorf("%s %q: Expected %d got %d", tt.method, tt.body, tt.code, w.Code)
+		}
+	}
+}
+
+func TestPreviewMinutes(t *testing.T) {
+	tests := map[string]int{"PT30M": 30, "PT1H30M": 60, "PT90S": 1, "": 0, "P1D": 0, "PTXM": 0}
+
+	for in, want := range tests {
+		if got := previewMinutes(in); got != want {
+			t.Errorf("%q: Expected %d got %d", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// Package siri converts SL realtime departures and deviations to SIRI 2.0
+// StopMonitoring and SituationExchange deliveries, for passenger
+// information systems that only consume SIRI.
+//
+//	sm := siri.StopMonitoring(realtime, "9192", time.Now())
+//	sx := siri.SituationExchange(realtime, time.Now())
+//	b, err := xml.Marshal(siri.NewSiri("SL", time.Now(), sm, sx))
+package siri
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+// Namespace is the SIRI XML namespace.
+const Namespace = "http://www.siri.org.uk/siri"
+
+// Version is the SIRI version of the deliveries.
+const Version = "2.0"
+
+// Siri represents the SIRI root element.
+type Siri struct {
+	XMLName         xml.Name         `xml:"http://www.siri.org.uk/siri Siri"`
+	Version         string           `xml:"version,attr"`
+	ServiceRequest  *ServiceRequest  `xml:"ServiceRequest,omitempty"`
+	ServiceDelivery *ServiceDelivery `xml:"ServiceDelivery,omitempty"`
+}
+
+// NewSiri returns a SIRI document with a service delivery of the deliveries.
+func NewSiri(producerRef string, now time.Time, sm *StopMonitoringDelivery, sx *SituationExchangeDelivery) *Siri {
+	d := &ServiceDelivery{
+		ResponseTimestamp: now,
+		ProducerRef:       producerRef,
+		Status:            true,
+	}
+
+	if sm != nil {
+		d.StopMonitoringDelivery = append(d.StopMonitoringDelivery, sm)
+	}
+
+	if sx != nil {
+		d.SituationExchangeDelivery = append(d.SituationExchangeDelivery, sx)
+	}
+
+	return &Siri{Version: Version, ServiceDelivery: d}
+}
+
+// ServiceRequest represents a SIRI service request.
+type ServiceRequest struct {
+	RequestTimestamp         time.Time                   `xml:"RequestTimestamp"`
+	RequestorRef             string                      `xml:"RequestorRef"`
+	StopMonitoringRequest    []*StopMonitoringRequest    `xml:"StopMonitoringRequest"`
+	SituationExchangeRequest []*SituationExchangeRequest `xml:"SituationExchangeRequest"`
+}
+
+// StopMonitoringRequest represents a request for departures from a stop.
+type StopMonitoringRequest struct {
+	Version           string `xml:"version,attr"`
+	MonitoringRef     string `xml:"MonitoringRef"`
+	PreviewInterval   string `xml:"PreviewInterval,omitempty"`
+	MaximumStopVisits int    `xml:"MaximumStopVisits,omitempty"`
+}
+
+// SituationExchangeRequest represents a request for situations.
+type SituationExchangeRequest struct {
+	Version string `xml:"version,attr"`
+}
+
+// ServiceDelivery represents a SIRI service delivery.
+type ServiceDelivery struct {
+	ResponseTimestamp         time.Time                    `xml:"ResponseTimestamp"`
+	ProducerRef               string                       `xml:"ProducerRef,omitempty"`
+	Status                    bool                         `xml:"Status"`
+	StopMonitoringDelivery    []*StopMonitoringDelivery    `xml:"StopMonitoringDelivery"`
+	SituationExchangeDelivery []*SituationExchangeDelivery `xml:"SituationExchangeDelivery"`
+}
+
+// ErrorCondition represents an error in a delivery.
+type ErrorCondition struct {
+	Description string `xml:"OtherError>ErrorText"`
+}
+
+// StopMonitoringDelivery represents the departures from a stop.
+type StopMonitoringDelivery struct {
+	Version            string                `xml:"version,attr"`
+	ResponseTimestamp  time.Time             `xml:"ResponseTimestamp"`
+	Status             bool                  `xml:"Status"`
+	ErrorCondition     *ErrorCondition       `xml:"ErrorCondition,omitempty"`
+	MonitoredStopVisit []*MonitoredStopVisit `xml:"MonitoredStopVisit"`
+}
+
+// MonitoredStopVisit represents a departure from a stop.
+type MonitoredStopVisit struct {
+	RecordedAtTime          time.Time                `xml:"RecordedAtTime"`
+	MonitoringRef           string                   `xml:"MonitoringRef"`
+	MonitoredVehicleJourney *MonitoredVehicleJourney `xml:"MonitoredVehicleJourney"`
+}
+
+// MonitoredVehicleJourney represents the vehicle journey of a departure.
+type MonitoredVehicleJourney struct {
+	LineRef                 string                   `xml:"LineRef"`
+	DirectionRef            string                   `xml:"DirectionRef"`
+	FramedVehicleJourneyRef *FramedVehicleJourneyRef `xml:"FramedVehicleJourneyRef,omitempty"`
+	VehicleMode             string                   `xml:"VehicleMode,omitempty"`
+	PublishedLineName       string                   `xml:"PublishedLineName"`
+	DestinationName         string                   `xml:"DestinationName"`
+	Monitored               bool                     `xml:"Monitored"`
+	SituationRef            []*SituationRef          `xml:"SituationRef"`
+	MonitoredCall           *MonitoredCall           `xml:"MonitoredCall"`
+}
+
+// FramedVehicleJourneyRef identifies a vehicle journey on a date.
+type FramedVehicleJourneyRef struct {
+	DataFrameRef           string `xml:"DataFrameRef"`
+	DatedVehicleJourneyRef string `xml:"DatedVehicleJourneyRef"`
+}
+
+// SituationRef refers to a situation in a SituationExchangeDelivery.
+type SituationRef struct {
+	SituationNumber string `xml:"SituationNumber"`
+}
+
+// MonitoredCall represents the call at the monitored stop.
+type MonitoredCall struct {
+	StopPointRef              string     `xml:"StopPointRef,omitempty"`
+	StopPointName             string     `xml:"StopPointName"`
+	AimedDepartureTime        *time.Time `xml:"AimedDepartureTime,omitempty"`
+	ExpectedDepartureTime     *time.Time `xml:"ExpectedDepartureTime,omitempty"`
+	DeparturePlatformName     string     `xml:"DeparturePlatformName,omitempty"`
+	DepartureBoardingActivity string     `xml:"DepartureBoardingActivity,omitempty"`
+}
+
+// SituationExchangeDelivery represents situations affecting traffic.
+type SituationExchangeDelivery struct {
+	Version           string                `xml:"version,attr"`
+	ResponseTimestamp time.Time             `xml:"ResponseTimestamp"`
+	Status            bool                  `xml:"Status"`
+	ErrorCondition    *ErrorCondition       `xml:"ErrorCondition,omitempty"`
+	Situations        []*PtSituationElement `xml:"Situations>PtSituationElement"`
+}
+
+// PtSituationElement represents a situation.
+type PtSituationElement struct {
+	CreationTime    time.Time `xml:"CreationTime"`
+	SituationNumber string    `xml:"SituationNumber"`
+	Progress        string    `xml:"Progress"`
+	Severity        string    `xml:"Severity"`
+	Priority        int       `xml:"Priority,omitempty"`
+	Summary         string    `xml:"Summary"`
+	Description     string    `xml:"Description,omitempty"`
+	Affects         *Affects  `xml:"Affects,omitempty"`
+}
+
+// Affects represents the lines and stops affected by a situation.
+type Affects struct {
+	Lines      []*AffectedLine      `xml:"Networks>AffectedNetwork>AffectedLine"`
+	StopPlaces []*AffectedStopPlace `xml:"StopPlaces>AffectedStopPlace"`
+}
+
+// AffectedLine represents a line affected by a situation.
+type AffectedLine struct {
+	LineRef string `xml:"LineRef"`
+}
+
+// AffectedStopPlace represents a stop affected by a situation.
+type AffectedStopPlace struct {
+	StopPlaceRef  string `xml:"StopPlaceRef"`
+	StopPlaceName string `xml:"PlaceName,omitempty"`
+}
+
+// StopMonitoring converts the realtime response for the site to a stop
+// monitoring delivery. Departures refer to the situations returned by
+// SituationExchange for the same response.
+func StopMonitoring(r *sl.RealtimeResponse, siteID string, now time.Time) *StopMonitoringDelivery {
+	d := &StopMonitoringDelivery{
+		Version:           Version,
+		ResponseTimestamp: now,
+		Status:            true,
+	}
+
+	recorded := now
+	if t, ok := parseTime(r.LatestUpdate); ok {
+		recorded = t
+	}
+
+	for _, t := range r.Departures() {
+		j := &MonitoredVehicleJourney{
+			LineRef:           t.LineNumber,
+			DirectionRef:      strconv.Itoa(t.JourneyDirection),
+			VehicleMode:       vehicleMode(t.TransportMode),
+			PublishedLineName: t.LineNumber,
+			DestinationName:   t.Destination,
+			Monitored:         !t.ScheduledOnly,
+			MonitoredCall: &MonitoredCall{
+				StopPointName:         t.StopAreaName,
+				DeparturePlatformName: t.StopPointDesignation,
+			},
+		}
+
+		if t.StopPointNumber > 0 {
+			j.MonitoredCall.StopPointRef = strconv.Itoa(t.StopPointNumber)
+		}
+
+		if aimed, ok := parseTime(t.TimeTabledDateTime); ok {
+			j.MonitoredCall.AimedDepartureTime = &aimed
+			if t.JourneyNumber > 0 {
+				j.FramedVehicleJourneyRef = &FramedVehicleJourneyRef{
+					DataFrameRef:           aimed.Format("2006-01-02"),
+					DatedVehicleJourneyRef: strconv.Itoa(t.JourneyNumber),
+				}
+			}
+		}
+
+		if expected, ok := parseTime(t.ExpectedDateTime); ok {
+			j.MonitoredCall.ExpectedDepartureTime = &expected
+		}
+
+		for _, dev := range t.Deviations {
+			j.SituationRef = append(j.SituationRef, &SituationRef{situationNumber(dev.Text)})
+			if strings.Contains(strings.ToLower(dev.Consequence), "cancel") {
+				j.MonitoredCall.DepartureBoardingActivity = "noBoarding"
+			}
+		}
+
+		d.MonitoredStopVisit = append(d.MonitoredStopVisit, &MonitoredStopVisit{
+			RecordedAtTime:          recorded,
+			MonitoringRef:           siteID,
+			MonitoredVehicleJourney: j,
+		})
+	}
+
+	return d
+}
+
+// SituationExchange converts the deviations in the realtime response to a
+// situation exchange delivery. Deviations with the same text are one
+// situation affecting all their lines and stops.
+func SituationExchange(r *sl.RealtimeResponse, now time.Time) *SituationExchangeDelivery {
+	d := &SituationExchangeDelivery{
+		Version:           Version,
+		ResponseTimestamp: now,
+		Status:            true,
+	}
+
+	situations := map[string]*PtSituationElement{}
+	situation := func(text string, level int) *PtSituationElement {
+		n := situationNumber(text)
+		if s, ok := situations[n]; ok {
+			return s
+		}
+
+		summary, description := text, ""
+		if i := strings.IndexAny(text, ".\n"); i > 0 && i < len(text)-1 {
+			summary, description = strings.TrimSpace(text[:i+1]), strings.TrimSpace(text[i+1:])
+		}
+
+		s := &PtSituationElement{
+			CreationTime:    now,
+			SituationNumber: n,
+			Progress:        "open",
+			Severity:        severity(level),
+			Priority:        level,
+			Summary:         summary,
+			Description:     description,
+			Affects:         &Affects{},
+		}
+
+		situations[n] = s
+		d.Situations = append(d.Situations, s)
+
+		return s
+	}
+
+	for _, dev := range r.StopPointDeviations {
+		s := situation(dev.Deviation.Text, dev.Deviation.ImportanceLevel)
+		s.Affects.addStop(strconv.Itoa(dev.StopInfo.StopAreaNumber), dev.StopInfo.StopAreaName)
+	}
+
+	for _, t := range r.Departures() {
+		for _, dev := range t.Deviations {
+			s := situation(dev.Text, dev.ImportanceLevel)
+			s.Affects.addLine(t.LineNumber)
+			s.Affects.addStop(strconv.Itoa(t.StopAreaNumber), t.StopAreaName)
+		}
+	}
+
+	return d
+}
+
+// addLine adds the line unless it is already affected.
+func (a *Affects) addLine(ref string) {
+	for _, l := range a.Lines {
+		if l.LineRef == ref {
+			return
+		}
+	}
+
+	a.Lines = append(a.Lines, &AffectedLine{ref})
+}
+
+// addStop adds the stop unless it is already affected.
+func (a *Affects) addStop(ref, name string) {
+	if ref == "0" {
+		return
+	}
+
+	for _, s := range a.StopPlaces {
+		if s.StopPlaceRef == ref {
+			return
+		}
+	}
+
+	a.StopPlaces = append(a.StopPlaces, &AffectedStopPlace{ref, name})
+}
+
+// situationNumber returns a stable situation number for the deviation text.
+func situationNumber(text string) string {
+	sum := sha1.Sum([]byte(text))
+	return "SL:" + hex.EncodeToString(sum[:8])
+}
+
+// severity returns the SIRI severity of an importance level.
+func severity(level int) string {
+	switch {
+	case level >= 7:
+		return "severe"
+	case level >= 4:
+		return "normal"
+	case level > 0:
+		return "slight"
+	default:
+		return "unknown"
+	}
+}
+
+// vehicleMode returns the SIRI vehicle mode of a transport mode.
+func vehicleMode(mode string) string {
+	switch mode {
+	case "METRO":
+		return "metro"
+	case "BUS":
+		return "bus"
+	case "TRAIN":
+		return "rail"
+	case "TRAM":
+		return "tram"
+	case "SHIP":
+		return "ferry"
+	default:
+		return ""
+	}
+}
+
+// parseTime parses a realtime API date time in the Stockholm time zone.
+func parseTime(s string) (time.Time, bool) {
+	t, err := sl.ParseRealtimeTime(s)
+	return t, err == nil
+}
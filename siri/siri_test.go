@@ -0,0 +1,109 @@
+package siri
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+const realtimeFixture = `{"LatestUpdate":"2017-12-18T20:10:19","Metros":[{"GroupOfLine":"tunnelbanans blå linje","TransportMode":"METRO","LineNumber":"11","Destination":"Akalla","JourneyDirection":1,"StopAreaName":"T-Centralen","StopAreaNumber":1051,"StopPointNumber":3051,"StopPointDesignation":"5","TimeTabledDateTime":"2017-12-18T20:10:45","ExpectedDateTime":"2017-12-18T20:11:03","JourneyNumber":30531,"Deviations":[{"Text":"Hissen ur funktion. Använd trapporna.","Consequence":"INFORMATION","ImportanceLevel":5}]}],"Buses":[{"TransportMode":"BUS","LineNumber":"4","Destination":"Radiohuset","JourneyDirection":2,"StopAreaName":"T-Centralen","StopAreaNumber":1051,"StopPointNumber":10307,"TimeTabledDateTime":"2017-12-18T20:15:00","ExpectedDateTime":"2017-12-18T20:15:00","Deviations":[{"Text":"Inställd","Consequence":"CANCELLED","ImportanceLevel":7}]}],"StopPointDeviations":[{"Deviation":{"Text":"Hissen ur funktion. Använd trapporna.","ImportanceLevel":5},"StopInfo":{"StopAreaName":"T-Centralen","StopAreaNumber":1051,"TransportMode":"METRO"}}]}`
+
+func realtime(t *testing.T) *sl.RealtimeResponse {
+	var r sl.RealtimeResponse
+	if err := json.Unmarshal([]byte(realtimeFixture), &r); err != nil {
+		t.Fatal(err)
+	}
+	return &r
+}
+
+func TestStopMonitoring(t *testing.T) {
+	now := time.Date(2017, 12, 18, 19, 10, 30, 0, time.UTC)
+	sm := StopMonitoring(realtime(t), "1051", now)
+
+	if len(sm.MonitoredStopVisit) != 2 {
+		t.Fatalf("Expected 2 stop visits got %d", len(sm.MonitoredStopVisit))
+	}
+
+	v := sm.MonitoredStopVisit[0]
+	if v.MonitoringRef != "1051" || v.RecordedAtTime.UTC() != time.Date(2017, 12, 18, 19, 10, 19, 0, time.UTC) {
+		t.Errorf("Expected visit at 1051 recorded at latest update got %v", v)
+	}
+
+	j := v.MonitoredVehicleJourney
+	if j.LineRef != "11" || j.VehicleMode != "metro" || j.DestinationName != "Akalla" || !j.Monitored {
+		t.Errorf("Expected monitored metro 11 to Akalla got %v", j)
+	}
+
+	if j.FramedVehicleJourneyRef == nil || j.FramedVehicleJourneyRef.DatedVehicleJourneyRef != "30531" || j.FramedVehicleJourneyRef.DataFrameRef != "2017-12-18" {
+		t.Errorf("Expected journey ref 30531 got %v", j.FramedVehicleJourneyRef)
+	}
+
+	c := j.MonitoredCall
+	if c.StopPointRef != "3051" || c.DeparturePlatformName != "5" || c.ExpectedDepartureTime.Sub(*c.AimedDepartureTime) != 18*time.Second {
+		t.Errorf("Expected call at platform 5 with 18 seconds delay got %v", c)
+	}
+
+	if c := sm.MonitoredStopVisit[1].MonitoredVehicleJourney.MonitoredCall; c.DepartureBoardingActivity != "noBoarding" {
+		t.Errorf("Expected no boarding for cancelled departure got %q", c.DepartureBoardingActivity)
+	}
+
+	b, err := xml.Marshal(NewSiri("SL", now, sm, nil))
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	for _, want := range []string{
+		`<Siri xmlns="http://www.siri.org.uk/siri" version="2.0">`,
+		`<AimedDepartureTime>2017-12-18T20:10:45+01:00</AimedDepartureTime>`,
+		`<SituationRef><SituationNumber>` + situationNumber("Inställd") + `</SituationNumber></SituationRef>`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("Expected %s in %s", want, b)
+		}
+	}
+}
+
+func TestStopMonitoringNoStopPoint(t *testing.T) {
+	r := realtime(t)
+	r.Metros[0].StopPointNumber = 0
+
+	sm := StopMonitoring(r, "1051", time.Now())
+
+	if ref := sm.MonitoredStopVisit[0].MonitoredVehicleJourney.MonitoredCall.StopPointRef; ref != "" {
+		t.Errorf("Expected empty stop point ref got %q", ref)
+	}
+
+	b, err := xml.Marshal(sm)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if strings.Count(string(b), "<StopPointRef>") != 1 {
+		t.Errorf("Expected a single stop point ref in %s", b)
+	}
+}
+
+func TestSituationExchange(t *testing.T) {
+	sx := SituationExchange(realtime(t), time.Now())
+
+	if len(sx.Situations) != 2 {
+		t.Fatalf("Expected 2 situations got %d", len(sx.Situations))
+	}
+
+	s := sx.Situations[0]
+	if s.Summary != "Hissen ur funktion." || s.Description != "Använd trapporna." || s.Severity != "normal" {
+		t.Errorf("Expected elevator situation got %v", s)
+	}
+
+	if len(s.Affects.StopPlaces) != 1 || s.Affects.StopPlaces[0].StopPlaceRef != "1051" || len(s.Affects.Lines) != 1 || s.Affects.Lines[0].LineRef != "11" {
+		t.Errorf("Expected T-Centralen and line 11 to be affected got %v", s.Affects)
+	}
+
+	if s := sx.Situations[1]; s.Summary != "Inställd" || s.Severity != "severe" {
+		t.Errorf("Expected severe cancellation got %v", s)
+	}
+}
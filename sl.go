@@ -7,10 +7,12 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/google/go-querystring/query"
 )
@@ -35,13 +37,28 @@ type Client struct {
 	// Base URL for API requests. Defaults to the SL API base url.
 	BaseURL *url.URL
 
+	// Base URL of the GTFS Realtime feeds. Defaults to the Trafiklab SL feeds.
+	GTFSRealtimeURL *url.URL
+
 	// User agent used when communicating with the SL API.
 	UserAgent string
 
+	// Logger used to log requests at debug level and errors at warn level.
+	// Nothing is logged if nil.
+	Logger *slog.Logger
+
+	// LogBodies enables logging of full response bodies. Response bodies may
+	// be large, so it is disabled by default.
+	LogBodies bool
+
 	// Reuse a single struct instead of allocating one for each service on the heap.
 	common service
 
+	// Middleware wrapping every service call.
+	middleware []Middleware
+
 	// Services used for talking to different parts of the SL API.
+	GTFSRealtime  *GTFSRealtimeService
 	Location      *LocationService
 	Realtime      *RealtimeService
 	TravelPlanner *TravelPlannerService
@@ -59,9 +76,11 @@ func NewClient(httpClient *http.Client) *Client {
 	}
 
 	baseURL, _ := url.Parse(defaultBaseURL)
+	gtfsRealtimeURL, _ := url.Parse(defaultGTFSRealtimeURL)
 
-	c := &Client{client: httpClient, BaseURL: baseURL, UserAgent: userAgent}
+	c := &Client{client: httpClient, BaseURL: baseURL, GTFSRealtimeURL: gtfsRealtimeURL, UserAgent: userAgent}
 	c.common.client = c
+	c.GTFSRealtime = (*GTFSRealtimeService)(&c.common)
 	c.Location = (*LocationService)(&c.common)
 	c.Realtime = (*RealtimeService)(&c.common)
 	c.TravelPlanner = (*TravelPlannerService)(&c.common)
@@ -133,6 +152,21 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 
 // Do sends an API request and returns the API response.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
+	if c.Logger == nil {
+		return c.do(ctx, req, v, nil)
+	}
+
+	start := time.Now()
+	body := &bodyRecorder{capture: c.LogBodies}
+	resp, err := c.do(ctx, req, v, body)
+	c.logResponse(ctx, req, resp, v, body, time.Since(start), err)
+
+	return resp, err
+}
+
+// do sends an API request and decodes the response into v. If body is not
+// nil it records the response body as it is read.
+func (c *Client) do(ctx context.Context, req *http.Request, v interface{}, body *bodyRecorder) (*http.Response, error) {
 	req = req.WithContext(ctx)
 
 	resp, err := c.client.Do(req)
@@ -148,7 +182,7 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 		// If the error type is *url.Error, sanitize its URL before returning.
 		if e, ok := err.(*url.Error); ok {
 			if url, err := url.Parse(e.URL); err == nil {
-				e.URL = sanitizeURL(url).String()
+				e.URL = SanitizeURL(url).String()
 				return nil, e
 			}
 		}
@@ -156,6 +190,11 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 		return nil, err
 	}
 
+	if body != nil {
+		body.ReadCloser = resp.Body
+		resp.Body = body
+	}
+
 	defer func() {
 		// Drain up to 512 bytes and close the body to let the Transport reuse the connection
 		io.CopyN(ioutil.Discard, resp.Body, 512)
@@ -176,8 +215,8 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 	return resp, err
 }
 
-// sanitizeURL removes the key parameter from the URL.
-func sanitizeURL(uri *url.URL) *url.URL {
+// SanitizeURL masks the key parameter in the URL so it can be logged or stored.
+func SanitizeURL(uri *url.URL) *url.URL {
 	if uri == nil {
 		return nil
 	}
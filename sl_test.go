@@ -46,3 +46,15 @@ func TestNewClient(t *testing.T) {
 		t.Errorf("NewClient UserAgent is %v, want %v", got, want)
 	}
 }
+
+func TestSanitizeURL(t *testing.T) {
+	u, _ := url.Parse("https://api.sl.se/api2/typeahead.json?key=secret&searchstring=Slussen")
+
+	if got, want := SanitizeURL(u).String(), "https://api.sl.se/api2/typeahead.json?key=%2A%2A%2A%2A%2A%2A&searchstring=Slussen"; got != want {
+		t.Errorf("SanitizeURL is %v, want %v", got, want)
+	}
+
+	if SanitizeURL(nil) != nil {
+		t.Errorf("SanitizeURL(nil) is not nil")
+	}
+}
@@ -0,0 +1,234 @@
+// Package slcache provides a file-backed implementation of sl.Cache, so
+// static and semi-static data like stop lookups survive restarts.
+//
+//	cache, err := slcache.New(filepath.Join(os.TempDir(), "sl"), &slcache.Options{
+//		MaxSize: 10 << 20,
+//		MaxAge:  7 * 24 * time.Hour,
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	client.Use(sl.CacheMiddleware(cache, nil))
+package slcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+const ext = ".json"
+
+// Options specifies optional parameters to New.
+type Options struct {
+	// Max total size in bytes of the cached entries. Zero means no limit.
+	MaxSize int64
+
+	// Max age of an entry before it is evicted. Zero means no limit.
+	MaxAge time.Duration
+}
+
+// DiskCache stores cache entries as files in a directory. It is safe for
+// concurrent use, but the directory should not be shared between processes.
+type DiskCache struct {
+	dir string
+	opt Options
+	now func() time.Time
+
+	mu sync.Mutex
+}
+
+// New returns a new disk cache that stores entries in dir. The directory
+// is created if it does not exist.
+func New(dir string, opt *Options) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	c := &DiskCache{
+		dir: dir,
+		now: time.Now,
+	}
+
+	if opt != nil {
+		c.opt = *opt
+	}
+
+	return c, nil
+}
+
+// Get implements sl.Cache. Entries older than MaxAge are removed and nil is returned.
+func (c *DiskCache) Get(key string) (*sl.CacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var e *sl.CacheEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	if c.expired(e.Time) {
+		os.Remove(path)
+		return nil, nil
+	}
+
+	return e, nil
+}
+
+// Set implements sl.Cache. Expired entries and, if the cache is larger than
+// MaxSize, the oldest entries are evicted after the entry is written.
+func (c *DiskCache) Set(key string, entry *sl.CacheEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Write to a temporary file first so a crash never leaves a partial entry.
+	f, err := ioutil.TempFile(c.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	path := c.path(key)
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	if !entry.Time.IsZero() {
+		os.Chtimes(path, entry.Time, entry.Time)
+	}
+
+	return c.evict()
+}
+
+// Delete removes the entry for key.
+func (c *DiskCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Size returns the total size in bytes of the cached entries.
+func (c *DiskCache) Size() (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, f := range files {
+		size += f.Size()
+	}
+
+	return size, nil
+}
+
+// evict removes expired entries and then the oldest entries until the
+// cache fits in MaxSize. The modification time of an entry file is the
+// time of the entry.
+func (c *DiskCache) evict() error {
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+
+	var size int64
+	kept := files[:0]
+	for _, f := range files {
+		if c.expired(f.ModTime()) {
+			if err := os.Remove(filepath.Join(c.dir, f.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		size += f.Size()
+		kept = append(kept, f)
+	}
+
+	for _, f := range kept {
+		if c.opt.MaxSize <= 0 || size <= c.opt.MaxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, f.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		size -= f.Size()
+	}
+
+	return nil
+}
+
+// files returns the entry files in the cache directory.
+func (c *DiskCache) files() ([]os.FileInfo, error) {
+	infos, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := infos[:0]
+	for _, f := range infos {
+		if f.Mode().IsRegular() && strings.HasSuffix(f.Name(), ext) {
+			files = append(files, f)
+		}
+	}
+
+	return files, nil
+}
+
+// expired reports whether an entry from t is older than MaxAge.
+func (c *DiskCache) expired(t time.Time) bool {
+	return c.opt.MaxAge > 0 && c.now().Sub(t) > c.opt.MaxAge
+}
+
+// path returns the file path of the entry for key.
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+ext)
+}
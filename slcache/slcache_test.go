@@ -0,0 +1,122 @@
+package slcache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "slcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, err := New(dir, nil)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if e, err := c.Get("missing"); e != nil || err != nil {
+		t.Errorf("Expected nil entry got %v and %v", e, err)
+	}
+
+	entry := &sl.CacheEntry{
+		Body:       []byte(`[{"Name":"Slussen"}]`),
+		ETag:       `"v1"`,
+		Header:     http.Header{"Etag": {`"v1"`}},
+		StatusCode: http.StatusOK,
+		Time:       time.Now().Truncate(time.Second),
+	}
+
+	if err := c.Set("slussen", entry); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	// A new cache for the same directory reads the entries written before a restart.
+	c, err = New(dir, nil)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	e, err := c.Get("slussen")
+	if err != nil || e == nil {
+		t.Fatalf("Expected entry got %v and %v", e, err)
+	}
+
+	if string(e.Body) != string(entry.Body) || e.ETag != entry.ETag || !e.Time.Equal(entry.Time) {
+		t.Errorf("Expected %v got %v", entry, e)
+	}
+
+	if err := c.Delete("slussen"); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if e, _ := c.Get("slussen"); e != nil {
+		t.Errorf("Expected deleted entry got %v", e)
+	}
+}
+
+func TestDiskCacheMaxAge(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, _ := New(dir, &Options{MaxAge: time.Hour})
+
+	c.Set("old", &sl.CacheEntry{Time: time.Now().Add(-2 * time.Hour)})
+	c.Set("new", &sl.CacheEntry{Time: time.Now()})
+
+	if e, _ := c.Get("old"); e != nil {
+		t.Errorf("Expected expired entry to be evicted got %v", e)
+	}
+
+	if e, _ := c.Get("new"); e == nil {
+		t.Errorf("Expected entry got nil")
+	}
+
+	c.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	if e, _ := c.Get("new"); e != nil {
+		t.Errorf("Expected expired entry got %v", e)
+	}
+}
+
+func TestDiskCacheMaxSize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// Whole seconds keep the entries the same size when encoded.
+	now := time.Now().Truncate(time.Second)
+
+	c, _ := New(dir, nil)
+	c.Set("a", &sl.CacheEntry{Time: now.Add(-time.Minute)})
+
+	size, err := c.Size()
+	if err != nil || size == 0 {
+		t.Fatalf("Expected size got %d and %v", size, err)
+	}
+
+	c.opt.MaxSize = 2 * size
+	c.Set("b", &sl.CacheEntry{Time: now.Add(-time.Second)})
+	c.Set("c", &sl.CacheEntry{Time: now})
+
+	if e, _ := c.Get("a"); e != nil {
+		t.Errorf("Expected oldest entry to be evicted got %v", e)
+	}
+
+	for _, key := range []string{"b", "c"} {
+		if e, _ := c.Get(key); e == nil {
+			t.Errorf("Expected entry %s got nil", key)
+		}
+	}
+}
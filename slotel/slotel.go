@@ -0,0 +1,170 @@
+// Package slotel provides OpenTelemetry tracing and metrics for the SL client.
+//
+// The instrumentation is a sl.Middleware so the core package does not
+// depend on OpenTelemetry:
+//
+//	mw, err := slotel.NewMiddleware()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	client := sl.NewClient(nil)
+//	client.Use(mw)
+//
+// A span named after the call, e.g. sl.realtime.search, is created for each
+// service call. The call duration is recorded in the sl.client.duration
+// histogram and errors are counted in the sl.client.errors counter.
+package slotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/frozzare/go-sl"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name.
+const ScopeName = "github.com/frozzare/go-sl/slotel"
+
+// Attribute keys added to spans and metrics.
+const (
+	CallKey           = attribute.Key("sl.call")
+	EndpointKey       = attribute.Key("sl.endpoint")
+	SiteIDKey         = attribute.Key("sl.site_id")
+	OriginIDKey       = attribute.Key("sl.origin_id")
+	DestinationIDKey  = attribute.Key("sl.destination_id")
+	StatusCodeKey     = attribute.Key("sl.status_code")
+	ExecutionTimeKey  = attribute.Key("sl.execution_time_ms")
+	HTTPStatusCodeKey = attribute.Key("http.response.status_code")
+)
+
+// config represents the middleware configuration.
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// Option configures the middleware.
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider. Defaults to the global tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider sets the meter provider. Defaults to the global meter provider.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+// NewMiddleware returns a middleware that traces and measures every service call.
+func NewMiddleware(opts ...Option) (sl.Middleware, error) {
+	c := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	tracer := c.tracerProvider.Tracer(ScopeName)
+	meter := c.meterProvider.Meter(ScopeName)
+
+	duration, err := meter.Float64Histogram("sl.client.duration",
+		metric.WithDescription("Duration of SL API calls."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	errorCount, err := meter.Int64Counter("sl.client.errors",
+		metric.WithDescription("Number of failed SL API calls."),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next sl.Handler) sl.Handler {
+		return func(ctx context.Context, call *sl.Call) error {
+			endpoint := []attribute.KeyValue{
+				CallKey.String(call.Name()),
+				EndpointKey.String(call.Endpoint),
+			}
+
+			ctx, span := tracer.Start(ctx, call.Name(),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(endpoint...),
+				trace.WithAttributes(optionAttributes(call.Options)...))
+			defer span.End()
+
+			start := time.Now()
+			err := next(ctx, call)
+			elapsed := time.Since(start)
+
+			span.SetAttributes(resultAttributes(call)...)
+
+			set := metric.WithAttributes(endpoint...)
+			duration.Record(ctx, elapsed.Seconds(), set)
+
+			if err != nil {
+				errorCount.Add(ctx, 1, set)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return err
+		}
+	}, nil
+}
+
+// optionAttributes returns the site and stop attributes of the call options.
+func optionAttributes(options interface{}) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+
+	add := func(key attribute.Key, values ...string) {
+		for _, v := range values {
+			if len(v) > 0 {
+				attrs = append(attrs, key.String(v))
+				return
+			}
+		}
+	}
+
+	switch opt := options.(type) {
+	case *sl.RealtimeSearchOptions:
+		add(SiteIDKey, opt.SiteID)
+	case *sl.TripOptions:
+		add(OriginIDKey, opt.OriginID, opt.OriginExtID)
+		add(DestinationIDKey, opt.DestID, opt.DestExtID)
+	}
+
+	return attrs
+}
+
+// resultAttributes returns the SL status code, execution time and http
+// status code attributes of a finished call.
+func resultAttributes(call *sl.Call) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+
+	if call.Response != nil {
+		attrs = append(attrs, HTTPStatusCodeKey.Int(call.Response.StatusCode))
+	}
+
+	switch r := call.Result.(type) {
+	case *sl.RealtimeResponseData:
+		attrs = append(attrs, StatusCodeKey.Int(r.StatusCode), ExecutionTimeKey.Int(r.ExecutionTime))
+	case *sl.TypeaheadResponseData:
+		attrs = append(attrs, StatusCodeKey.Int(r.StatusCode), ExecutionTimeKey.Int(r.ExecutionTime))
+	}
+
+	return attrs
+}
@@ -0,0 +1,160 @@
+package slotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frozzare/go-sl"
+	"github.com/frozzare/go-sl/sltest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+type testSpan struct {
+	tracenoop.Span
+	name   string
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+	ended  bool
+}
+
+func (s *testSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *testSpan) SetStatus(code codes.Code, _ string) { s.status = code }
+func (s *testSpan) End(...trace.SpanEndOption)          { s.ended = true }
+
+type testTracer struct {
+	tracenoop.Tracer
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	s := &testSpan{name: name, attrs: map[attribute.Key]attribute.Value{}}
+	config := trace.NewSpanStartConfig(opts...)
+	s.SetAttributes(config.Attributes()...)
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+type testTracerProvider struct {
+	tracenoop.TracerProvider
+	tracer *testTracer
+}
+
+func (p *testTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer { return p.tracer }
+
+type testHistogram struct {
+	metricnoop.Float64Histogram
+	count int
+}
+
+func (h *testHistogram) Record(context.Context, float64, ...metric.RecordOption) { h.count++ }
+
+type testCounter struct {
+	metricnoop.Int64Counter
+	sum int64
+}
+
+func (c *testCounter) Add(_ context.Context, n int64, _ ...metric.AddOption) { c.sum += n }
+
+type testMeter struct {
+	metricnoop.Meter
+	histogram *testHistogram
+	counter   *testCounter
+}
+
+func (m *testMeter) Float64Histogram(string, ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return m.histogram, nil
+}
+
+func (m *testMeter) Int64Counter(string, ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return m.counter, nil
+}
+
+type testMeterProvider struct {
+	metricnoop.MeterProvider
+	meter *testMeter
+}
+
+func (p *testMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter { return p.meter }
+
+func TestMiddleware(t *testing.T) {
+	server := sltest.NewServer()
+	defer server.Close()
+
+	tracer := &testTracer{}
+	meter := &testMeter{histogram: &testHistogram{}, counter: &testCounter{}}
+
+	mw, err := NewMiddleware(
+		WithTracerProvider(&testTracerProvider{tracer: tracer}),
+		WithMeterProvider(&testMeterProvider{meter: meter}),
+	)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	client := server.Client()
+	client.Use(mw)
+
+	if _, err := client.Realtime.Search(context.Background(), &sl.RealtimeSearchOptions{Key: "XXXX", SiteID: "1002"}); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	server.SetMessage(sltest.EndpointTrip, "No trip found")
+
+	if _, err := client.TravelPlanner.Trip(context.Background(), &sl.TripOptions{Key: "XXXX", OriginID: "9192", DestExtID: "9001"}); err == nil {
+		t.Fatalf("Expected error got nil")
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("Expected 2 spans got %d", len(tracer.spans))
+	}
+
+	realtime := tracer.spans[0]
+
+	if realtime.name != "sl.realtime.search" || !realtime.ended {
+		t.Errorf("Expected ended 'sl.realtime.search' span got %s", realtime.name)
+	}
+
+	if got := realtime.attrs[SiteIDKey].AsString(); got != "1002" {
+		t.Errorf("Expected '1002' got %s", got)
+	}
+
+	if got := realtime.attrs[ExecutionTimeKey].AsInt64(); got != 621 {
+		t.Errorf("Expected 621 got %d", got)
+	}
+
+	if got := realtime.attrs[HTTPStatusCodeKey].AsInt64(); got != 200 {
+		t.Errorf("Expected 200 got %d", got)
+	}
+
+	trip := tracer.spans[1]
+
+	if trip.name != "sl.travelplanner.trip" {
+		t.Errorf("Expected 'sl.travelplanner.trip' got %s", trip.name)
+	}
+
+	if got := trip.attrs[DestinationIDKey].AsString(); got != "9001" {
+		t.Errorf("Expected '9001' got %s", got)
+	}
+
+	if trip.status != codes.Error {
+		t.Errorf("Expected error status got %v", trip.status)
+	}
+
+	if meter.histogram.count != 2 {
+		t.Errorf("Expected 2 recorded durations got %d", meter.histogram.count)
+	}
+
+	if meter.counter.sum != 1 {
+		t.Errorf("Expected 1 error got %d", meter.counter.sum)
+	}
+}
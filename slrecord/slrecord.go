@@ -0,0 +1,240 @@
+// Package slrecord provides an http.RoundTripper that records SL API
+// responses to a cassette file and replays them, so tests can run against
+// real responses without network access.
+//
+// The key query parameter is masked with sl.SanitizeURL before anything is
+// written to disk, so cassettes can be committed.
+//
+//	rec, err := slrecord.New("testdata/realtime.json", slrecord.ModeReplay)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	client := sl.NewClient(rec.Client())
+package slrecord
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/frozzare/go-sl"
+)
+
+// Mode represents the mode of a recorder.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and fails on unmatched requests.
+	ModeReplay Mode = iota
+
+	// ModeRecord sends requests to the real API and stores the responses in the cassette.
+	ModeRecord
+)
+
+var (
+	ErrNoInteraction = errors.New("slrecord: no recorded interaction matches the request")
+)
+
+// Cassette represents the recorded interactions stored on disk.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction represents a recorded request and response pair.
+type Interaction struct {
+	Request  *Request  `json:"request"`
+	Response *Response `json:"response"`
+}
+
+// Request represents a recorded request. The key parameter in URL is masked.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response represents a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	// Transport used to send requests in record mode. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	mode     Mode
+	path     string
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// New returns a recorder for the cassette at path. In replay mode the
+// cassette must exist, in record mode it is created or overwritten.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path, cassette: &Cassette{}}
+
+	if mode == ModeReplay {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(b, r.cassette); err != nil {
+			return nil, err
+		}
+
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Mode returns the recorder mode.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns a http client using the recorder as transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	recorded := &Request{
+		Method: req.Method,
+		URL:    sanitize(req.URL),
+		Body:   string(body),
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	return r.record(req, recorded)
+}
+
+// replay returns the first unused interaction matching the request. When
+// all matching interactions are used the last one is served again.
+func (r *Recorder) replay(req *http.Request, recorded *Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, in := range r.cassette.Interactions {
+		if !in.Request.matches(recorded) {
+			continue
+		}
+
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+
+	if match < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+	}
+
+	r.used[match] = true
+	res := r.cassette.Interactions[match].Response
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", res.StatusCode, http.StatusText(res.StatusCode)),
+		StatusCode:    res.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        res.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(res.Body))),
+		ContentLength: int64(len(res.Body)),
+		Request:       req,
+	}, nil
+}
+
+// record sends the request and stores the interaction in the cassette.
+func (r *Recorder) record(req *http.Request, recorded *Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: recorded,
+		Response: &Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       string(body),
+		},
+	})
+
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// save writes the cassette to disk.
+func (r *Recorder) save() error {
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.path, b, 0644)
+}
+
+// matches reports whether the recorded request matches other.
+func (r *Request) matches(other *Request) bool {
+	return r.Method == other.Method && r.URL == other.URL && r.Body == other.Body
+}
+
+// sanitize returns the url with the key parameter masked and the query
+// parameters sorted, so equal requests always give the same string.
+func sanitize(u *url.URL) string {
+	c := *u
+	c.RawQuery = c.Query().Encode()
+	return sl.SanitizeURL(&c).String()
+}
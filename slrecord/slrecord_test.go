@@ -0,0 +1,81 @@
+package slrecord
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go-sl"
+	"github.com/frozzare/go-sl/sltest"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slrecord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "testdata", "realtime.json")
+	server := sltest.NewServer()
+	opt := func() *sl.RealtimeSearchOptions {
+		return &sl.RealtimeSearchOptions{Key: "secret", SiteID: "1002"}
+	}
+
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	client := sl.NewClient(rec.Client())
+	client.BaseURL, _ = url.Parse(server.URL)
+
+	if _, err := client.Realtime.Search(context.Background(), opt()); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	server.Close()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if strings.Contains(string(b), "secret") {
+		t.Errorf("Expected key to be masked in cassette got %s", b)
+	}
+
+	rec, err = New(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	client = sl.NewClient(rec.Client())
+	client.BaseURL, _ = url.Parse(server.URL)
+
+	realtime, err := client.Realtime.Search(context.Background(), opt())
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if realtime.Metros[0].Destination != "Akalla" {
+		t.Errorf("Expected 'Akalla' got %s", realtime.Metros[0].Destination)
+	}
+
+	other := opt()
+	other.SiteID = "9192"
+	if _, err := client.Realtime.Search(context.Background(), other); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("Expected %v got %v", ErrNoInteraction, err)
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join("testdata", "missing.json"), ModeReplay); err == nil {
+		t.Errorf("Expected error got nil")
+	}
+}
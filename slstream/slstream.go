@@ -0,0 +1,416 @@
+// Package slstream streams realtime departures to browsers over
+// Server-Sent Events or WebSocket.
+//
+// A Handler keeps one poller per site, so any number of clients watching
+// the same site cost one RealtimeService.Search call per interval. A client
+// first gets a snapshot of the departures and then a diff of the added,
+// updated and removed departures after each poll that changed anything.
+package slstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+// Event types.
+const (
+	EventSnapshot = "snapshot"
+	EventDiff     = "diff"
+	EventError    = "error"
+)
+
+// subscriberBuffer is the number of events buffered per client. Clients
+// that fall further behind are disconnected.
+const subscriberBuffer = 16
+
+var (
+	ErrTooManySites   = errors.New("slstream: too many sites")
+	ErrTooManyClients = errors.New("slstream: too many clients")
+)
+
+// Departure represents a departure with the key used to identify it in diffs.
+type Departure struct {
+	Key string `json:"Key"`
+	*sl.Transport
+}
+
+// Event represents a snapshot, diff or error event sent to clients.
+type Event struct {
+	Type   string    `json:"type"`
+	SiteID string    `json:"siteId"`
+	Time   time.Time `json:"time"`
+
+	// Departures of a snapshot event.
+	Departures []*Departure `json:"departures,omitempty"`
+
+	// Added and updated departures and the keys of removed departures of a diff event.
+	Added   []*Departure `json:"added,omitempty"`
+	Updated []*Departure `json:"updated,omitempty"`
+	Removed []string     `json:"removed,omitempty"`
+
+	// Error of an error event. The previous departures are kept.
+	Error string `json:"error,omitempty"`
+}
+
+// Options specifies optional parameters to NewHandler.
+type Options struct {
+	// API Key for the realtime API.
+	Key string
+
+	// Interval between polls of a site. Default is 30 seconds.
+	Interval time.Duration
+
+	// Time window to search departures within. Max 60 minutes.
+	TimeWindow int
+
+	// Max number of sites polled at once and max number of clients of a
+	// site. Clients that would exceed a limit get 503 Service Unavailable.
+	// Zero means no limit.
+	MaxSites   int
+	MaxClients int
+
+	// CheckOrigin reports whether a WebSocket upgrade request is allowed,
+	// other requests get 403 Forbidden. Browsers don't apply CORS to
+	// WebSocket, so handlers called from browsers should check the Origin
+	// header. All requests are allowed if nil.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// Handler streams departures of the site given by the siteId query
+// parameter. WebSocket upgrade requests get a WebSocket connection with an
+// event per text message, other requests get a Server-Sent Events stream.
+type Handler struct {
+	client *sl.Client
+	opt    Options
+	now    func() time.Time
+
+	mu      sync.Mutex
+	pollers map[string]*poller
+}
+
+// NewHandler creates a handler that polls sites using the client.
+func NewHandler(client *sl.Client, opt *Options) *Handler {
+	h := &Handler{client: client, now: time.Now, pollers: map[string]*poller{}}
+
+	if opt != nil {
+		h.opt = *opt
+	}
+
+	if h.opt.Interval <= 0 {
+		h.opt.Interval = 30 * time.Second
+	}
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	siteID := r.URL.Query().Get("siteId")
+	if len(siteID) == 0 {
+		http.Error(w, sl.ErrNoSiteID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ws := isWebSocket(r)
+	if ws && !h.checkWebSocket(w, r) {
+		return
+	}
+
+	events, cancel, err := h.subscribe(siteID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer cancel()
+
+	if ws {
+		h.serveWebSocket(w, r, events)
+		return
+	}
+
+	h.serveEvents(w, r, events)
+}
+
+// Close stops all pollers and disconnects all clients.
+func (h *Handler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, p := range h.pollers {
+		for ch := range p.subs {
+			p.remove(ch)
+		}
+	}
+}
+
+// Sites returns the number of sites polled and clients connected.
+func (h *Handler) Sites() (sites, clients int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, p := range h.pollers {
+		sites++
+		clients += len(p.subs)
+	}
+
+	return sites, clients
+}
+
+// subscribe adds a client to the poller of the site, starting it if
+// needed. The channel is closed when the client is removed, by calling
+// the returned function or for falling behind. ErrTooManySites or
+// ErrTooManyClients is returned if a limit is reached.
+func (h *Handler) subscribe(siteID string) (<-chan *Event, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p := h.pollers[siteID]
+	if p == nil && h.opt.MaxSites > 0 && len(h.pollers) >= h.opt.MaxSites {
+		return nil, nil, ErrTooManySites
+	}
+
+	if p != nil && h.opt.MaxClients > 0 && len(p.subs) >= h.opt.MaxClients {
+		return nil, nil, ErrTooManyClients
+	}
+
+	if p == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		p = &poller{h: h, siteID: siteID, subs: map[chan *Event]bool{}, ctx: ctx, cancel: cancel}
+		h.pollers[siteID] = p
+		go p.run()
+	}
+
+	ch := make(chan *Event, subscriberBuffer)
+	p.subs[ch] = true
+
+	if p.ready {
+		ch <- &Event{Type: EventSnapshot, SiteID: siteID, Time: p.time, Departures: p.departures}
+	}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		p.remove(ch)
+	}, nil
+}
+
+// poller polls the departures of a site while it has clients.
+type poller struct {
+	h      *Handler
+	siteID string
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Guarded by h.mu.
+	subs       map[chan *Event]bool
+	ready      bool
+	time       time.Time
+	departures []*Departure
+}
+
+// run polls the site until the poller is stopped.
+func (p *poller) run() {
+	ticker := time.NewTicker(p.h.opt.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll()
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll searches for departures and sends the changes to the clients.
+func (p *poller) poll() {
+	resp, err := p.h.client.Realtime.Search(p.ctx, &sl.RealtimeSearchOptions{
+		Key:        p.h.opt.Key,
+		SiteID:     p.siteID,
+		TimeWindow: p.h.opt.TimeWindow,
+	})
+	if p.ctx.Err() != nil {
+		return
+	}
+
+	p.h.mu.Lock()
+	defer p.h.mu.Unlock()
+
+	now := p.h.now()
+
+	if err != nil {
+		p.broadcast(&Event{Type: EventError, SiteID: p.siteID, Time: now, Error: err.Error()})
+		return
+	}
+
+	var departures []*Departure
+	if resp != nil {
+		for _, t := range resp.Departures() {
+			departures = append(departures, &Departure{Key: Key(t), Transport: t})
+		}
+	}
+
+	prev := p.departures
+	p.departures = departures
+	p.time = now
+
+	if !p.ready {
+		p.ready = true
+		p.broadcast(&Event{Type: EventSnapshot, SiteID: p.siteID, Time: now, Departures: departures})
+		return
+	}
+
+	if ev := diff(prev, departures); ev != nil {
+		ev.SiteID = p.siteID
+		ev.Time = now
+		p.broadcast(ev)
+	}
+}
+
+// broadcast sends the event to all clients, removing the clients that
+// have fallen behind. Must be called with h.mu held.
+func (p *poller) broadcast(ev *Event) {
+	for ch := range p.subs {
+		select {
+		case ch <- ev:
+		default:
+			p.remove(ch)
+		}
+	}
+}
+
+// remove removes a client, stopping the poller when the last client is
+// removed. Must be called with h.mu held.
+func (p *poller) remove(ch chan *Event) {
+	if !p.subs[ch] {
+		return
+	}
+
+	delete(p.subs, ch)
+	close(ch)
+
+	if len(p.subs) == 0 {
+		p.cancel()
+		if p.h.pollers[p.siteID] == p {
+			delete(p.h.pollers, p.siteID)
+		}
+	}
+}
+
+// Key returns the key identifying a departure between polls.
+func Key(t *sl.Transport) string {
+	return fmt.Sprintf("%s/%s/%d/%d/%s", t.TransportMode, t.LineNumber, t.JourneyNumber, t.StopPointNumber, t.TimeTabledDateTime)
+}
+
+// diff returns a diff event of the changes from prev to next or nil if
+// nothing changed.
+func diff(prev, next []*Departure) *Event {
+	ev := &Event{Type: EventDiff}
+
+	old := map[string]*Departure{}
+	for _, d := range prev {
+		old[d.Key] = d
+	}
+
+	keys := map[string]bool{}
+	for _, d := range next {
+		keys[d.Key] = true
+
+		o, ok := old[d.Key]
+		switch {
+		case !ok:
+			ev.Added = append(ev.Added, d)
+		case !reflect.DeepEqual(o.Transport, d.Transport):
+			ev.Updated = append(ev.Updated, d)
+		}
+	}
+
+	for _, d := range prev {
+		if !keys[d.Key] {
+			ev.Removed = append(ev.Removed, d.Key)
+		}
+	}
+
+	if len(ev.Added) == 0 && len(ev.Updated) == 0 && len(ev.Removed) == 0 {
+		return nil
+	}
+
+	return ev
+}
+
+// serveEvents streams events as Server-Sent Events. A comment is sent
+// every interval so proxies don't close connections to idle sites.
+func (h *Handler) serveEvents(w http.ResponseWriter, r *http.Request, events <-chan *Event) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(h.opt.Interval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+
+			b, err := json.Marshal(ev)
+			if err != nil {
+				return
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, b); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// headerContains reports whether the comma separated header contains the
+// token ignoring case.
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
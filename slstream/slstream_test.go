@@ -0,0 +1,227 @@
+package slstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frozzare/go-sl"
+	"github.com/frozzare/go-sl/sltest"
+)
+
+func setupHandler(t *testing.T, opt *Options) (*sltest.Server, *Handler, *httptest.Server) {
+	server := sltest.NewServer()
+	t.Cleanup(server.Close)
+
+	if opt == nil {
+		opt = &Options{}
+	}
+	opt.Key = "XXXX"
+	opt.Interval = 20 * time.Millisecond
+
+	h := NewHandler(server.Client(), opt)
+	t.Cleanup(h.Close)
+
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	return server, h, ts
+}
+
+// readEvent reads the next Server-Sent Event.
+func readEvent(t *testing.T, r *bufio.Reader) (string, *Event) {
+	var typ string
+	var ev Event
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected nil got error: %v", err)
+		}
+
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			typ = line[len("event: "):]
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(line[len("data: "):]), &ev); err != nil {
+				t.Fatalf("Expected nil got error: %v", err)
+			}
+		case len(line) == 0 && len(typ) > 0:
+			return typ, &ev
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected condition to be met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHandlerEvents(t *testing.T) {
+	server, h, ts := setupHandler(t, nil)
+
+	var readers []*bufio.Reader
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(ts.URL + "?siteId=1051")
+		if err != nil {
+			t.Fatalf("Expected nil got error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+			t.Errorf("Expected 'text/event-stream' got %s", got)
+		}
+
+		r := bufio.NewReader(resp.Body)
+		typ, ev := readEvent(t, r)
+		if typ != EventSnapshot || ev.SiteID != "1051" || len(ev.Departures) != 1 || ev.Departures[0].LineNumber != "11" {
+			t.Fatalf("Expected snapshot with line 11 got %s %+v", typ, ev)
+		}
+
+		if want := "METRO/11/30531/3051/2017-12-18T20:10:45"; ev.Departures[0].Key != want {
+			t.Errorf("Expected %s got %s", want, ev.Departures[0].Key)
+		}
+
+		readers = append(readers, r)
+	}
+
+	if sites, clients := h.Sites(); sites != 1 || clients != 3 {
+		t.Errorf("Expected 1 site and 3 clients got %d and %d", sites, clients)
+	}
+
+	server.SetFixture(sltest.EndpointRealtime, strings.Replace(sltest.RealtimeFixture, "2017-12-18T20:11:03", "2017-12-18T20:12:30", 1))
+
+	for _, r := range readers {
+		typ, ev := readEvent(t, r)
+		if typ != EventDiff || len(ev.Updated) != 1 || ev.Updated[0].ExpectedDateTime != "2017-12-18T20:12:30" || len(ev.Added) != 0 || len(ev.Removed) != 0 {
+			t.Errorf("Expected diff with updated expected time got %s %+v", typ, ev)
+		}
+	}
+
+	// All clients share one search per interval.
+	polls := len(server.Requests())
+	for _, req := range server.Requests() {
+		if req.Query.Get("siteId") != "1051" {
+			t.Errorf("Expected search for 1051 got %v", req.Query)
+		}
+	}
+
+	if polls > 10 {
+		t.Errorf("Expected a few searches got %d", polls)
+	}
+
+	server.SetMessage(sltest.EndpointRealtime, "Invalid key")
+
+	if typ, ev := readEvent(t, readers[0]); typ != EventError || ev.Error != "Invalid key" {
+		t.Errorf("Expected error 'Invalid key' got %s %+v", typ, ev)
+	}
+
+	ts.CloseClientConnections()
+
+	waitFor(t, func() bool {
+		sites, clients := h.Sites()
+		return sites == 0 && clients == 0
+	})
+}
+
+func TestHandlerKeepalive(t *testing.T) {
+	_, _, ts := setupHandler(t, nil)
+
+	resp, err := http.Get(ts.URL + "?siteId=1051")
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	readEvent(t, r)
+
+	// Nothing changes, so only comments are sent.
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if line != ": keepalive\n" {
+		t.Errorf("Expected keepalive comment got %q", line)
+	}
+}
+
+func TestHandlerLimits(t *testing.T) {
+	_, _, ts := setupHandler(t, &Options{MaxSites: 1, MaxClients: 1})
+
+	resp, err := http.Get(ts.URL + "?siteId=1051")
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	readEvent(t, bufio.NewReader(resp.Body))
+
+	for _, site := range []string{"1051", "9192"} {
+		resp, err := http.Get(ts.URL + "?siteId=" + site)
+		if err != nil {
+			t.Fatalf("Expected nil got error: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503 for %s got %d", site, resp.StatusCode)
+		}
+	}
+}
+
+func TestHandlerBadRequest(t *testing.T) {
+	_, _, ts := setupHandler(t, nil)
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 got %d", resp.StatusCode)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dep := func(line, expected string) *Departure {
+		t := &sl.Transport{TransportMode: "BUS", LineNumber: line, JourneyNumber: 1, TimeTabledDateTime: "2017-12-18T20:10:00", ExpectedDateTime: expected}
+		return &Departure{Key: Key(t), Transport: t}
+	}
+
+	prev := []*Departure{dep("1", "2017-12-18T20:10:00"), dep("2", "2017-12-18T20:10:00"), dep("3", "2017-12-18T20:10:00")}
+	next := []*Departure{dep("1", "2017-12-18T20:10:00"), dep("2", "2017-12-18T20:11:00"), dep("4", "2017-12-18T20:10:00")}
+
+	ev := diff(prev, next)
+	if ev == nil || ev.Type != EventDiff {
+		t.Fatalf("Expected diff got %v", ev)
+	}
+
+	if len(ev.Added) != 1 || ev.Added[0].LineNumber != "4" {
+		t.Errorf("Expected line 4 added got %v", ev.Added)
+	}
+
+	if len(ev.Updated) != 1 || ev.Updated[0].LineNumber != "2" {
+		t.Errorf("Expected line 2 updated got %v", ev.Updated)
+	}
+
+	if len(ev.Removed) != 1 || ev.Removed[0] != prev[2].Key {
+		t.Errorf("Expected line 3 removed got %v", ev.Removed)
+	}
+
+	if ev := diff(next, next); ev != nil {
+		t.Errorf("Expected nil got %v", ev)
+	}
+}
@@ -0,0 +1,263 @@
+package slstream
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WebSocket opcodes.
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+// WebSocket close status codes.
+const (
+	closeProtocolError = 1002
+	closeMessageTooBig = 1009
+)
+
+const (
+	// websocketGUID is appended to the client key in the handshake, see RFC 6455.
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	// maxFrameSize is the max payload size of frames read from clients.
+	maxFrameSize = 1 << 16
+
+	// maxControlSize is the max payload size of control frames.
+	maxControlSize = 125
+
+	// writeTimeout is the max time to write a frame.
+	writeTimeout = 10 * time.Second
+)
+
+var (
+	errFrameTooLarge = errors.New("slstream: websocket frame too large")
+	errProtocol      = errors.New("slstream: websocket protocol error")
+)
+
+// checkWebSocket writes an error response and returns false if the upgrade
+// request is not allowed or not supported.
+func (h *Handler) checkWebSocket(w http.ResponseWriter, r *http.Request) bool {
+	if h.opt.CheckOrigin != nil && !h.opt.CheckOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return false
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" || len(r.Header.Get("Sec-WebSocket-Key")) == 0 {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// isWebSocket reports whether r is a WebSocket upgrade request.
+func isWebSocket(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+// websocketAccept returns the Sec-WebSocket-Accept value of a client key.
+func websocketAccept(key string) string {
+	h := sha1.New()
+	io.WriteString(h, key+websocketGUID)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// wsConn is a WebSocket connection.
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	mu   sync.Mutex
+
+	// server is true on the server side, where frames from the client must be masked.
+	server bool
+}
+
+// writeFrame writes an unmasked final frame.
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header := []byte{0x80 | op, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	n := 2
+
+	switch l := len(payload); {
+	case l < 126:
+		header[1] = byte(l)
+	case l <= 0xFFFF:
+		header[1] = 126
+		binary.BigEndian.PutUint16(header[2:], uint16(l))
+		n = 4
+	default:
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(l))
+		n = 10
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+
+	if _, err := c.rw.Write(header[:n]); err != nil {
+		return err
+	}
+
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+
+	return c.rw.Flush()
+}
+
+// writeClose writes a close frame with the status code.
+func (c *wsConn) writeClose(status int) error {
+	var payload [2]byte
+	binary.BigEndian.PutUint16(payload[:], uint16(status))
+	return c.writeFrame(opClose, payload[:])
+}
+
+// readFrame reads a frame and returns its opcode and unmasked payload.
+// Fragmented messages are returned frame by frame. errProtocol is returned
+// for unmasked client frames and control frames with too large payloads.
+func (c *wsConn) readFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.rw, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	op := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.rw, b[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.rw, b[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(b[:])
+	}
+
+	if c.server && !masked {
+		return 0, nil, errProtocol
+	}
+
+	if op >= opClose && length > maxControlSize {
+		return 0, nil, errProtocol
+	}
+
+	if length > maxFrameSize {
+		return 0, nil, errFrameTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return op, payload, nil
+}
+
+// serveWebSocket upgrades the connection and sends an event per text message.
+// Messages from the client are ignored except pings and close.
+func (h *Handler) serveWebSocket(w http.ResponseWriter, r *http.Request, events <-chan *Event) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	c := &wsConn{conn: conn, rw: rw, server: true}
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + websocketAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	// Read until the client closes the connection.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		for {
+			op, payload, err := c.readFrame()
+			if err != nil {
+				switch err {
+				case errProtocol:
+					c.writeClose(closeProtocolError)
+				case errFrameTooLarge:
+					c.writeClose(closeMessageTooBig)
+				}
+				return
+			}
+
+			switch op {
+			case opPing:
+				c.writeFrame(opPong, payload)
+			case opClose:
+				c.writeFrame(opClose, payload)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				c.writeFrame(opClose, nil)
+				return
+			}
+
+			b, err := json.Marshal(ev)
+			if err != nil {
+				return
+			}
+
+			if err := c.writeFrame(opText, b); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package slstream
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebsocketAccept(t *testing.T) {
+	// Example from RFC 6455.
+	if got := websocketAccept("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Expected 's3pPLMBiTxaQ9kYGzzhZRbK+xOo=' got %s", got)
+	}
+}
+
+// dialWebSocket connects to the handler and returns the client side of the
+// connection.
+func dialWebSocket(t *testing.T, ts *httptest.Server) (net.Conn, *wsConn) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	conn.Write([]byte("GET /?siteId=1051 HTTP/1.1\r\n" +
+		"Host: localhost\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"))
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	resp, err := http.ReadResponse(rw.Reader, nil)
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Expected 101 with accept key got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	// The client side reads unmasked server frames the same way.
+	return conn, &wsConn{conn: conn, rw: rw}
+}
+
+func TestHandlerWebSocket(t *testing.T) {
+	_, h, ts := setupHandler(t, nil)
+
+	conn, c := dialWebSocket(t, ts)
+
+	op, payload, err := c.readFrame()
+	if err != nil || op != opText {
+		t.Fatalf("Expected text frame got %d, %v", op, err)
+	}
+
+	var ev Event
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if ev.Type != EventSnapshot || len(ev.Departures) != 1 {
+		t.Errorf("Expected snapshot with one departure got %+v", ev)
+	}
+
+	// Ping and close frames from clients are masked.
+	writeMasked := func(op byte, payload string) {
+		b := []byte{0x80 | op, 0x80 | byte(len(payload)), 1, 2, 3, 4}
+		for i := 0; i < len(payload); i++ {
+			b = append(b, payload[i]^b[2+i%4])
+		}
+		conn.Write(b)
+	}
+
+	writeMasked(opPing, "hello")
+
+	for {
+		op, payload, err = c.readFrame()
+		if err != nil {
+			t.Fatalf("Expected nil got error: %v", err)
+		}
+
+		if op == opPong {
+			break
+		}
+	}
+
+	if string(payload) != "hello" {
+		t.Errorf("Expected 'hello' got %s", payload)
+	}
+
+	writeMasked(opClose, "")
+
+	for op != opClose {
+		if op, _, err = c.readFrame(); err != nil {
+			t.Fatalf("Expected close frame got error: %v", err)
+		}
+	}
+
+	waitFor(t, func() bool {
+		sites, _ := h.Sites()
+		return sites == 0
+	})
+}
+
+func TestHandlerWebSocketOrigin(t *testing.T) {
+	server, h, _ := setupHandler(t, &Options{
+		CheckOrigin: func(r *http.Request) bool {
+			return r.Header.Get("Origin") == "https://example.com"
+		},
+	})
+
+	r := httptest.NewRequest("GET", "/?siteId=1051", nil)
+	r.Header.Set("Origin", "https://evil.com")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 got %d", w.Code)
+	}
+
+	if n := len(server.Requests()); n != 0 {
+		t.Errorf("Expected no searches got %d", n)
+	}
+}
+
+func TestHandlerWebSocketProtocolError(t *testing.T) {
+	_, _, ts := setupHandler(t, nil)
+
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{"unmasked frame", []byte{0x80 | opText, 2, 'h', 'i'}},
+		{"large control frame", append([]byte{0x80 | opPing, 0x80 | 126, 0, 200, 0, 0, 0, 0}, make([]byte, 200)...)},
+	}
+
+	for _, tt := range tests {
+		conn, c := dialWebSocket(t, ts)
+		conn.Write(tt.frame)
+
+		for {
+			op, payload, err := c.readFrame()
+			if err != nil {
+				t.Fatalf("Expected close frame for %s got error: %v", tt.name, err)
+			}
+
+			if op != opClose {
+				continue
+			}
+
+			if len(payload) != 2 || binary.BigEndian.Uint16(payload) != closeProtocolError {
+				t.Errorf("Expected status %d for %s got %v", closeProtocolError, tt.name, payload)
+			}
+			break
+		}
+	}
+}
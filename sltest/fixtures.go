@@ -0,0 +1,19 @@
+package sltest
+
+// Default fixtures served by a new Server.
+const (
+	// TypeaheadFixture is the default response for EndpointTypeahead.
+	TypeaheadFixture = `{"StatusCode":0,"Message":null,"ExecutionTime":0,"ResponseData":[{"Name":"Slussen (Stockholm)","SiteId":"9192","Type":"Station","X":"18071860","Y":"59320284"},{"Name":"T-Centralen (Stockholm)","SiteId":"9001","Type":"Station","X":"18061486","Y":"59331358"}]}`
+
+	// RealtimeFixture is the default response for EndpointRealtime.
+	RealtimeFixture = `{"StatusCode":0,"Message":null,"ExecutionTime":621,"ResponseData":{"LatestUpdate":"2017-12-18T20:10:19","DataAge":12,"Metros":[{"GroupOfLine":"tunnelbanans blå linje","DisplayTime":"Nu","TransportMode":"METRO","LineNumber":"11","Destination":"Akalla","JourneyDirection":1,"StopAreaName":"T-Centralen","StopAreaNumber":1051,"StopPointNumber":3051,"StopPointDesignation":"5","TimeTabledDateTime":"2017-12-18T20:10:45","ExpectedDateTime":"2017-12-18T20:11:03","JourneyNumber":30531,"Deviations":null}],"Buses":[],"Trains":[],"Trams":[],"Ships":[],"StopPointDeviations":[]}}`
+
+	// TripFixture is the default response for EndpointTrip.
+	TripFixture = `{"Trip":[{"LegList":{"Leg":[{"Origin":{"name":"Slussen","type":"ST","extId":"400102011","lon":18.071491,"lat":59.319511,"time":"22:58:00","date":"2017-12-18","track":"2"},"Destination":{"name":"T-Centralen","type":"ST","extId":"400101051","lon":18.061477,"lat":59.331358,"time":"23:02:00","date":"2017-12-18","track":"3"},"JourneyDetailRef":{"ref":"1|4455|1|74|18122017"},"JourneyStatus":"P","Product":{"name":"TUNNELBANA  13","num":"20765","line":"13","catOut":"METRO   ","catIn":"MET","catCode":"1","catOutS":"MET","catOutL":"TUNNELBANA ","operatorCode":"SL","operator":"Storstockholms Lokaltrafik"},"idx":"0","name":"TUNNELBANA  13","number":"20765","category":"MET","type":"JNY","reachable":true,"direction":"Ropsten"}]},"TariffResult":{"fareSetItem":[{"fareItem":[{"name":"Reskassa","desc":"Helt pris","price":3000,"cur":"SEK"}],"name":"ONEWAY","desc":"SL"}]},"idx":0,"tripId":"C-0","ctxRecon":"T$A=1@O=Slussen@L=400102011@a=128@$A=1@O=T-Centralen@L=400101051@a=128@$201712182258$201712182302$        $","duration":"PT4M","checksum":"A26A97EE_4"}]}`
+
+	// JourneyFixture is the default response for EndpointJourney.
+	JourneyFixture = `{"Stops":{"Stop":[{"name":"Slussen","extId":"400102011","routeIdx":0,"lon":18.071491,"lat":59.319511,"depTime":"22:58:00","depDate":"2017-12-18","depTrack":"2"},{"name":"T-Centralen","extId":"400101051","routeIdx":2,"lon":18.061477,"lat":59.331358,"arrTime":"23:02:00","arrDate":"2017-12-18","arrTrack":"3"}]},"Names":{"Name":[{"Product":{"name":"TUNNELBANA  13","line":"13","catOutL":"TUNNELBANA "},"name":"TUNNELBANA  13","routeIdxFrom":0,"routeIdxTo":2}]},"Directions":{"Direction":[{"value":"Ropsten","routeIdxFrom":0,"routeIdxTo":2}]},"JourneyStatus":"P","ref":"1|4455|1|74|18122017"}`
+
+	// ReconstructionFixture is the default response for EndpointReconstruction.
+	ReconstructionFixture = TripFixture
+)
@@ -0,0 +1,288 @@
+// Package sltest provides a fake SL API server for integration tests.
+//
+// The server serves the typeahead, realtime departures and travel planner
+// endpoints from configurable fixtures, checks the key parameter, can
+// simulate errors, latency and quota responses and records the requests it
+// received.
+package sltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+// Endpoints served by the fake server, relative to the base url.
+const (
+	EndpointTypeahead      = "typeahead.json"
+	EndpointRealtime       = "realtimedeparturesV4.json"
+	EndpointTrip           = "TravelplannerV3/trip.json"
+	EndpointJourney        = "TravelplannerV3/journeydetail.json"
+	EndpointReconstruction = "TravelplannerV3/reconstruction.json"
+)
+
+// basePath is the path the fake API is served under, like the real SL API.
+const basePath = "/api2/"
+
+// SL API status codes used by the location and realtime endpoints.
+const (
+	StatusKeyUndefined     = 1001
+	StatusKeyInvalid       = 1002
+	StatusTooManyPerMinute = 1006
+	StatusTooManyPerMonth  = 1007
+)
+
+// Request represents a request received by the fake server.
+type Request struct {
+	Method   string
+	Endpoint string
+	Query    url.Values
+	Header   http.Header
+}
+
+// Key returns the key parameter of the request.
+func (r *Request) Key() string {
+	return r.Query.Get("key")
+}
+
+// failure represents a simulated http error.
+type failure struct {
+	status int
+	body   string
+}
+
+// Server represents a fake SL API server.
+type Server struct {
+	// URL is the base url of the fake API with a trailing slash.
+	URL string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]bool
+	fixtures map[string]string
+	messages map[string]string
+	failures map[string]*failure
+	latency  time.Duration
+	quota    int
+	requests []*Request
+}
+
+// NewServer starts and returns a new fake SL API server serving the default
+// fixtures. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{}
+	s.Reset()
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL + basePath
+
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a SL client configured to use the fake server.
+func (s *Server) Client() *sl.Client {
+	c := sl.NewClient(s.server.Client())
+	c.BaseURL, _ = url.Parse(s.URL)
+	return c
+}
+
+// Reset restores the default fixtures and removes all keys, simulations and
+// recorded requests.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = map[string]bool{}
+	s.fixtures = map[string]string{
+		EndpointTypeahead:      TypeaheadFixture,
+		EndpointRealtime:       RealtimeFixture,
+		EndpointTrip:           TripFixture,
+		EndpointJourney:        JourneyFixture,
+		EndpointReconstruction: ReconstructionFixture,
+	}
+	s.messages = map[string]string{}
+	s.failures = map[string]*failure{}
+	s.latency = 0
+	s.quota = 0
+	s.requests = nil
+}
+
+// SetKeys sets the keys accepted by the server. If no keys are set any
+// non-empty key is accepted.
+func (s *Server) SetKeys(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = map[string]bool{}
+	for _, k := range keys {
+		s.keys[k] = true
+	}
+}
+
+// SetFixture sets the response body served for the endpoint.
+func (s *Server) SetFixture(endpoint, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fixtures[endpoint] = body
+}
+
+// SetFixtureFile sets the response body served for the endpoint to the content of the file.
+func (s *Server) SetFixtureFile(endpoint, path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	s.SetFixture(endpoint, string(b))
+	return nil
+}
+
+// SetMessage makes the endpoint respond with an API error message, e.g.
+// "No trip found" or "Invalid SiteId". An empty message removes it.
+func (s *Server) SetMessage(endpoint, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(message) == 0 {
+		delete(s.messages, endpoint)
+		return
+	}
+
+	s.messages[endpoint] = message
+}
+
+// SetError makes the endpoint respond with the http status code and body.
+// A zero status removes the error.
+func (s *Server) SetError(endpoint string, status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status == 0 {
+		delete(s.failures, endpoint)
+		return
+	}
+
+	if len(body) == 0 {
+		body = http.StatusText(status)
+	}
+
+	s.failures[endpoint] = &failure{status: status, body: body}
+}
+
+// SetLatency sets the time to wait before responding.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = d
+}
+
+// SetQuota sets the number of requests accepted before the server responds
+// with quota exceeded. Zero means no limit.
+func (s *Server) SetQuota(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quota = n
+}
+
+// Requests returns the requests received by the server.
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := make([]*Request, len(s.requests))
+	copy(requests, s.requests)
+
+	return requests
+}
+
+// handle handles all requests to the fake server.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, basePath) {
+		http.NotFound(w, r)
+		return
+	}
+
+	endpoint := strings.TrimPrefix(r.URL.Path, basePath)
+	key := r.URL.Query().Get("key")
+
+	s.mu.Lock()
+	s.requests = append(s.requests, &Request{
+		Method:   r.Method,
+		Endpoint: endpoint,
+		Query:    r.URL.Query(),
+		Header:   r.Header,
+	})
+	fixture, ok := s.fixtures[endpoint]
+	message := s.messages[endpoint]
+	fail := s.failures[endpoint]
+	latency := s.latency
+	overQuota := s.quota > 0 && len(s.requests) > s.quota
+	validKey := len(key) > 0 && (len(s.keys) == 0 || s.keys[key])
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if fail != nil {
+		http.Error(w, fail.body, fail.status)
+		return
+	}
+
+	travelPlanner := strings.HasPrefix(endpoint, "TravelplannerV3/")
+
+	switch {
+	case len(key) == 0:
+		writeError(w, travelPlanner, StatusKeyUndefined, "API_AUTH", "Key is undefined")
+	case !validKey:
+		writeError(w, travelPlanner, StatusKeyInvalid, "API_AUTH", "Key is invalid")
+	case overQuota:
+		writeError(w, travelPlanner, StatusTooManyPerMonth, "API_QUOTA", "Too many requests per month")
+	case len(message) > 0:
+		writeError(w, travelPlanner, 0, "SVC_NO_RESULT", message)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, fixture)
+	}
+}
+
+// writeError writes an API error in the format used by the endpoint. The
+// location and realtime APIs use StatusCode and Message while the travel
+// planner uses errorCode and errorText.
+func writeError(w http.ResponseWriter, travelPlanner bool, status int, code, message string) {
+	var body interface{}
+	if travelPlanner {
+		body = map[string]interface{}{"errorCode": code, "errorText": message}
+	} else {
+		body = map[string]interface{}{"StatusCode": status, "Message": message, "ExecutionTime": 0, "ResponseData": nil}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,141 @@
+package sltest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frozzare/go-sl"
+)
+
+func TestServerFixtures(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	client := s.Client()
+
+	locations, err := client.Location.Search(context.Background(), &sl.LocationSearchOptions{
+		Key:          "XXXX",
+		SearchString: "Slussen",
+	})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if locations[0].SiteID != "9192" {
+		t.Errorf("Expected '9192' got %s", locations[0].SiteID)
+	}
+
+	s.SetFixture(EndpointRealtime, `{"StatusCode":0,"ResponseData":{"Buses":[{"LineNumber":"53"}]}}`)
+
+	realtime, err := client.Realtime.Search(context.Background(), &sl.RealtimeSearchOptions{
+		Key:    "XXXX",
+		SiteID: "1002",
+	})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if realtime.Buses[0].LineNumber != "53" {
+		t.Errorf("Expected '53' got %s", realtime.Buses[0].LineNumber)
+	}
+
+	trips, err := client.TravelPlanner.Trip(context.Background(), &sl.TripOptions{
+		Key:      "XXXX",
+		OriginID: "9192",
+		DestID:   "9001",
+	})
+	if err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if trips[0].LegList.Leg[0].Product.Line != "13" {
+		t.Errorf("Expected '13' got %s", trips[0].LegList.Leg[0].Product.Line)
+	}
+
+	requests := s.Requests()
+
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 requests got %d", len(requests))
+	}
+
+	if requests[1].Endpoint != EndpointRealtime || requests[1].Query.Get("siteId") != "1002" {
+		t.Errorf("Expected realtime request for site 1002 got %s %v", requests[1].Endpoint, requests[1].Query)
+	}
+
+	if requests[2].Key() != "XXXX" {
+		t.Errorf("Expected 'XXXX' got %s", requests[2].Key())
+	}
+}
+
+func TestServerKeys(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.SetKeys("valid")
+
+	_, err := s.Client().Realtime.Search(context.Background(), &sl.RealtimeSearchOptions{
+		Key:    "invalid",
+		SiteID: "1002",
+	})
+	if err == nil || err.Error() != "Key is invalid" {
+		t.Errorf("Expected 'Key is invalid' got %v", err)
+	}
+
+	_, err = s.Client().TravelPlanner.Journey(context.Background(), &sl.JourneyOptions{
+		Key: "invalid",
+		ID:  "1|4455|1|74|18122017",
+	})
+	if err == nil || err.Error() != "Key is invalid" {
+		t.Errorf("Expected 'Key is invalid' got %v", err)
+	}
+
+	_, err = s.Client().TravelPlanner.Journey(context.Background(), &sl.JourneyOptions{
+		Key: "valid",
+		ID:  "1|4455|1|74|18122017",
+	})
+	if err != nil {
+		t.Errorf("Expected nil got error: %v", err)
+	}
+}
+
+func TestServerSimulations(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	client := s.Client()
+	opt := func() *sl.LocationSearchOptions {
+		return &sl.LocationSearchOptions{Key: "XXXX", SearchString: "Slussen"}
+	}
+
+	s.SetMessage(EndpointTypeahead, "Invalid search string")
+	if _, err := client.Location.Search(context.Background(), opt()); err == nil || err.Error() != "Invalid search string" {
+		t.Errorf("Expected 'Invalid search string' got %v", err)
+	}
+	s.SetMessage(EndpointTypeahead, "")
+
+	s.SetError(EndpointTypeahead, http.StatusBadGateway, "")
+	if _, err := client.Location.Search(context.Background(), opt()); err == nil {
+		t.Errorf("Expected error got nil")
+	}
+	s.SetError(EndpointTypeahead, 0, "")
+
+	s.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Location.Search(ctx, opt()); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v got %v", context.DeadlineExceeded, err)
+	}
+	s.SetLatency(0)
+
+	s.Reset()
+	s.SetQuota(1)
+	if _, err := client.Location.Search(context.Background(), opt()); err != nil {
+		t.Errorf("Expected nil got error: %v", err)
+	}
+	if _, err := client.Location.Search(context.Background(), opt()); err == nil || !strings.Contains(err.Error(), "Too many requests") {
+		t.Errorf("Expected quota error got %v", err)
+	}
+}
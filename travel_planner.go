@@ -4,74 +4,193 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 )
 
-// travelPlannerEndpoint is the endpoint to the travel planner api.
-const travelPlannerEndpoint = "TravelplannerV3/%s.json"
+const (
+	// travelPlannerEndpoint is the endpoint to the travel planner api.
+	travelPlannerEndpoint = "TravelplannerV3/%s.json"
+
+	// dateTimeLayout is the layout of a date and time joined with a space in the travel planner api.
+	dateTimeLayout = "2006-01-02 15:04:05"
+)
 
 var (
 	ErrNoTripFound = errors.New("No trip found")
 )
 
+// LegStop represents the origin or destination of a trip leg.
+type LegStop struct {
+	Cancelled     bool    `json:"cancelled"`
+	Date          string  `json:"date"`
+	ExtID         string  `json:"extId"`
+	HasMainMast   bool    `json:"hasMainMast"`
+	ID            string  `json:"id"`
+	Lat           float64 `json:"lat"`
+	Lon           float64 `json:"lon"`
+	MainMastExtID string  `json:"mainMastExtId"`
+	MainMastID    string  `json:"mainMastId"`
+	Name          string  `json:"name"`
+	PrognosisType string  `json:"prognosisType"`
+	RtDate        string  `json:"rtDate"`
+	RtTime        string  `json:"rtTime"`
+	RtTrack       string  `json:"rtTrack"`
+	Time          string  `json:"time"`
+	Track         string  `json:"track"`
+	Type          string  `json:"type"`
+}
+
+// Delay returns the difference between the realtime and the planned time
+// or zero if no realtime prognosis exists.
+func (s *LegStop) Delay() time.Duration {
+	if len(s.RtTime) == 0 {
+		return 0
+	}
+
+	rtDate := s.RtDate
+	if len(rtDate) == 0 {
+		rtDate = s.Date
+	}
+
+	planned, err := time.Parse(dateTimeLayout, s.Date+" "+s.Time)
+	if err != nil {
+		return 0
+	}
+
+	realtime, err := time.Parse(dateTimeLayout, rtDate+" "+s.RtTime)
+	if err != nil {
+		return 0
+	}
+
+	return realtime.Sub(planned)
+}
+
+// Note represents a note attached to a trip leg.
+type Note struct {
+	Key          string `json:"key"`
+	Priority     int    `json:"priority"`
+	RouteIdxFrom int    `json:"routeIdxFrom"`
+	RouteIdxTo   int    `json:"routeIdxTo"`
+	Type         string `json:"type"`
+	Value        string `json:"value"`
+}
+
+// Message represents a HIM (disruption) message attached to a trip leg.
+type Message struct {
+	Act      bool   `json:"act"`
+	Category string `json:"category"`
+	EDate    string `json:"eDate"`
+	ETime    string `json:"eTime"`
+	Head     string `json:"head"`
+	ID       string `json:"id"`
+	Lead     string `json:"lead"`
+	Priority int    `json:"priority"`
+	Products int    `json:"products"`
+	SDate    string `json:"sDate"`
+	STime    string `json:"sTime"`
+	Text     string `json:"text"`
+}
+
+// Product represents the product (line and operator) used by a trip leg or journey.
+type Product struct {
+	Admin        string `json:"admin"`
+	CatCode      string `json:"catCode"`
+	CatIn        string `json:"catIn"`
+	CatOut       string `json:"catOut"`
+	CatOutL      string `json:"catOutL"`
+	CatOutS      string `json:"catOutS"`
+	Line         string `json:"line"`
+	Name         string `json:"name"`
+	Num          string `json:"num"`
+	Operator     string `json:"operator"`
+	OperatorCode string `json:"operatorCode"`
+}
+
+// Stop represents a stop passed by a journey or a trip leg.
+type Stop struct {
+	ArrCancelled     bool    `json:"arrCancelled"`
+	ArrDate          string  `json:"arrDate"`
+	ArrPrognosisType string  `json:"arrPrognosisType"`
+	ArrTime          string  `json:"arrTime"`
+	ArrTrack         string  `json:"arrTrack"`
+	Cancelled        bool    `json:"cancelled"`
+	DepCancelled     bool    `json:"depCancelled"`
+	DepDate          string  `json:"depDate"`
+	DepPrognosisType string  `json:"depPrognosisType"`
+	DepTime          string  `json:"depTime"`
+	DepTrack         string  `json:"depTrack"`
+	ExtID            string  `json:"extId"`
+	HasMainMast      bool    `json:"hasMainMast"`
+	ID               string  `json:"id"`
+	Lat              float64 `json:"lat"`
+	Lon              float64 `json:"lon"`
+	MainMastExtID    string  `json:"mainMastExtId"`
+	MainMastID       string  `json:"mainMastId"`
+	Name             string  `json:"name"`
+	RouteIdx         int     `json:"routeIdx"`
+	RtArrDate        string  `json:"rtArrDate"`
+	RtArrTime        string  `json:"rtArrTime"`
+	RtArrTrack       string  `json:"rtArrTrack"`
+	RtDepDate        string  `json:"rtDepDate"`
+	RtDepTime        string  `json:"rtDepTime"`
+	RtDepTrack       string  `json:"rtDepTrack"`
+}
+
+// Leg represents a leg of a trip.
+type Leg struct {
+	Destination      LegStop `json:"Destination"`
+	JourneyDetailRef struct {
+		Ref string `json:"ref"`
+	} `json:"JourneyDetailRef"`
+	JourneyStatus string `json:"JourneyStatus"`
+	Messages      struct {
+		Message []*Message `json:"Message"`
+	} `json:"Messages"`
+	Notes struct {
+		Note []*Note `json:"Note"`
+	} `json:"Notes"`
+	Origin        LegStop        `json:"Origin"`
+	Polyline      *Polyline      `json:"Polyline"`
+	PolylineGroup *PolylineGroup `json:"PolylineGroup"`
+	Product       Product        `json:"Product"`
+	Stops         struct {
+		Stop []*Stop `json:"Stop"`
+	} `json:"Stops"`
+	Cancelled     bool   `json:"cancelled"`
+	Category      string `json:"category"`
+	Direction     string `json:"direction"`
+	Idx           string `json:"idx"`
+	Name          string `json:"name"`
+	Number        string `json:"number"`
+	PartCancelled bool   `json:"partCancelled"`
+	Reachable     bool   `json:"reachable"`
+	Type          string `json:"type"`
+}
+
+// DepartureDelay returns the realtime departure delay from the leg origin.
+func (l *Leg) DepartureDelay() time.Duration {
+	return l.Origin.Delay()
+}
+
+// ArrivalDelay returns the realtime arrival delay at the leg destination.
+func (l *Leg) ArrivalDelay() time.Duration {
+	return l.Destination.Delay()
+}
+
+// IntermediateStops returns the stops passed between the leg origin and
+// destination. The stops are only included when Passlist is 1.
+func (l *Leg) IntermediateStops() []*Stop {
+	if len(l.Stops.Stop) <= 2 {
+		return nil
+	}
+
+	return l.Stops.Stop[1 : len(l.Stops.Stop)-1]
+}
+
 // Trip represents a trip.
 type Trip struct {
 	LegList struct {
-		Leg []struct {
-			Destination struct {
-				Date          string  `json:"date"`
-				ExtID         string  `json:"extId"`
-				HasMainMast   bool    `json:"hasMainMast"`
-				ID            string  `json:"id"`
-				Lat           float64 `json:"lat"`
-				Lon           float64 `json:"lon"`
-				MainMastExtID string  `json:"mainMastExtId"`
-				MainMastID    string  `json:"mainMastId"`
-				Name          string  `json:"name"`
-				PrognosisType string  `json:"prognosisType"`
-				Time          string  `json:"time"`
-				Track         string  `json:"track"`
-				Type          string  `json:"type"`
-			} `json:"Destination"`
-			JourneyDetailRef struct {
-				Ref string `json:"ref"`
-			} `json:"JourneyDetailRef"`
-			JourneyStatus string `json:"JourneyStatus"`
-			Origin        struct {
-				Date          string  `json:"date"`
-				ExtID         string  `json:"extId"`
-				HasMainMast   bool    `json:"hasMainMast"`
-				ID            string  `json:"id"`
-				Lat           float64 `json:"lat"`
-				Lon           float64 `json:"lon"`
-				MainMastExtID string  `json:"mainMastExtId"`
-				MainMastID    string  `json:"mainMastId"`
-				Name          string  `json:"name"`
-				PrognosisType string  `json:"prognosisType"`
-				Time          string  `json:"time"`
-				Track         string  `json:"track"`
-				Type          string  `json:"type"`
-			} `json:"Origin"`
-			Product struct {
-				Admin        string `json:"admin"`
-				CatCode      string `json:"catCode"`
-				CatIn        string `json:"catIn"`
-				CatOut       string `json:"catOut"`
-				CatOutL      string `json:"catOutL"`
-				CatOutS      string `json:"catOutS"`
-				Line         string `json:"line"`
-				Name         string `json:"name"`
-				Num          string `json:"num"`
-				Operator     string `json:"operator"`
-				OperatorCode string `json:"operatorCode"`
-			} `json:"Product"`
-			Category  string `json:"category"`
-			Direction string `json:"direction"`
-			Idx       string `json:"idx"`
-			Name      string `json:"name"`
-			Number    string `json:"number"`
-			Reachable bool   `json:"reachable"`
-			Type      string `json:"type"`
-		} `json:"Leg"`
+		Leg []*Leg `json:"Leg"`
 	} `json:"LegList"`
 	ServiceDays []struct {
 		PlanningPeriodBegin string `json:"planningPeriodBegin"`
@@ -109,6 +228,11 @@ type TripResponseData struct {
 	ScrF      string  `json:"scrF"`
 }
 
+// apiError implements errorResponse.
+func (r *TripResponseData) apiError() error {
+	return messageError(r.ErrorText, r.Message)
+}
+
 // TravelPlannerService handles communication with the travel planner related
 // methods of the SL API.
 //
@@ -202,6 +326,7 @@ type TripOptions struct {
 	OriginWalk string `url:"originWalk,omitempty"`
 
 	// Indicates whether stops / stations passed on the trip should be retrieved. Default 0.
+	// The stops are available with Leg.IntermediateStops.
 	Passlist int `url:"passlist,omitempty"`
 
 	// Indicates whether detailed routes should be calculated for the results. 0 or 1. Default is 0.
@@ -227,34 +352,29 @@ type TripOptions struct {
 
 // Trip does a trip request to SL API and response with the trip list or a error.
 func (s *TravelPlannerService) Trip(ctx context.Context, opt *TripOptions) ([]*Trip, error) {
-	if len(opt.Key) == 0 {
-		return nil, ErrNoKey
-	}
-
-	r, err := addOptions(fmt.Sprintf(travelPlannerEndpoint, "trip"), opt)
-	if err != nil {
-		return nil, err
-	}
+	trips, _, err := s.TripWithResponse(ctx, opt)
+	return trips, err
+}
 
-	req, err := s.client.NewRequest("GET", r, nil)
-	if err != nil {
-		return nil, err
+// TripWithResponse is like Trip but also returns the API response.
+func (s *TravelPlannerService) TripWithResponse(ctx context.Context, opt *TripOptions) ([]*Trip, *Response, error) {
+	if len(opt.Key) == 0 {
+		return nil, nil, ErrNoKey
 	}
 
-	var resp *TripResponseData
-	if _, err := s.client.Do(ctx, req, &resp); err != nil {
-		return nil, err
+	resp := &TripResponseData{}
+	call := &Call{
+		Service:  "travelplanner",
+		Method:   "trip",
+		Endpoint: fmt.Sprintf(travelPlannerEndpoint, "trip"),
+		Options:  opt,
+		Result:   resp,
 	}
-
-	if len(resp.ErrorText) > 0 {
-		return nil, errors.New(resp.ErrorText)
+	if err := s.client.call(ctx, call); err != nil {
+		return nil, newResponse(call), err
 	}
 
-	if len(resp.Message) > 0 {
-		return nil, errors.New(resp.Message)
-	}
-
-	return resp.Trip, nil
+	return resp.Trip, newResponse(call), nil
 }
 
 // Journey represents a journey.
@@ -272,53 +392,34 @@ type Journey struct {
 	JourneyStatus string `json:"JourneyStatus"`
 	Names         struct {
 		Name []struct {
-			Product struct {
-				Admin        string `json:"admin"`
-				CatCode      string `json:"catCode"`
-				CatIn        string `json:"catIn"`
-				CatOut       string `json:"catOut"`
-				CatOutL      string `json:"catOutL"`
-				CatOutS      string `json:"catOutS"`
-				Line         string `json:"line"`
-				Name         string `json:"name"`
-				Num          string `json:"num"`
-				Operator     string `json:"operator"`
-				OperatorCode string `json:"operatorCode"`
-			} `json:"Product"`
-			Category     string `json:"category"`
-			Name         string `json:"name"`
-			Number       string `json:"number"`
-			RouteIdxFrom int    `json:"routeIdxFrom"`
-			RouteIdxTo   int    `json:"routeIdxTo"`
+			Product      Product `json:"Product"`
+			Category     string  `json:"category"`
+			Name         string  `json:"name"`
+			Number       string  `json:"number"`
+			RouteIdxFrom int     `json:"routeIdxFrom"`
+			RouteIdxTo   int     `json:"routeIdxTo"`
 		} `json:"Name"`
 	} `json:"Names"`
-	ServiceDays []struct {
+	Polyline      *Polyline      `json:"Polyline"`
+	PolylineGroup *PolylineGroup `json:"PolylineGroup"`
+	ServiceDays   []struct {
 		SDaysB string `json:"sDaysB"`
 		SDaysI string `json:"sDaysI"`
 		SDaysR string `json:"sDaysR"`
 	} `json:"ServiceDays"`
 	Stops struct {
-		Stop []struct {
-			DepDate          string  `json:"depDate"`
-			DepPrognosisType string  `json:"depPrognosisType"`
-			DepTime          string  `json:"depTime"`
-			DepTrack         string  `json:"depTrack"`
-			ExtID            string  `json:"extId"`
-			HasMainMast      bool    `json:"hasMainMast"`
-			ID               string  `json:"id"`
-			Lat              float64 `json:"lat"`
-			Lon              float64 `json:"lon"`
-			MainMastExtID    string  `json:"mainMastExtId"`
-			MainMastID       string  `json:"mainMastId"`
-			Name             string  `json:"name"`
-			RouteIdx         int     `json:"routeIdx"`
-		} `json:"Stop"`
+		Stop []*Stop `json:"Stop"`
 	} `json:"Stops"`
 	LastPassRouteIdx int    `json:"lastPassRouteIdx"`
 	LastPassStopRef  int    `json:"lastPassStopRef"`
 	Ref              string `json:"ref"`
 }
 
+// apiError implements errorResponse.
+func (j *Journey) apiError() error {
+	return messageError(j.ErrorText, j.Message)
+}
+
 // JourneyOptions specifies optional parameters to the TravelPlannerService.Journey.
 type JourneyOptions struct {
 	// Trip date. Example: 2014-08-23. Default is today.
@@ -336,34 +437,29 @@ type JourneyOptions struct {
 
 // Journey does a journey request to SL API and response with the journey list or a error.
 func (s *TravelPlannerService) Journey(ctx context.Context, opt *JourneyOptions) (*Journey, error) {
-	if len(opt.Key) == 0 {
-		return nil, ErrNoKey
-	}
-
-	r, err := addOptions(fmt.Sprintf(travelPlannerEndpoint, "journeydetail"), opt)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := s.client.NewRequest("GET", r, nil)
-	if err != nil {
-		return nil, err
-	}
+	journey, _, err := s.JourneyWithResponse(ctx, opt)
+	return journey, err
+}
 
-	var resp *Journey
-	if _, err := s.client.Do(ctx, req, &resp); err != nil {
-		return nil, err
+// JourneyWithResponse is like Journey but also returns the API response.
+func (s *TravelPlannerService) JourneyWithResponse(ctx context.Context, opt *JourneyOptions) (*Journey, *Response, error) {
+	if len(opt.Key) == 0 {
+		return nil, nil, ErrNoKey
 	}
 
-	if len(resp.ErrorText) > 0 {
-		return nil, errors.New(resp.ErrorText)
+	resp := &Journey{}
+	call := &Call{
+		Service:  "travelplanner",
+		Method:   "journey",
+		Endpoint: fmt.Sprintf(travelPlannerEndpoint, "journeydetail"),
+		Options:  opt,
+		Result:   resp,
 	}
-
-	if len(resp.Message) > 0 {
-		return nil, errors.New(resp.Message)
+	if err := s.client.call(ctx, call); err != nil {
+		return nil, newResponse(call), err
 	}
 
-	return resp, nil
+	return resp, newResponse(call), nil
 }
 
 // ReconstructionOptions specifies optional parameters to the TravelPlannerService.Reconstruction.
@@ -383,36 +479,31 @@ type ReconstructionOptions struct {
 
 // Reconstruction does a reconstruction request to SL API and response with a trip or a error.
 func (s *TravelPlannerService) Reconstruction(ctx context.Context, opt *ReconstructionOptions) (*Trip, error) {
-	if len(opt.Key) == 0 {
-		return nil, ErrNoKey
-	}
-
-	r, err := addOptions(fmt.Sprintf(travelPlannerEndpoint, "reconstruction"), opt)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := s.client.NewRequest("GET", r, nil)
-	if err != nil {
-		return nil, err
-	}
+	trip, _, err := s.ReconstructionWithResponse(ctx, opt)
+	return trip, err
+}
 
-	var resp *TripResponseData
-	if _, err := s.client.Do(ctx, req, &resp); err != nil {
-		return nil, err
+// ReconstructionWithResponse is like Reconstruction but also returns the API response.
+func (s *TravelPlannerService) ReconstructionWithResponse(ctx context.Context, opt *ReconstructionOptions) (*Trip, *Response, error) {
+	if len(opt.Key) == 0 {
+		return nil, nil, ErrNoKey
 	}
 
-	if len(resp.ErrorText) > 0 {
-		return nil, errors.New(resp.ErrorText)
+	resp := &TripResponseData{}
+	call := &Call{
+		Service:  "travelplanner",
+		Method:   "reconstruction",
+		Endpoint: fmt.Sprintf(travelPlannerEndpoint, "reconstruction"),
+		Options:  opt,
+		Result:   resp,
 	}
-
-	if len(resp.Message) > 0 {
-		return nil, errors.New(resp.Message)
+	if err := s.client.call(ctx, call); err != nil {
+		return nil, newResponse(call), err
 	}
 
 	if len(resp.Trip) == 0 {
-		return nil, ErrNoTripFound
+		return nil, newResponse(call), ErrNoTripFound
 	}
 
-	return resp.Trip[0], nil
+	return resp.Trip[0], newResponse(call), nil
 }
@@ -2,9 +2,11 @@ package sl
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestTravelPlannerTrip(t *testing.T) {
@@ -76,3 +78,63 @@ func TestTravelPlannerReconstruction(t *testing.T) {
 		t.Errorf("Expected 'BUSS  54' got %s", trip.LegList.Leg[0].Name)
 	}
 }
+
+func TestLegIntermediateStops(t *testing.T) {
+	var leg *Leg
+	if err := json.Unmarshal([]byte(`{"name":"TUNNELBANA  13","Stops":{"Stop":[{"name":"Slussen","extId":"400102011","routeIdx":10,"depTime":"22:58:00","depDate":"2017-12-18","depTrack":"2"},{"name":"Gamla stan","extId":"400101031","routeIdx":11,"arrTime":"22:59:00","arrDate":"2017-12-18","depTime":"22:59:00","depDate":"2017-12-18","rtDepTime":"23:00:00","rtDepDate":"2017-12-18","depPrognosisType":"PROGNOSED"},{"name":"T-Centralen","extId":"400101051","routeIdx":12,"arrTime":"23:02:00","arrDate":"2017-12-18","arrTrack":"3","cancelled":true}]}}`), &leg); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	stops := leg.IntermediateStops()
+
+	if len(stops) != 1 {
+		t.Fatalf("Expected 1 stop got %d", len(stops))
+	}
+
+	if stops[0].Name != "Gamla stan" {
+		t.Errorf("Expected 'Gamla stan' got %s", stops[0].Name)
+	}
+
+	if stops[0].RtDepTime != "23:00:00" {
+		t.Errorf("Expected '23:00:00' got %s", stops[0].RtDepTime)
+	}
+
+	if !leg.Stops.Stop[2].Cancelled {
+		t.Errorf("Expected last stop to be cancelled")
+	}
+
+	if stops := (&Leg{}).IntermediateStops(); stops != nil {
+		t.Errorf("Expected nil got %v", stops)
+	}
+}
+
+func TestLegDelay(t *testing.T) {
+	var leg *Leg
+	if err := json.Unmarshal([]byte(`{"Origin":{"name":"Centralen (Klarabergsviad.)","time":"23:59:00","date":"2017-12-19","rtTime":"00:01:00","rtDate":"2017-12-20","rtTrack":"S"},"Destination":{"name":"Sergels torg","time":"09:13:00","date":"2017-12-19","cancelled":true},"Messages":{"Message":[{"id":"HIM_FREETEXT_123","act":true,"head":"Inställd avgång","text":"Bussen är inställd.","priority":50}]},"Notes":{"Note":[{"value":"Endast påstigning","key":"text.realtime.stop.entry","type":"R"}]},"name":"BUSS  54","partCancelled":true}`), &leg); err != nil {
+		t.Fatalf("Expected nil got error: %v", err)
+	}
+
+	if delay := leg.DepartureDelay(); delay != 2*time.Minute {
+		t.Errorf("Expected 2m0s got %s", delay)
+	}
+
+	if delay := leg.ArrivalDelay(); delay != 0 {
+		t.Errorf("Expected 0s got %s", delay)
+	}
+
+	if leg.Origin.RtTrack != "S" {
+		t.Errorf("Expected 'S' got %s", leg.Origin.RtTrack)
+	}
+
+	if !leg.Destination.Cancelled || !leg.PartCancelled {
+		t.Errorf("Expected leg to be part cancelled")
+	}
+
+	if leg.Messages.Message[0].Head != "Inställd avgång" {
+		t.Errorf("Expected 'Inställd avgång' got %s", leg.Messages.Message[0].Head)
+	}
+
+	if leg.Notes.Note[0].Value != "Endast påstigning" {
+		t.Errorf("Expected 'Endast påstigning' got %s", leg.Notes.Note[0].Value)
+	}
+}